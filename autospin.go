@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxAutoSpins caps how many spins one /slot autospin plays.
+const maxAutoSpins = 50
+
+// minAutoSpins is the autospin option's minimum; an autospin of 1 is a plain spin.
+var minAutoSpins float64 = 1
+
+// autoSpinDelay paces autospin so message edits stay under Discord's rate limits.
+const autoSpinDelay = 1500 * time.Millisecond
+
+// autoSpinShown is how many per-spin summaries the embed keeps.
+const autoSpinShown = 10
+
+// Running autospins by user; the Stop button closes the channel.
+var (
+	autoSpinMutex sync.Mutex
+	autoSpins     = make(map[string]chan struct{})
+)
+
+// autoSpin is what /slot autospin was asked to do.
+type autoSpin struct {
+	Count     int
+	StopOnWin bool
+	LossLimit Money // stop once the net loss reaches it, 0 for none
+	StopBelow Money // stop once the balance drops below it, 0 for none
+}
+
+// stopReason says why autospin ends after a spin, "" to keep going.
+func (a autoSpin) stopReason(spun int, outcome, net, balance, stake Money) string {
+	switch {
+	case a.StopOnWin && outcome > 0:
+		return "🎉 Stopped on a win"
+	case a.LossLimit > 0 && -net >= a.LossLimit:
+		return fmt.Sprintf("🛑 Loss limit of $%s reached", a.LossLimit)
+	case a.StopBelow > 0 && balance < a.StopBelow:
+		return fmt.Sprintf("🛑 Balance fell below $%s", a.StopBelow)
+	case spun >= a.Count:
+		return fmt.Sprintf("✅ All %d spins played", a.Count)
+	case balance < stake:
+		return "💸 Not enough balance for another spin"
+	}
+	return ""
+}
+
+// spinSummary is one compact line per spin, e.g. "`#3` 🟢 +$7.70".
+func spinSummary(n int, spin *paidSpin) string {
+	line := fmt.Sprintf("`#%d` ", n)
+	switch {
+	case spin.Outcome > 0:
+		line += fmt.Sprintf("🟢 +$%s", spin.Outcome)
+	case spin.Outcome < 0:
+		line += fmt.Sprintf("🔴 -$%s", spin.Outcome.Abs())
+	default:
+		line += "⚪ $0.00"
+	}
+	if spin.Jackpot != nil && spin.Jackpot.Win {
+		line += " 🏆 Jackpot"
+	}
+	if spin.Session != nil && len(spin.Session.Spins) > 1 {
+		line += fmt.Sprintf(" 🎁 %d free spins", len(spin.Session.Spins)-1)
+	}
+	return line
+}
+
+// autoSlot plays up to a.Count spins of m one after another in a single
+// message, holding the user's active slot game throughout. The guard is
+// refreshed every spin, since a run can outlast abandonAfter.
+func autoSlot(s *discordgo.Session, i *discordgo.InteractionCreate, m *SlotMachine, betAmount Money, a autoSpin) {
+	userID, balance, ok := beginSlot(s, i, m, betAmount)
+	if !ok {
+		return
+	}
+	defer endSlot(userID)
+
+	stop := make(chan struct{})
+	autoSpinMutex.Lock()
+	autoSpins[userID] = stop
+	autoSpinMutex.Unlock()
+	defer func() {
+		autoSpinMutex.Lock()
+		delete(autoSpins, userID)
+		autoSpinMutex.Unlock()
+	}()
+
+	content := fmt.Sprintf("> **<@%s>'s Autospin**", userID)
+	stopRow := []discordgo.MessageComponent{discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{&discordgo.Button{
+			Label:    "Stop ⏹️",
+			Style:    discordgo.DangerButton,
+			CustomID: "autospinStop_" + userID,
+		}},
+	}}
+
+	var (
+		summaries []string
+		last      *paidSpin
+		net       Money
+		reason    string
+	)
+	for n := 1; reason == ""; n++ {
+		spin, err := settleSpin(m, userID, betAmount)
+		if err == ErrInsufficientBalance {
+			if last == nil {
+				respondEphemeral(s, i, "❌ Insufficient balance or concurrent transaction!", nil)
+				return
+			}
+			reason = "💸 Not enough balance for another spin"
+			break
+		} else if err != nil {
+			log.Printf("DB error autospinning for user %s: %v", userID, err)
+			if last == nil {
+				respondEphemeral(s, i, "❌ Database error!", nil)
+				return
+			}
+			reason = "⚠️ Database error, autospin stopped"
+			break
+		}
+		if err := store.TouchActiveSlot(userID); err != nil {
+			log.Printf("DB error refreshing slot guard of %s: %v", userID, err)
+		}
+		last = spin
+		net += spin.Outcome
+		balance += spin.Outcome
+		summaries = append(summaries, spinSummary(n, spin))
+		reason = a.stopReason(n, spin.Outcome, net, balance, m.Stake(betAmount))
+
+		if reason == "" {
+			embed, files := autoSpinEmbed(s, m, last, summaries, n, a.Count, net, balance)
+			if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Content:     &content,
+				Embeds:      &[]*discordgo.MessageEmbed{embed},
+				Files:       files,
+				Attachments: replaceAttachments(files),
+				Components:  &stopRow,
+			}); err != nil {
+				log.Printf("Failed to edit autospin: %v", err)
+			}
+			select {
+			case <-stop:
+				reason = "⏹️ Stopped"
+			case <-time.After(autoSpinDelay):
+			}
+		}
+	}
+
+	final, files := autoSpinEmbed(s, m, last, summaries, len(summaries), a.Count, net, balance)
+	final.Color = 0xFF0000 // red
+	if net > 0 {
+		final.Color = 0x00FF00 // green
+	}
+	final.Footer = &discordgo.MessageEmbedFooter{Text: reason}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:     &content,
+		Embeds:      &[]*discordgo.MessageEmbed{final},
+		Files:       files,
+		Attachments: replaceAttachments(files),
+		Components:  &[]discordgo.MessageComponent{},
+	}); err != nil {
+		log.Printf("Failed to edit autospin: %v", err)
+	}
+}
+
+// autoSpinEmbed shows the last spin's reels above the latest summaries,
+// returning the reel image to attach if there is one.
+func autoSpinEmbed(s *discordgo.Session, m *SlotMachine, last *paidSpin, summaries []string, spun, count int, net, balance Money) (*discordgo.MessageEmbed, []*discordgo.File) {
+	if len(summaries) > autoSpinShown {
+		summaries = summaries[len(summaries)-autoSpinShown:]
+	}
+
+	netValue := fmt.Sprintf("`+$%s`", net)
+	if net < 0 {
+		netValue = fmt.Sprintf("`-$%s`", net.Abs())
+	}
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "💰 Bet", Value: fmt.Sprintf("`$%s`", last.Bet), Inline: true},
+		{Name: "📊 Net", Value: netValue, Inline: true},
+	}
+	if last.Jackpot != nil {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "🏆 Jackpot", Value: fmt.Sprintf("`$%s`", last.Jackpot.Pool), Inline: true})
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{Value: fmt.Sprintf("👤 Balance `$%s`", balance), Inline: false})
+
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("🎰 %s · Autospin %d/%d 🎰", m.Name, spun, count),
+		Color:  0xFFFF00, // yellow
+		Fields: fields,
+	}
+	var files []*discordgo.File
+	if last.Session != nil {
+		embed.Description = m.formatGrid(last.Session.Spins[0].Grid, len(m.Reels))
+	} else {
+		files = showReels(s, embed, m.reelTiles(last.Line, len(last.Line)+1))
+	}
+	embed.Description += "\n\n" + strings.Join(summaries, "\n")
+	return embed, files
+}
+
+// stopAutoSpin handles the Stop button; only the player who started the
+// autospin can stop it.
+func stopAutoSpin(s *discordgo.Session, i *discordgo.InteractionCreate, userID string) {
+	if strings.TrimPrefix(i.MessageComponentData().CustomID, "autospinStop_") != userID {
+		respondEphemeral(s, i, "❌ This isn't your autospin!", nil)
+		return
+	}
+	autoSpinMutex.Lock()
+	if stop, ok := autoSpins[userID]; ok {
+		close(stop)
+		delete(autoSpins, userID)
+	}
+	autoSpinMutex.Unlock()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("failed to acknowledge autospin stop: %v", err)
+	}
+}
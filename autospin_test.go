@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutoSpinStopReason(t *testing.T) {
+	a := autoSpin{Count: 10, StopOnWin: true, LossLimit: 500, StopBelow: 2000}
+	for _, tt := range []struct {
+		name                         string
+		a                            autoSpin
+		spun                         int
+		outcome, net, balance, stake Money
+		want                         string // prefix, "" = keep going
+	}{
+		{"losing spin", a, 3, -100, -300, 5000, 100, ""},
+		{"win", a, 3, 250, -50, 5000, 100, "🎉"},
+		{"win without stop_on_win", autoSpin{Count: 10}, 3, 250, -50, 5000, 100, ""},
+		{"loss limit", a, 5, -100, -500, 5000, 100, "🛑 Loss limit"},
+		{"balance floor", a, 5, -100, -400, 1999, 100, "🛑 Balance"},
+		{"last spin", a, 10, -100, -400, 5000, 100, "✅ All 10"},
+		{"can't afford the next spin", autoSpin{Count: 10}, 4, -100, -400, 50, 100, "💸"},
+		{"a push isn't a win", a, 3, 0, -200, 5000, 100, ""},
+	} {
+		got := tt.a.stopReason(tt.spun, tt.outcome, tt.net, tt.balance, tt.stake)
+		if tt.want == "" && got != "" || !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%s: stopReason = %q; want %q…", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpinSummary(t *testing.T) {
+	for _, tt := range []struct {
+		spin *paidSpin
+		want string
+	}{
+		{&paidSpin{Outcome: 770}, "`#3` 🟢 +$7.70"},
+		{&paidSpin{Outcome: -100}, "`#3` 🔴 -$1.00"},
+		{&paidSpin{Outcome: 1500, Jackpot: &JackpotDraw{Win: true, Paid: 1500}}, "`#3` 🟢 +$15.00 🏆 Jackpot"},
+		{&paidSpin{Outcome: 0, Session: &SlotSession{Spins: make([]SpinResult, 9)}}, "`#3` ⚪ $0.00 🎁 8 free spins"},
+	} {
+		if got := spinSummary(3, tt.spin); got != tt.want {
+			t.Errorf("spinSummary = %q; want %q", got, tt.want)
+		}
+	}
+}
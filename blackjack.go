@@ -0,0 +1,604 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// blackjackDecks is how many decks a blackjack shoe is shuffled from.
+const blackjackDecks = 6
+
+// maxBlackjackHands caps how many hands splitting can make.
+const maxBlackjackHands = 4
+
+// errBlackjackMove is returned for a move the hand doesn't allow right now,
+// e.g. doubling after a hit.
+var errBlackjackMove = errors.New("move not allowed")
+
+// card is one card of a shoe: rank card%13 (ace first, king last), suit card/13%4.
+type card int
+
+var (
+	cardRanks = [...]string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+	cardSuits = [...]string{"♠", "♥", "♦", "♣"}
+)
+
+// rank is 1 for an ace up to 13 for a king.
+func (c card) rank() int { return int(c)%13 + 1 }
+
+// value is what the card counts, aces as 1.
+func (c card) value() int { return min(c.rank(), 10) }
+
+func (c card) String() string { return cardRanks[c.rank()-1] + cardSuits[int(c)/13%4] }
+
+// drawShoe shuffles decks full decks into a shoe, dealt from the front.
+func drawShoe(rng RNG, decks int) []card {
+	shoe := make([]card, 52*decks)
+	for k := range shoe {
+		shoe[k] = card(k)
+	}
+	// Fisher-Yates, each position drawn from the cards not placed yet
+	for k := len(shoe) - 1; k > 0; k-- {
+		j := rng.Intn(k + 1)
+		shoe[k], shoe[j] = shoe[j], shoe[k]
+	}
+	return shoe
+}
+
+// handTotal is the best total of cards and whether an ace counts 11 in it.
+func handTotal(cards []card) (total int, soft bool) {
+	ace := false
+	for _, c := range cards {
+		total += c.value()
+		ace = ace || c.rank() == 1
+	}
+	if ace && total+10 <= 21 {
+		return total + 10, true
+	}
+	return total, false
+}
+
+// formatCards lists cards like "A♠ 10♥".
+func formatCards(cards []card) string {
+	names := make([]string, len(cards))
+	for k, c := range cards {
+		names[k] = c.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// blackjackHand is one of the player's hands; splitting adds more.
+type blackjackHand struct {
+	Cards   []card
+	Bet     Money
+	Doubled bool
+	Split   bool // played from a split, so 21 on two cards isn't a blackjack
+	Done    bool // stood, doubled, busted or reached 21
+}
+
+func (h *blackjackHand) total() int {
+	total, _ := handTotal(h.Cards)
+	return total
+}
+
+func (h *blackjackHand) blackjack() bool {
+	return len(h.Cards) == 2 && !h.Split && h.total() == 21
+}
+
+// blackjackPhase is where a round is: waiting for the insurance decision,
+// waiting for the player's moves, or over and ready to settle.
+type blackjackPhase string
+
+const (
+	phaseInsurance blackjackPhase = "insurance"
+	phasePlay      blackjackPhase = "play"
+	phaseOver      blackjackPhase = "over"
+)
+
+// BlackjackGame is a round of blackjack against the dealer, saved in
+// active_games under type "blackjack" between moves. The dealer stands on
+// all 17s, blackjack pays 3:2 and insurance 2:1. Every stake, the opening bet
+// and whatever doubling, splitting and insurance add, is held in escrow.
+type BlackjackGame struct {
+	UserID    string
+	UserName  string
+	Bet       Money  // opening bet, each split hand stakes it again
+	Shoe      []card // cards not dealt yet
+	Dealt     int    // cards dealt from the shoe so far
+	Dealer    []card // the first card is the upcard
+	Hands     []*blackjackHand
+	Current   int // hand being played
+	Insurance Money
+	Phase     blackjackPhase
+	SeedID    int64 // provably-fair seed pair the shoe was shuffled from
+	Nonce     int64
+	Version   int64 `json:"-"` // active_games row version the game was loaded at
+	GameID    int64 `json:"-"` // games row id once settled
+}
+
+// newBlackjackGame deals a round from shoe: a card to the player, the upcard,
+// the player's second card and the hole card.
+func newBlackjackGame(userID, userName string, bet Money, shoe []card) *BlackjackGame {
+	game := &BlackjackGame{
+		UserID:   userID,
+		UserName: userName,
+		Bet:      bet,
+		Shoe:     shoe,
+		Hands:    []*blackjackHand{{Bet: bet}},
+	}
+	hand := game.Hands[0]
+	hand.Cards = append(hand.Cards, game.draw())
+	game.Dealer = append(game.Dealer, game.draw())
+	hand.Cards = append(hand.Cards, game.draw())
+	game.Dealer = append(game.Dealer, game.draw())
+
+	if game.Dealer[0].rank() == 1 {
+		game.Phase = phaseInsurance
+	} else {
+		game.peek()
+	}
+	return game
+}
+
+func (game *BlackjackGame) draw() card {
+	c := game.Shoe[0]
+	game.Shoe = game.Shoe[1:]
+	game.Dealt++
+	return c
+}
+
+func (game *BlackjackGame) dealerBlackjack() bool {
+	total, _ := handTotal(game.Dealer)
+	return len(game.Dealer) == 2 && total == 21
+}
+
+// peek ends the round straight away if the dealer or the player has a
+// blackjack, and otherwise hands over to the player.
+func (game *BlackjackGame) peek() {
+	if game.dealerBlackjack() || game.Hands[0].blackjack() {
+		game.Phase = phaseOver
+		return
+	}
+	game.Phase = phasePlay
+}
+
+// stake is everything the player has in escrow for the round.
+func (game *BlackjackGame) stake() Money {
+	stake := game.Insurance
+	for _, h := range game.Hands {
+		stake += h.Bet
+	}
+	return stake
+}
+
+// insure settles the insurance offer against an ace upcard, returning the
+// stake taking it adds.
+func (game *BlackjackGame) insure(take bool) (Money, error) {
+	if game.Phase != phaseInsurance {
+		return 0, errBlackjackMove
+	}
+	if take {
+		game.Insurance = game.Bet / 2
+	}
+	game.peek()
+	return game.Insurance, nil
+}
+
+// hand is the hand being played, nil once the round is out of the player's hands.
+func (game *BlackjackGame) hand() *blackjackHand {
+	if game.Phase != phasePlay {
+		return nil
+	}
+	return game.Hands[game.Current]
+}
+
+func (game *BlackjackGame) canDouble() bool {
+	h := game.hand()
+	return h != nil && len(h.Cards) == 2
+}
+
+func (game *BlackjackGame) canSplit() bool {
+	h := game.hand()
+	return h != nil && len(h.Cards) == 2 && h.Cards[0].value() == h.Cards[1].value() && len(game.Hands) < maxBlackjackHands
+}
+
+func (game *BlackjackGame) hit() error {
+	h := game.hand()
+	if h == nil {
+		return errBlackjackMove
+	}
+	h.Cards = append(h.Cards, game.draw())
+	h.Done = h.total() >= 21
+	game.next()
+	return nil
+}
+
+func (game *BlackjackGame) stand() error {
+	h := game.hand()
+	if h == nil {
+		return errBlackjackMove
+	}
+	h.Done = true
+	game.next()
+	return nil
+}
+
+// double doubles the hand's bet for exactly one more card, returning the added stake.
+func (game *BlackjackGame) double() (Money, error) {
+	if !game.canDouble() {
+		return 0, errBlackjackMove
+	}
+	h := game.hand()
+	raise := h.Bet
+	h.Bet += raise
+	h.Doubled, h.Done = true, true
+	h.Cards = append(h.Cards, game.draw())
+	game.next()
+	return raise, nil
+}
+
+// split turns a pair into two hands with a card dealt to each, returning the
+// added stake. Split aces get one card each and can't be played further.
+func (game *BlackjackGame) split() (Money, error) {
+	if !game.canSplit() {
+		return 0, errBlackjackMove
+	}
+	h := game.hand()
+	other := &blackjackHand{Cards: []card{h.Cards[1]}, Bet: h.Bet, Split: true}
+	h.Cards, h.Split = h.Cards[:1], true
+	game.Hands = append(game.Hands[:game.Current+1], append([]*blackjackHand{other}, game.Hands[game.Current+1:]...)...)
+
+	aces := h.Cards[0].rank() == 1
+	for _, hand := range []*blackjackHand{h, other} {
+		hand.Cards = append(hand.Cards, game.draw())
+		hand.Done = aces || hand.total() == 21
+	}
+	game.next()
+	return other.Bet, nil
+}
+
+// next moves on past finished hands, and once none is left plays the
+// dealer's hand if any of the player's is still standing.
+func (game *BlackjackGame) next() {
+	for game.Current < len(game.Hands) && game.Hands[game.Current].Done {
+		game.Current++
+	}
+	if game.Current < len(game.Hands) {
+		return
+	}
+	game.Current = len(game.Hands) - 1
+	game.Phase = phaseOver
+	for _, h := range game.Hands {
+		if h.total() <= 21 {
+			game.dealerPlay()
+			break
+		}
+	}
+}
+
+// dealerPlay draws to the dealer until 17 or more, standing on soft 17.
+func (game *BlackjackGame) dealerPlay() {
+	for {
+		if total, _ := handTotal(game.Dealer); total >= 17 {
+			return
+		}
+		game.Dealer = append(game.Dealer, game.draw())
+	}
+}
+
+// standAll declines insurance and stands on every hand left, playing the
+// round out as the player would by walking away.
+func (game *BlackjackGame) standAll() {
+	if game.Phase == phaseInsurance {
+		game.insure(false)
+	}
+	if game.Phase != phasePlay {
+		return
+	}
+	for _, h := range game.Hands {
+		h.Done = true
+	}
+	game.next()
+}
+
+// handPayout is what a finished hand returns, its stake included.
+func (game *BlackjackGame) handPayout(h *blackjackHand) Money {
+	dealer, _ := handTotal(game.Dealer)
+	total := h.total()
+	switch {
+	case h.blackjack() && game.dealerBlackjack():
+		return h.Bet
+	case h.blackjack():
+		return h.Bet + h.Bet*3/2
+	case game.dealerBlackjack(), total > 21:
+		return 0
+	case dealer > 21 || total > dealer:
+		return 2 * h.Bet
+	case total == dealer:
+		return h.Bet
+	}
+	return 0
+}
+
+// payout is what the finished round returns in all, stakes included.
+func (game *BlackjackGame) payout() Money {
+	var paid Money
+	if game.dealerBlackjack() {
+		paid += 3 * game.Insurance
+	}
+	for _, h := range game.Hands {
+		paid += game.handPayout(h)
+	}
+	return paid
+}
+
+// result settles the finished round, closing it and releasing its escrowed stakes.
+func (game *BlackjackGame) result() GameResult {
+	return GameResult{
+		UserID:      game.UserID,
+		GameType:    "blackjack",
+		Bet:         game.stake(),
+		Outcome:     game.payout() - game.stake(),
+		CloseActive: true,
+		Escrowed:    true,
+		Version:     game.Version,
+		SeedID:      game.SeedID,
+		Nonce:       game.Nonce,
+		Params:      fmt.Sprintf("decks=%d,cards=%d", blackjackDecks, game.Dealt),
+	}
+}
+
+// settleBlackjack logs a finished round and pays it out in one transaction.
+func settleBlackjack(game *BlackjackGame) error {
+	gameID, err := store.SettleGame(game.result())
+	if err != nil {
+		return err
+	}
+	game.GameID = gameID
+	return nil
+}
+
+// blackjackStatus is the message showing game; balance is the player's
+// balance once the round is where it is now.
+func blackjackStatus(game *BlackjackGame, balance Money) string {
+	over := game.Phase == phaseOver
+	status := fmt.Sprintf("> **<@%s>'s Blackjack**\n", game.UserID)
+	status += fmt.Sprintf("👤 Balance: %s\n", balance)
+	status += fmt.Sprintf("💰 Bet: %s\n", game.stake())
+
+	if over {
+		dealer, _ := handTotal(game.Dealer)
+		status += fmt.Sprintf("🤵 Dealer: %s (%s)\n", formatCards(game.Dealer), pick(game.dealerBlackjack(), "blackjack", fmt.Sprint(dealer)))
+	} else {
+		status += fmt.Sprintf("🤵 Dealer: %s 🂠\n", game.Dealer[0])
+	}
+
+	for k, h := range game.Hands {
+		total, soft := handTotal(h.Cards)
+		desc := fmt.Sprint(total)
+		switch {
+		case h.blackjack():
+			desc = "blackjack"
+		case total > 21:
+			desc = "bust"
+		case soft && !h.Done:
+			desc = "soft " + desc
+		}
+		label := "🫵 Hand"
+		if len(game.Hands) > 1 {
+			label += fmt.Sprintf(" %d", k+1)
+		}
+		status += fmt.Sprintf("%s: %s (%s)", label, formatCards(h.Cards), desc)
+		if h.Doubled {
+			status += " ×2"
+		}
+		switch {
+		case over:
+			status += " " + handOutcome(h.Bet, game.handPayout(h))
+		case game.Phase == phasePlay && k == game.Current && len(game.Hands) > 1:
+			status += " ◀️"
+		}
+		status += "\n"
+	}
+	if game.Insurance > 0 {
+		status += fmt.Sprintf("🛡️ Insurance: %s\n", game.Insurance)
+	}
+
+	switch {
+	case game.Phase == phaseInsurance:
+		status += fmt.Sprintf("🛡️ The dealer shows an ace. Insure for %s?\n", game.Bet/2)
+	case over:
+		if net := game.payout() - game.stake(); net >= 0 {
+			status += fmt.Sprintf("💵 Profit: +%s\n", net)
+		} else {
+			status += fmt.Sprintf("💸 Loss: %s\n", -net)
+		}
+		if game.GameID != 0 {
+			status += fmt.Sprintf("🎲 Game #%d (`/verify %d`)\n", game.GameID, game.GameID)
+		}
+	}
+	return status
+}
+
+// handOutcome describes how a hand staking bet did from what it paid back.
+func handOutcome(bet, paid Money) string {
+	switch {
+	case paid > bet:
+		return fmt.Sprintf("✅ +%s", paid-bet)
+	case paid == bet:
+		return "🤝 push"
+	}
+	return "❌ lost"
+}
+
+// blackjackButtons are the moves game allows, or Play Again once it's over.
+// Button ids are bj_<move>_<user>.
+func blackjackButtons(game *BlackjackGame) []discordgo.MessageComponent {
+	id := func(move string) string { return fmt.Sprintf("bj_%s_%s", move, game.UserID) }
+
+	var buttons []discordgo.MessageComponent
+	switch game.Phase {
+	case phaseInsurance:
+		buttons = []discordgo.MessageComponent{
+			discordgo.Button{Label: fmt.Sprintf("🛡️ Insure (%s)", game.Bet/2), Style: discordgo.PrimaryButton, CustomID: id("insure"), Disabled: game.Bet/2 == 0},
+			discordgo.Button{Label: "No insurance", Style: discordgo.SecondaryButton, CustomID: id("noinsure")},
+		}
+	case phasePlay:
+		buttons = []discordgo.MessageComponent{
+			discordgo.Button{Label: "👆 Hit", Style: discordgo.PrimaryButton, CustomID: id("hit")},
+			discordgo.Button{Label: "✋ Stand", Style: discordgo.SecondaryButton, CustomID: id("stand")},
+			discordgo.Button{Label: "💰 Double", Style: discordgo.SuccessButton, CustomID: id("double"), Disabled: !game.canDouble()},
+			discordgo.Button{Label: "✂️ Split", Style: discordgo.SuccessButton, CustomID: id("split"), Disabled: !game.canSplit()},
+		}
+	default:
+		buttons = []discordgo.MessageComponent{
+			discordgo.Button{Label: "Play Again", Style: discordgo.PrimaryButton, CustomID: fmt.Sprintf("bjplayagain_%s", game.Bet)},
+		}
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+}
+
+// startBlackjack handles /blackjack and the Play Again button.
+func startBlackjack(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, betAmount Money, balance Money) {
+	if banChk(s, i, userID) {
+		return // stop here if banned
+	}
+	// A round still running is shown instead of dealing a new one
+	if game, err := store.GetBlackjack(userID); err == nil {
+		status := blackjackStatus(game, balance)
+		if err := respondEphemeral(s, i, "🟢 You already have a blackjack hand! Continue playing:\n\n"+status, blackjackButtons(game)); err != nil {
+			log.Println("respondEphemeral error (active blackjack):", err)
+		}
+		return
+	}
+
+	if betAmount <= 0 {
+		respondEphemeral(s, i, "❌ Bet amount must be greater than 0!", nil)
+		return
+	}
+	if balance < betAmount {
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	}
+	var username string
+	if i.Member != nil && i.Member.User != nil {
+		username = i.Member.User.Username
+	} else if i.User != nil {
+		username = i.User.Username
+	}
+
+	// Shuffle the shoe from the user's provably-fair seed
+	seed, err := drawSeed(store, userID)
+	if err != nil {
+		log.Println("Error drawing seed:", err)
+		respondEphemeral(s, i, "❌ Failed to create game!", nil)
+		return
+	}
+	game := newBlackjackGame(userID, username, betAmount, drawShoe(newFairStream(seed), blackjackDecks))
+	game.SeedID, game.Nonce = seed.ID, seed.Nonce
+
+	// Take the bet into escrow and save the round in one transaction
+	switch err := store.StartBlackjack(game); err {
+	case nil:
+	case ErrInsufficientBalance:
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	case ErrGameActive:
+		respondEphemeral(s, i, "❌ You already have a blackjack hand!", nil)
+		return
+	default:
+		log.Println("Error saving blackjack game:", err)
+		respondEphemeral(s, i, "❌ Failed to create game!", nil)
+		return
+	}
+	balance -= betAmount
+
+	// A blackjack on either side ends the round on the deal
+	if game.Phase == phaseOver {
+		if err := settleBlackjack(game); err != nil {
+			log.Println("DB error settling blackjack:", err)
+			respondEphemeral(s, i, "❌ Error settling the game!", nil)
+			return
+		}
+		balance += game.payout()
+	}
+	if err := sendNewMessage(s, i, blackjackStatus(game, balance), blackjackButtons(game)); err != nil {
+		log.Println("sendNewMessage error (blackjack):", err)
+	}
+}
+
+// handleBlackjackButton plays a move: bj_<move>_<owner>.
+func handleBlackjackButton(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, customID string, balance Money) {
+	parts := strings.Split(customID, "_")
+	if len(parts) != 3 {
+		return
+	}
+	if parts[2] != userID {
+		respondEphemeral(s, i, "❌ This isn't your game!", nil)
+		return
+	}
+	game, err := store.GetBlackjack(userID)
+	if err == ErrNotFound {
+		respondEphemeral(s, i, "❌ This game has already ended!", nil)
+		return
+	} else if err != nil {
+		log.Println("DB error loading blackjack:", err)
+		respondEphemeral(s, i, "❌ Database error!", nil)
+		return
+	}
+
+	var raise Money
+	switch parts[1] {
+	case "hit":
+		err = game.hit()
+	case "stand":
+		err = game.stand()
+	case "double":
+		raise, err = game.double()
+	case "split":
+		raise, err = game.split()
+	case "insure", "noinsure":
+		raise, err = game.insure(parts[1] == "insure")
+	default:
+		err = errBlackjackMove
+	}
+	if err != nil {
+		respondEphemeral(s, i, "❌ You can't do that right now!", nil)
+		return
+	}
+
+	// Any extra stake goes into escrow with the move, even if it ends the round
+	switch err := store.SaveBlackjack(game, raise); err {
+	case nil:
+	case ErrInsufficientBalance:
+		respondEphemeral(s, i, fmt.Sprintf("❌ Insufficient balance, that takes another %s!", raise), nil)
+		return
+	case ErrGameChanged:
+		respondEphemeral(s, i, "⚠️ The game state changed before your click went through, try again!", nil)
+		return
+	default:
+		log.Println("Error saving blackjack game:", err)
+		respondEphemeral(s, i, "❌ Error saving the game!", nil)
+		return
+	}
+	balance -= raise
+
+	if game.Phase == phaseOver {
+		if err := settleBlackjack(game); err == ErrNotFound || err == ErrGameChanged {
+			respondEphemeral(s, i, "❌ This game has already ended!", nil)
+			return
+		} else if err != nil {
+			log.Println("DB error settling blackjack:", err)
+			respondEphemeral(s, i, "❌ Error settling the game!", nil)
+			return
+		}
+		balance += game.payout()
+	}
+	if err := respondUpdateMessage(s, i, blackjackStatus(game, balance), blackjackButtons(game)); err != nil {
+		log.Println("respondUpdateMessage error (blackjack):", err)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import "testing"
+
+// testShoe stacks a shoe with cards of the given ranks (1 for an ace, 10 for
+// a ten), dealt in that order.
+func testShoe(ranks ...int) []card {
+	shoe := make([]card, len(ranks))
+	for k, r := range ranks {
+		shoe[k] = card(r - 1)
+	}
+	return shoe
+}
+
+func TestHandTotal(t *testing.T) {
+	tests := []struct {
+		ranks []int
+		total int
+		soft  bool
+	}{
+		{[]int{1, 6}, 17, true},
+		{[]int{1, 6, 10}, 17, false},
+		{[]int{1, 1, 9}, 21, true},
+		{[]int{13, 12}, 20, false},
+		{[]int{10, 6, 9}, 25, false},
+	}
+	for _, tt := range tests {
+		if total, soft := handTotal(testShoe(tt.ranks...)); total != tt.total || soft != tt.soft {
+			t.Errorf("handTotal(%v) = %d, %v; want %d, %v", tt.ranks, total, soft, tt.total, tt.soft)
+		}
+	}
+}
+
+func TestDrawShoe(t *testing.T) {
+	shoe := drawShoe(newFairStream(testSeed), blackjackDecks)
+	if len(shoe) != 52*blackjackDecks {
+		t.Fatalf("shoe has %d cards; want %d", len(shoe), 52*blackjackDecks)
+	}
+	seen := make(map[card]bool)
+	for _, c := range shoe {
+		if seen[c] {
+			t.Fatalf("%s dealt twice", c)
+		}
+		seen[c] = true
+	}
+	again := drawShoe(newFairStream(testSeed), blackjackDecks)
+	for k := range shoe {
+		if shoe[k] != again[k] {
+			t.Fatal("the same seed shuffled two different shoes")
+		}
+	}
+}
+
+func TestBlackjackRounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		shoe   []int // player, upcard, player, hole card, then draws
+		moves  func(game *BlackjackGame)
+		stake  Money
+		payout Money
+	}{
+		{
+			name:   "dealer stands on soft 17",
+			shoe:   []int{10, 6, 9, 1, 5},
+			moves:  func(game *BlackjackGame) { game.stand() },
+			stake:  100,
+			payout: 200,
+		},
+		{
+			name:   "blackjack pays 3:2",
+			shoe:   []int{1, 9, 13, 7},
+			stake:  100,
+			payout: 250,
+		},
+		{
+			name:   "blackjack against blackjack pushes",
+			shoe:   []int{1, 10, 13, 1},
+			stake:  100,
+			payout: 100,
+		},
+		{
+			name:   "insurance pays 2:1",
+			shoe:   []int{10, 1, 9, 12},
+			moves:  func(game *BlackjackGame) { game.insure(true) },
+			stake:  150,
+			payout: 150,
+		},
+		{
+			name:   "declined insurance",
+			shoe:   []int{10, 1, 9, 7},
+			moves:  func(game *BlackjackGame) { game.insure(false); game.stand() },
+			stake:  100,
+			payout: 200,
+		},
+		{
+			name:   "bust loses without the dealer drawing",
+			shoe:   []int{10, 6, 6, 10, 10, 5},
+			moves:  func(game *BlackjackGame) { game.hit() },
+			stake:  100,
+			payout: 0,
+		},
+		{
+			name: "split then double",
+			shoe: []int{8, 5, 8, 10, 3, 10, 10, 9},
+			moves: func(game *BlackjackGame) {
+				game.split()
+				game.double()
+				game.stand()
+			},
+			stake:  300,
+			payout: 600,
+		},
+		{
+			name:   "split aces get one card",
+			shoe:   []int{1, 9, 1, 8, 13, 5},
+			moves:  func(game *BlackjackGame) { game.split() },
+			stake:  200,
+			payout: 200, // 21 pays even money after a split, 16 loses to 17
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := newBlackjackGame("1", "alice", 100, testShoe(tt.shoe...))
+			if tt.moves != nil {
+				tt.moves(game)
+			}
+			if game.Phase != phaseOver {
+				t.Fatalf("round still in phase %s", game.Phase)
+			}
+			if stake, payout := game.stake(), game.payout(); stake != tt.stake || payout != tt.payout {
+				t.Errorf("stake %s, payout %s; want %s, %s", stake, payout, tt.stake, tt.payout)
+			}
+		})
+	}
+}
+
+func TestBlackjackMoves(t *testing.T) {
+	game := newBlackjackGame("1", "alice", 100, testShoe(5, 10, 4, 7, 2, 3))
+	if game.canSplit() {
+		t.Error("5 and 4 can be split")
+	}
+	if _, err := game.insure(true); err != errBlackjackMove {
+		t.Errorf("insuring against a 10 error = %v; want errBlackjackMove", err)
+	}
+	if err := game.hit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := game.double(); err != errBlackjackMove {
+		t.Errorf("doubling after a hit error = %v; want errBlackjackMove", err)
+	}
+
+	// Walking away stands and lets the dealer play: 11 against 17
+	game.standAll()
+	if game.Phase != phaseOver || game.payout() != 0 {
+		t.Errorf("after standAll phase %s, payout %s; want over and nothing", game.Phase, game.payout())
+	}
+	if err := game.stand(); err != errBlackjackMove {
+		t.Errorf("standing on a finished round error = %v; want errBlackjackMove", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// runSubcommand handles `<binary> <name> [args]` invocations that run a
+// maintenance task instead of starting the bot.
+func runSubcommand(name string, args []string) error {
+	switch name {
+	case "migrate":
+		return runMigrate(args)
+	case "reconcile":
+		return runReconcile(args)
+	case "simulate":
+		return runSimulate(args)
+	case "multipliers":
+		return runMultipliers(args)
+	case "sync-emojis":
+		return runSyncEmojis(args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+// runMigrate implements `migrate [-dry-run] up|down [n]|status`.
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "print the SQL that would run without executing it")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: migrate [-dry-run] up | down [n] | status")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if d := os.Getenv("dbDriver"); d != "" && d != "mysql" {
+		return fmt.Errorf("migrations only apply to the mysql store, dbDriver is %q", d)
+	}
+	ms, err := openMySQLStore(os.Getenv("dbPath"))
+	if err != nil {
+		return err
+	}
+	defer ms.Close()
+
+	action := fs.Arg(0)
+	switch action {
+	case "", "up":
+		n, err := migrateUp(ms.db, *dryRun)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) %s\n", n, pick(*dryRun, "pending", "applied"))
+		return nil
+
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			if steps, err = strconv.Atoi(fs.Arg(1)); err != nil || steps < 1 {
+				return fmt.Errorf("invalid step count %q", fs.Arg(1))
+			}
+		}
+		n, err := migrateDown(ms.db, steps, *dryRun)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migration(s) %s\n", n, pick(*dryRun, "would be reverted", "reverted"))
+		return nil
+
+	case "status":
+		states, err := migrationStatus(ms.db)
+		if err != nil {
+			return err
+		}
+		for _, st := range states {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", st.Version, st.Name, applied)
+		}
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+}
+
+func pick(cond bool, a, b string) string {
+	if cond {
+		return a
+	}
+	return b
+}
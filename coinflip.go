@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// coinflipRake is the share of a duel's pot the house keeps from the winner,
+// read from the coinflipRake env var at startup. There's none by default.
+var coinflipRake = new(big.Rat)
+
+// duelTimeout is how long a challenge waits for its opponent before the
+// challenger's stake is refunded.
+const duelTimeout = 2 * time.Minute
+
+// duelHeads flips d's coin from the duel's own seed. Heads, a draw of 0, is
+// the challenger's side.
+func duelHeads(d *Duel) bool {
+	seed := &FairSeed{ServerSeed: d.ServerSeed, ClientSeed: "coinflip", Nonce: d.ID}
+	return newFairStream(seed).Intn(2) == 0
+}
+
+// duelRake is what the house keeps of d's pot at rate.
+func duelRake(d *Duel, rate *big.Rat) Money {
+	return (2 * d.Amount).MulRat(rate)
+}
+
+// results settles both sides of d's flip, challenger first: the winner takes
+// the pot less the rake.
+func (d *Duel) results(heads bool, rate *big.Rat) []GameResult {
+	rake := duelRake(d, rate)
+	side := func(userID, name string, won bool) GameResult {
+		outcome := -d.Amount
+		if won {
+			outcome = d.Amount - rake
+		}
+		return GameResult{
+			UserID:      userID,
+			GameType:    "coinflip",
+			Bet:         d.Amount,
+			Outcome:     outcome,
+			CloseActive: true,
+			Escrowed:    true,
+			Params:      fmt.Sprintf("side=%s,rake=%s", name, rake),
+			RoundID:     d.ID,
+		}
+	}
+	return []GameResult{
+		side(d.ChallengerID, "heads", heads),
+		side(d.OpponentID, "tails", !heads),
+	}
+}
+
+// refund gives the challenger their stake back for a challenge nobody took.
+func (d *Duel) refund() GameResult {
+	return GameResult{
+		UserID:      d.ChallengerID,
+		GameType:    "coinflip",
+		Bet:         d.Amount,
+		CloseActive: true,
+		Escrowed:    true,
+		Params:      "side=heads",
+		RoundID:     d.ID,
+	}
+}
+
+func duelButtons(id int64) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Accept", Emoji: &discordgo.ComponentEmoji{Name: "🪙"}, Style: discordgo.SuccessButton, CustomID: fmt.Sprintf("coinflipaccept_%d", id)},
+		discordgo.Button{Label: "Decline", Style: discordgo.DangerButton, CustomID: fmt.Sprintf("coinflipdecline_%d", id)},
+	}}}
+}
+
+// startCoinflip challenges opponentID, taking the challenger's stake into
+// escrow until the challenge is answered or expires.
+func startCoinflip(s *discordgo.Session, i *discordgo.InteractionCreate, userID, opponentID string, amount Money, balance Money) {
+	if opponentID == userID {
+		respondEphemeral(s, i, "❌ You cannot challenge yourself.", nil)
+		return
+	}
+	if amount <= 0 {
+		respondEphemeral(s, i, "❌ Bet amount must be greater than 0!", nil)
+		return
+	}
+	if balance < amount {
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	}
+	opponent, err := store.GetUser(opponentID)
+	if err == ErrNotFound || (err == nil && opponent.Banned) {
+		respondEphemeral(s, i, "❌ That user can't be challenged.", nil)
+		return
+	} else if err != nil {
+		log.Println("DB error:", err)
+		respondEphemeral(s, i, "⚠️ Database error, please try again later.", nil)
+		return
+	}
+	var username string
+	if i.Member != nil && i.Member.User != nil {
+		username = i.Member.User.Username
+	} else if i.User != nil {
+		username = i.User.Username
+	}
+
+	serverSeed, err := randomHex(32)
+	if err != nil {
+		log.Println("Error drawing coinflip seed:", err)
+		respondEphemeral(s, i, "❌ Failed to create the challenge!", nil)
+		return
+	}
+	d := &Duel{
+		ChallengerID:   userID,
+		ChallengerName: username,
+		OpponentID:     opponentID,
+		OpponentName:   opponent.Username,
+		Amount:         amount,
+		ServerSeed:     serverSeed,
+		ServerSeedHash: hashServerSeed(serverSeed),
+	}
+	switch err := store.StartDuel(d); err {
+	case nil:
+	case ErrInsufficientBalance:
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	case ErrGameActive:
+		respondEphemeral(s, i, "❌ You already have a coinflip going!", nil)
+		return
+	default:
+		log.Println("Error saving coinflip challenge:", err)
+		respondEphemeral(s, i, "❌ Failed to create the challenge!", nil)
+		return
+	}
+
+	msg := fmt.Sprintf("🪙 <@%s> challenges <@%s> to a coinflip for %s each!\n", userID, opponentID, amount)
+	msg += fmt.Sprintf("👑 Heads: <@%s> · Tails: <@%s>\n", userID, opponentID)
+	if coinflipRake.Sign() > 0 {
+		msg += fmt.Sprintf("🏦 The house keeps %s of the pot.\n", duelRake(d, coinflipRake))
+	}
+	msg += fmt.Sprintf("🔐 Server seed hash: `%s`\n⏳ Expires <t:%d:R>", d.ServerSeedHash, time.Now().Add(duelTimeout).Unix())
+	if err := sendNewMessage(s, i, msg, duelButtons(d.ID)); err != nil {
+		log.Println("sendNewMessage error (coinflip):", err)
+	}
+
+	time.AfterFunc(duelTimeout, func() { expireDuel(s, i, d.ID) })
+}
+
+// expireDuel refunds a challenge nobody answered in time and says so on the
+// challenge message.
+func expireDuel(s *discordgo.Session, i *discordgo.InteractionCreate, id int64) {
+	d, err := store.CloseDuel(id, "expired")
+	if err == ErrNotFound {
+		return // answered meanwhile
+	} else if err != nil {
+		log.Printf("DB error expiring coinflip #%d: %v", id, err)
+		return
+	}
+	content := fmt.Sprintf("⌛ <@%s> didn't answer <@%s>'s coinflip in time. The %s stake was refunded.", d.OpponentID, d.ChallengerID, d.Amount)
+	components := []discordgo.MessageComponent{}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content, Components: &components}); err != nil {
+		log.Printf("Failed to edit expired coinflip #%d: %v", id, err)
+	}
+}
+
+// expireDuels refunds challenges still open long after they should have
+// expired, which only happens when the bot restarted in between.
+func expireDuels(st Store, idle time.Duration) {
+	duels, err := st.StaleDuels(idle)
+	if err != nil {
+		log.Println("DB error listing stale coinflips:", err)
+		return
+	}
+	for _, d := range duels {
+		if _, err := st.CloseDuel(d.ID, "expired"); err != nil && err != ErrNotFound {
+			log.Printf("DB error expiring coinflip #%d: %v", d.ID, err)
+		}
+	}
+}
+
+// handleCoinflipButton accepts or declines a challenge.
+func handleCoinflipButton(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, customID string, balance Money) {
+	action, idStr, _ := strings.Cut(strings.TrimPrefix(customID, "coinflip"), "_")
+	id, _ := strconv.ParseInt(idStr, 10, 64)
+	d, err := store.GetDuel(id)
+	if err == ErrNotFound || (err == nil && d.Status != "open") {
+		respondEphemeral(s, i, "❌ This challenge is no longer open!", nil)
+		return
+	} else if err != nil {
+		log.Println("DB error loading coinflip:", err)
+		respondEphemeral(s, i, "❌ Database error!", nil)
+		return
+	}
+
+	switch action {
+	case "accept":
+		if userID != d.OpponentID {
+			respondEphemeral(s, i, "❌ This challenge isn't for you!", nil)
+			return
+		}
+		if balance < d.Amount {
+			respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+			return
+		}
+		heads := duelHeads(d)
+		gameIDs, err := store.AcceptDuel(d, d.results(heads, coinflipRake))
+		switch err {
+		case nil:
+		case ErrNotFound:
+			respondEphemeral(s, i, "❌ This challenge is no longer open!", nil)
+			return
+		case ErrInsufficientBalance:
+			respondEphemeral(s, i, "❌ Insufficient balance or concurrent transaction!", nil)
+			return
+		case ErrGameActive:
+			respondEphemeral(s, i, "❌ Finish your own coinflip first!", nil)
+			return
+		default:
+			log.Printf("DB error settling coinflip #%d: %v", d.ID, err)
+			respondEphemeral(s, i, "❌ Database error!", nil)
+			return
+		}
+		if err := respondUpdateMessage(s, i, duelResult(d, heads, gameIDs), []discordgo.MessageComponent{}); err != nil {
+			log.Println("respondUpdateMessage error (coinflip):", err)
+		}
+
+	case "decline":
+		if userID != d.OpponentID && userID != d.ChallengerID {
+			respondEphemeral(s, i, "❌ This challenge isn't for you!", nil)
+			return
+		}
+		if _, err := store.CloseDuel(d.ID, "declined"); err == ErrNotFound {
+			respondEphemeral(s, i, "❌ This challenge is no longer open!", nil)
+			return
+		} else if err != nil {
+			log.Printf("DB error declining coinflip #%d: %v", d.ID, err)
+			respondEphemeral(s, i, "❌ Database error!", nil)
+			return
+		}
+		msg := fmt.Sprintf("🚫 <@%s> declined the coinflip. <@%s>'s %s stake was refunded.", d.OpponentID, d.ChallengerID, d.Amount)
+		if userID == d.ChallengerID {
+			msg = fmt.Sprintf("🚫 <@%s> withdrew the coinflip challenge. The %s stake was refunded.", d.ChallengerID, d.Amount)
+		}
+		if err := respondUpdateMessage(s, i, msg, []discordgo.MessageComponent{}); err != nil {
+			log.Println("respondUpdateMessage error (coinflip decline):", err)
+		}
+	}
+}
+
+// duelResult announces the flip, revealing the seed it came from.
+func duelResult(d *Duel, heads bool, gameIDs []int64) string {
+	winner := pick(heads, d.ChallengerID, d.OpponentID)
+	rake := duelRake(d, coinflipRake)
+	msg := fmt.Sprintf("🪙 <@%s> vs <@%s> for %s each\n", d.ChallengerID, d.OpponentID, d.Amount)
+	msg += fmt.Sprintf("The coin landed on **%s**!\n", pick(heads, "Heads", "Tails"))
+	msg += fmt.Sprintf("🏆 <@%s> wins %s", winner, 2*d.Amount-rake)
+	if rake > 0 {
+		msg += fmt.Sprintf(" (the house kept %s)", rake)
+	}
+	msg += fmt.Sprintf("\n🔓 Server seed: `%s`\n", d.ServerSeed)
+	msg += fmt.Sprintf("🎲 Games #%d and #%d (`/verify`)", gameIDs[0], gameIDs[1])
+	return msg
+}
+
+// verifyCoinflip recomputes a duel's flip from its revealed server seed.
+func verifyCoinflip(st Store, game *GameRecord) string {
+	d, err := st.GetDuel(game.RoundID)
+	if err == ErrNotFound {
+		return "❌ This game's duel wasn't found."
+	} else if err != nil {
+		log.Println("DB error (verify coinflip):", err)
+		return "⚠️ Database error, please try again later."
+	}
+	if d.Status != "accepted" {
+		return fmt.Sprintf("🚫 Game #%d: coinflip #%d was %s, so its stake was refunded.", game.ID, d.ID, d.Status)
+	}
+
+	heads := duelHeads(d)
+	msg := fmt.Sprintf("🎲 Game #%d (coinflip #%d) by <@%s>\n🔓 Server seed: `%s`\n🔐 Hash: `%s` %s\n🌱 Client seed: `coinflip`\n🔢 Nonce: %d\n\n",
+		game.ID, d.ID, game.UserID, d.ServerSeed, d.ServerSeedHash,
+		pick(hashServerSeed(d.ServerSeed) == d.ServerSeedHash, "✅", "❌ does not match"), d.ID)
+	msg += fmt.Sprintf("🪙 The coin landed on **%s**\n", pick(heads, "Heads", "Tails"))
+
+	expected := -game.Amount
+	if side := gameParam(game.Params, "side"); (side == "heads") == heads {
+		rake, _ := parseMoney(gameParam(game.Params, "rake"))
+		expected = game.Amount - rake
+	}
+	msg += fmt.Sprintf("💵 Outcome: %s %s", expected, pick(expected == game.Outcome, "✅", "❌ does not match the logged "+game.Outcome.String()))
+	return msg
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestDuelHeads(t *testing.T) {
+	d := &Duel{ID: 1, ServerSeed: testSeed.ServerSeed}
+	if duelHeads(d) != duelHeads(d) {
+		t.Fatal("the same duel flipped both ways")
+	}
+	const duels = 4000
+	heads := 0
+	for n := int64(1); n <= duels; n++ {
+		d.ID = n
+		if duelHeads(d) {
+			heads++
+		}
+	}
+	if rate := float64(heads) / duels; rate < 0.47 || rate > 0.53 {
+		t.Errorf("%.3f of the flips came up heads; want about half", rate)
+	}
+}
+
+func TestDuelResults(t *testing.T) {
+	d := &Duel{ID: 3, ChallengerID: "1", OpponentID: "2", Amount: 1000}
+	results := d.results(false, mustRat("0.025"))
+	if r := results[0]; r.UserID != "1" || r.Outcome != -1000 || r.Params != "side=heads,rake=0.50" || r.RoundID != 3 {
+		t.Errorf("challenger = %+v; want -10.00 on heads in duel 3", r)
+	}
+	if r := results[1]; r.UserID != "2" || r.Outcome != 950 || r.Params != "side=tails,rake=0.50" || r.RoundID != 3 {
+		t.Errorf("opponent = %+v; want +9.50 on tails after a 0.50 rake", r)
+	}
+	if rake := results[0].Outcome + results[1].Outcome; rake != -50 {
+		t.Errorf("the house kept %s; want 0.50", -rake)
+	}
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// crashRTP is what a crash bet returns on average, whatever multiplier it is
+// cashed out at.
+var crashRTP = mustRat("0.97")
+
+const (
+	// crashCountdown is how long a round takes bets before it takes off, in
+	// steps of the 5s the lobby message is refreshed at.
+	crashCountdown = 15 * time.Second
+	// crashTick is how often the multiplier climbs, one message edit each.
+	crashTick = time.Second
+	// crashGrowth is how much the multiplier climbs per tick: 2x after 9
+	// ticks, 100x after 60.
+	crashGrowth = 1.08
+	// maxCrashPoint caps a round at 100.00x, 60 ticks after take-off.
+	maxCrashPoint int64 = 10000
+)
+
+// crashPoint draws where round crashes, in hundredths of a multiplier, so a
+// bet cashed out at m survives with probability RTP/m and returns RTP on
+// average. A round at 1.00x crashes before anyone can cash out.
+func crashPoint(round *CrashRound) int64 {
+	seed := &FairSeed{ServerSeed: round.ServerSeed, ClientSeed: "crash:" + round.ChannelID, Nonce: round.ID}
+	if point := drawMultiplier(newFairStream(seed), crashRTP); point < maxCrashPoint {
+		return point
+	}
+	return maxCrashPoint
+}
+
+// crashMultiplier is the multiplier a round shows tick ticks after take-off,
+// in hundredths.
+func crashMultiplier(tick int) int64 {
+	return int64(100 * math.Pow(crashGrowth, float64(tick)))
+}
+
+// formatCrash shows a multiplier in hundredths, e.g. "1.57x".
+func formatCrash(m int64) string {
+	return hundredths(m).String() + "x"
+}
+
+// crashPayout is what bet returns cashed out at m, stake included.
+func crashPayout(bet Money, m int64) Money {
+	return bet.MulRat(ratio(m, 100))
+}
+
+// crashPlayer is one bet riding a round.
+type crashPlayer struct {
+	UserID   string
+	Name     string
+	Bet      Money
+	CashedAt int64 // multiplier in hundredths, 0 while still riding
+}
+
+// crashRound is a round running in a channel. Its response to the first
+// rider's /crash is the round's message, edited as the round goes.
+type crashRound struct {
+	CrashRound
+	i          *discordgo.InteractionCreate
+	point      int64 // where it will crash, secret until it does
+	Players    []*crashPlayer
+	Multiplier int64 // in hundredths, 0 during the countdown
+	Crashed    bool
+}
+
+// results settles every bet: a cashed out one pays its multiplier, the rest
+// are lost.
+func (round *crashRound) results() []GameResult {
+	results := make([]GameResult, len(round.Players))
+	for k, p := range round.Players {
+		outcome, params := -p.Bet, "cashout="
+		if p.CashedAt > 0 {
+			outcome = crashPayout(p.Bet, p.CashedAt) - p.Bet
+			params += hundredths(p.CashedAt).String()
+		}
+		results[k] = GameResult{
+			UserID:      p.UserID,
+			GameType:    "crash",
+			Bet:         p.Bet,
+			Outcome:     outcome,
+			CloseActive: true,
+			Escrowed:    true,
+			Params:      params,
+			RoundID:     round.ID,
+		}
+	}
+	return results
+}
+
+// crashRefund returns a bet that never rode a round, like the reaper does.
+func crashRefund(userID string, bet Money) GameResult {
+	return GameResult{
+		UserID:      userID,
+		GameType:    "crash",
+		Bet:         bet,
+		CloseActive: true,
+		Escrowed:    true,
+		Params:      "cashout=",
+	}
+}
+
+var (
+	// crashMutex guards crashRounds, crashUnsettled and every round in them.
+	crashMutex  sync.Mutex
+	crashRounds = make(map[string]*crashRound) // channel id -> round taking bets or flying
+	// crashUnsettled holds every round this process started until its bets
+	// are settled, which is after it left crashRounds.
+	crashUnsettled = make(map[int64]*crashRound)
+)
+
+// crashRiding reports whether userID has a bet on a round that is still
+// running here. A round outlasts a short abandonAfter, so the reaper leaves
+// these bets to the round and only refunds those a restart left behind.
+func crashRiding(userID string) bool {
+	crashMutex.Lock()
+	defer crashMutex.Unlock()
+
+	for _, round := range crashUnsettled {
+		for _, p := range round.Players {
+			if p.UserID == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// crashStatus renders a round: its countdown, the climb or the crash, and
+// every rider. gameIDs are the settled bets' games rows, nil until then.
+func crashStatus(round *crashRound, startsIn time.Duration, gameIDs []int64) string {
+	msg := fmt.Sprintf("🚀 **Crash round #%d**\n", round.ID)
+	switch {
+	case round.Crashed:
+		msg += fmt.Sprintf("💥 **Crashed at %s!**\n", formatCrash(round.point))
+	case round.Multiplier > 0:
+		msg += fmt.Sprintf("📈 **%s** and climbing...\n", formatCrash(round.Multiplier))
+	default:
+		msg += fmt.Sprintf("⏳ Takes off in %ds, join with `/crash`!\n", int(startsIn/time.Second))
+	}
+	msg += fmt.Sprintf("🔐 Server seed hash: `%s`\n", round.ServerSeedHash)
+	if round.Settled {
+		msg += fmt.Sprintf("🔓 Server seed: `%s`\n", round.ServerSeed)
+	}
+
+	msg += "\n👥 Riders:\n"
+	for k, p := range round.Players {
+		msg += fmt.Sprintf("• %s: %s", p.Name, p.Bet)
+		if p.CashedAt > 0 {
+			msg += fmt.Sprintf(" 💰 cashed out at %s (+%s)", formatCrash(p.CashedAt), crashPayout(p.Bet, p.CashedAt)-p.Bet)
+		} else if round.Crashed {
+			msg += " 💥"
+		}
+		if k < len(gameIDs) && gameIDs[k] != 0 {
+			msg += fmt.Sprintf(" (`/verify %d`)", gameIDs[k])
+		}
+		msg += "\n"
+	}
+	return msg
+}
+
+// cashOutButton is each rider's own Cash Out button, sent to them alone.
+func cashOutButton(roundID int64) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "💰 Cash Out", Style: discordgo.SuccessButton, CustomID: fmt.Sprintf("crashcashout_%d", roundID)},
+	}}}
+}
+
+// joinCrash puts a bet on the channel's round, starting one if none is taking
+// bets.
+func joinCrash(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, bet Money, balance Money) {
+	if bet <= 0 {
+		respondEphemeral(s, i, "❌ Bet amount must be greater than 0!", nil)
+		return
+	}
+	if balance < bet {
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	}
+	var username string
+	if i.Member != nil && i.Member.User != nil {
+		username = i.Member.User.Username
+	} else if i.User != nil {
+		username = i.User.Username
+	}
+
+	// The store is only called with the lock released: a round being saved
+	// holds its channel with a placeholder nobody can join until then
+	crashMutex.Lock()
+	round, created := crashRounds[i.ChannelID], false
+	switch {
+	case round == nil:
+		crashRounds[i.ChannelID] = &crashRound{CrashRound: CrashRound{ChannelID: i.ChannelID}}
+		created = true
+	case round.ID == 0:
+		crashMutex.Unlock()
+		respondEphemeral(s, i, "⏳ A round is starting in this channel, try again in a moment!", nil)
+		return
+	case round.Multiplier > 0:
+		crashMutex.Unlock()
+		respondEphemeral(s, i, "❌ This round already took off! Join the next one once it crashes.", nil)
+		return
+	}
+	crashMutex.Unlock()
+
+	var err error
+	if created {
+		round, err = newCrashRound(i, userID, username, bet)
+	} else {
+		err = store.StartCrashBet(userID, username, bet)
+	}
+
+	crashMutex.Lock()
+	tookOff := false
+	switch {
+	case created && err != nil:
+		delete(crashRounds, i.ChannelID)
+	case created:
+		crashRounds[i.ChannelID] = round
+		crashUnsettled[round.ID] = round
+		fallthrough
+	case err == nil && round.Multiplier == 0:
+		round.Players = append(round.Players, &crashPlayer{UserID: userID, Name: username, Bet: bet})
+	case err == nil:
+		tookOff = true
+	}
+	var lobby string
+	if err == nil {
+		lobby = crashStatus(round, crashCountdown, nil)
+	}
+	crashMutex.Unlock()
+
+	if tookOff {
+		// The countdown ran out while the bet was escrowed
+		if _, err := store.SettleGame(crashRefund(userID, bet)); err != nil {
+			log.Printf("DB error refunding a late crash bet of %s: %v", userID, err)
+			respondEphemeral(s, i, "❌ This round already took off! Your bet stays in escrow until it's refunded.", nil)
+			return
+		}
+		respondEphemeral(s, i, "❌ This round took off before your bet went in, so it was refunded. Join the next one once it crashes.", nil)
+		return
+	}
+
+	switch err {
+	case nil:
+	case ErrInsufficientBalance:
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	case ErrGameActive:
+		respondEphemeral(s, i, "❌ You're already riding a crash round!", nil)
+		return
+	default:
+		log.Println("Error escrowing crash bet:", err)
+		respondEphemeral(s, i, "❌ Failed to place your bet!", nil)
+		return
+	}
+
+	joined := fmt.Sprintf("🎟️ You're in crash round #%d with %s. Cash out before it crashes!", round.ID, bet)
+	if !created {
+		respondEphemeral(s, i, joined, cashOutButton(round.ID))
+		return
+	}
+	if err := sendNewMessage(s, i, lobby, nil); err != nil {
+		log.Println("sendNewMessage error (crash):", err)
+	}
+	if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content:    joined,
+		Components: cashOutButton(round.ID),
+		Flags:      discordgo.MessageFlagsEphemeral,
+	}); err != nil {
+		log.Println("FollowupMessageCreate error (crash):", err)
+	}
+	go runCrashRound(s, round)
+}
+
+// newCrashRound commits to a fresh server seed for a round in i's channel,
+// escrowing its creator's bet with it.
+func newCrashRound(i *discordgo.InteractionCreate, userID, username string, bet Money) (*crashRound, error) {
+	serverSeed, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	round := &crashRound{
+		CrashRound: CrashRound{ChannelID: i.ChannelID, ServerSeed: serverSeed, ServerSeedHash: hashServerSeed(serverSeed)},
+		i:          i,
+	}
+	if err := store.StartCrashRound(&round.CrashRound, userID, username, bet); err != nil {
+		return nil, err
+	}
+	round.point = crashPoint(&round.CrashRound)
+	return round, nil
+}
+
+// runCrashRound counts a round down, flies it until it crashes and settles
+// every bet in one go.
+func runCrashRound(s *discordgo.Session, round *crashRound) {
+	edit := func(content string) {
+		components := []discordgo.MessageComponent{}
+		if _, err := s.InteractionResponseEdit(round.i.Interaction, &discordgo.WebhookEdit{Content: &content, Components: &components}); err != nil {
+			log.Printf("Failed to edit crash round #%d: %v", round.ID, err)
+		}
+	}
+
+	// Show who joined meanwhile every few seconds
+	for left := crashCountdown; left > 0; left -= 5 * time.Second {
+		crashMutex.Lock()
+		content := crashStatus(round, left, nil)
+		crashMutex.Unlock()
+		edit(content)
+		time.Sleep(5 * time.Second)
+	}
+
+	crashMutex.Lock()
+	round.Multiplier = 100
+	content := crashStatus(round, 0, nil)
+	crashMutex.Unlock()
+	edit(content)
+
+	for tick := 1; ; tick++ {
+		time.Sleep(crashTick)
+		crashMutex.Lock()
+		if m := crashMultiplier(tick); m < round.point {
+			round.Multiplier = m
+		} else {
+			round.Crashed = true
+			delete(crashRounds, round.ChannelID) // the channel can start the next round
+		}
+		content, crashed := crashStatus(round, 0, nil), round.Crashed
+		crashMutex.Unlock()
+		if crashed {
+			break
+		}
+		edit(content)
+	}
+
+	// Nothing changes a crashed round any more, so it's read without the lock
+	round.CrashPoint = round.point
+	gameIDs, err := store.SettleCrashRound(&round.CrashRound, round.results())
+	crashMutex.Lock()
+	delete(crashUnsettled, round.ID)
+	crashMutex.Unlock()
+	content = crashStatus(round, 0, gameIDs)
+	if err != nil {
+		log.Printf("DB error settling crash round #%d: %v", round.ID, err)
+		content += "\n⚠️ Database error settling the round! The bets stay in escrow until they're refunded."
+	}
+	edit(content)
+}
+
+// cashOutCrash locks in a rider's bet at the multiplier the round shows. It
+// is paid when the round settles.
+func cashOutCrash(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, customID string) {
+	roundID, _ := strconv.ParseInt(strings.TrimPrefix(customID, "crashcashout_"), 10, 64)
+
+	crashMutex.Lock()
+	var round *crashRound
+	for _, r := range crashRounds {
+		if r.ID == roundID {
+			round = r
+		}
+	}
+	var player *crashPlayer
+	if round != nil {
+		for _, p := range round.Players {
+			if p.UserID == userID {
+				player = p
+			}
+		}
+	}
+	var msg string
+	switch {
+	case round == nil:
+		msg = "💥 Too late, this round already crashed!"
+	case player == nil:
+		msg = "❌ You're not riding this round!"
+	case player.CashedAt > 0:
+		msg = fmt.Sprintf("❌ You already cashed out at %s!", formatCrash(player.CashedAt))
+	case round.Multiplier <= 100:
+		msg = "⏳ Wait for the round to take off first!"
+	default:
+		player.CashedAt = round.Multiplier
+	}
+	crashMutex.Unlock()
+
+	if msg != "" {
+		respondEphemeral(s, i, msg, nil)
+		return
+	}
+	msg = fmt.Sprintf("💰 Cashed out at %s: %s (+%s) is paid when the round ends.",
+		formatCrash(player.CashedAt), crashPayout(player.Bet, player.CashedAt), crashPayout(player.Bet, player.CashedAt)-player.Bet)
+	if err := respondUpdateMessage(s, i, msg, []discordgo.MessageComponent{}); err != nil {
+		log.Println("respondUpdateMessage error (crash cashout):", err)
+	}
+}
+
+// verifyCrash recomputes a crash bet's round from its revealed server seed.
+func verifyCrash(st Store, game *GameRecord) string {
+	if game.RoundID == 0 {
+		return "❌ This bet's round never finished, so it was refunded."
+	}
+	round, err := st.GetCrashRound(game.RoundID)
+	if err != nil {
+		log.Println("DB error (verify crash round):", err)
+		return "⚠️ Database error, please try again later."
+	}
+
+	point := crashPoint(round)
+	msg := fmt.Sprintf("🎲 Game #%d (crash round #%d) by <@%s>\n🔓 Server seed: `%s`\n🔐 Hash: `%s` %s\n🌱 Client seed: `crash:%s`\n🔢 Nonce: %d\n\n",
+		game.ID, round.ID, game.UserID, round.ServerSeed, round.ServerSeedHash,
+		pick(hashServerSeed(round.ServerSeed) == round.ServerSeedHash, "✅", "❌ does not match"),
+		round.ChannelID, round.ID)
+	msg += fmt.Sprintf("💥 Crash point: %s %s\n", formatCrash(point),
+		pick(point == round.CrashPoint, "✅", "❌ does not match the logged "+formatCrash(round.CrashPoint)))
+
+	expected := -game.Amount
+	if cashedAt, err := parseHundredths(gameParam(game.Params, "cashout")); err == nil && cashedAt > 0 {
+		msg += fmt.Sprintf("💰 Cashed out at %s\n", formatCrash(int64(cashedAt)))
+		if int64(cashedAt) < point {
+			expected = crashPayout(game.Amount, int64(cashedAt)) - game.Amount
+		}
+	}
+	msg += fmt.Sprintf("💵 Outcome: %s %s", expected, pick(expected == game.Outcome, "✅", "❌ does not match the logged "+game.Outcome.String()))
+	return msg
+}
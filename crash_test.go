@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestCrashPoint(t *testing.T) {
+	round := &CrashRound{ID: 1, ChannelID: "42", ServerSeed: testSeed.ServerSeed}
+	if crashPoint(round) != crashPoint(round) {
+		t.Fatal("the same round crashed at two different points")
+	}
+
+	// A bet cashed out at 2.00x survives RTP/2 of the rounds
+	const rounds = 20000
+	survived := 0
+	for n := int64(1); n <= rounds; n++ {
+		round.ID = n
+		point := crashPoint(round)
+		if point < 100 || point > maxCrashPoint {
+			t.Fatalf("round %d crashed at %s, outside 1.00x to %s", n, formatCrash(point), formatCrash(maxCrashPoint))
+		}
+		if point > 200 {
+			survived++
+		}
+	}
+	if rate := float64(survived) / rounds; rate < 0.47 || rate > 0.50 {
+		t.Errorf("%.3f of the rounds passed 2.00x; want about 0.485", rate)
+	}
+}
+
+func TestCrashMultiplier(t *testing.T) {
+	if crashMultiplier(0) != 100 {
+		t.Errorf("take-off at %s; want 1.00x", formatCrash(crashMultiplier(0)))
+	}
+	for tick := 1; tick <= 60; tick++ {
+		if crashMultiplier(tick) <= crashMultiplier(tick-1) {
+			t.Fatalf("multiplier stalled at tick %d", tick)
+		}
+	}
+	if crashMultiplier(60) < maxCrashPoint {
+		t.Errorf("tick 60 shows %s; a round at the cap should be over by then", formatCrash(crashMultiplier(60)))
+	}
+}
+
+func TestCrashResults(t *testing.T) {
+	round := &crashRound{
+		CrashRound: CrashRound{ID: 7},
+		Players: []*crashPlayer{
+			{UserID: "1", Name: "alice", Bet: 200, CashedAt: 157},
+			{UserID: "2", Name: "bob", Bet: 300},
+		},
+	}
+	results := round.results()
+	if r := results[0]; r.Outcome != 114 || r.Params != "cashout=1.57" || r.RoundID != 7 || !r.Escrowed {
+		t.Errorf("cashed out bet = %+v; want +1.14 at 1.57x in round 7", r)
+	}
+	if r := results[1]; r.Outcome != -300 || r.Params != "cashout=" || r.RoundID != 7 {
+		t.Errorf("bet that rode the crash = %+v; want -3.00 in round 7", r)
+	}
+}
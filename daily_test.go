@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestRewardRange(t *testing.T) {
+	tests := []struct {
+		streak    int
+		low, high Money
+	}{
+		{0, 50000, 150000}, // streaks below 1 count as 1
+		{1, 50000, 150000},
+		{4, 400000, 1200000},
+		{9, 1350000, 4050000},
+	}
+	for _, tt := range tests {
+		low, high := rewardRange(tt.streak)
+		if low != tt.low || high != tt.high {
+			t.Errorf("rewardRange(%d) = %s-%s; want %s-%s", tt.streak, low, high, tt.low, tt.high)
+		}
+	}
+}
+
+func TestGetRewardInfo(t *testing.T) {
+	rng := newSeededRNG(1)
+	for streak := 1; streak <= 30; streak++ {
+		for k := 0; k < 200; k++ {
+			low, high, reward := getRewardInfo(streak, rng)
+			if reward < low || reward > high {
+				t.Fatalf("streak %d reward %s outside %s-%s", streak, reward, low, high)
+			}
+		}
+	}
+
+	_, _, a := getRewardInfo(5, newSeededRNG(99))
+	_, _, b := getRewardInfo(5, newSeededRNG(99))
+	if a != b {
+		t.Errorf("same seed drew %s then %s", a, b)
+	}
+}
+
+func TestClaimDailyReward(t *testing.T) {
+	st := newMemoryStore()
+	st.AddUser("1", "alice")
+
+	reward, err := ClaimDailyReward(st, newSeededRNG(3), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if low, high := rewardRange(1); reward.Streak != 1 || reward.RewardAmount < low || reward.RewardAmount > high {
+		t.Errorf("first claim = %+v; want streak 1 within %s-%s", reward, low, high)
+	}
+	if u, _ := st.GetUser("1"); u.Balance != startingBalance+reward.RewardAmount {
+		t.Errorf("balance = %s; want %s", u.Balance, startingBalance+reward.RewardAmount)
+	}
+	if _, err := ClaimDailyReward(st, newSeededRNG(3), "1"); err != ErrAlreadyClaimed {
+		t.Errorf("second claim error = %v; want ErrAlreadyClaimed", err)
+	}
+}
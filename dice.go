@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// diceRolls is how many results a roll has, 0.00 to 99.99.
+const diceRolls = 10000
+
+// Dice targets leave a win chance from 1% to 98.99%.
+const (
+	minDiceTarget hundredths = 100
+	maxDiceTarget hundredths = 9899
+)
+
+// minDiceOption is the /dice target option's minimum, minDiceTarget.
+var minDiceOption float64 = 1
+
+// diceWins counts the rolls that win rolling over or under target.
+func diceWins(target hundredths, over bool) int64 {
+	if over {
+		return diceRolls - 1 - int64(target)
+	}
+	return int64(target)
+}
+
+// diceMultiplier pays a win RTP over its probability, so every target returns
+// RTP on average.
+func diceMultiplier(target hundredths, over bool, rtp *big.Rat) *big.Rat {
+	return new(big.Rat).Mul(rtp, ratio(diceRolls, diceWins(target, over)))
+}
+
+// diceOutcome is the net outcome of bet on roll.
+func diceOutcome(bet Money, roll, target hundredths, over bool, rtp *big.Rat) Money {
+	if over && roll > target || !over && roll < target {
+		return bet.MulRat(diceMultiplier(target, over, rtp)) - bet
+	}
+	return -bet
+}
+
+// playDice rolls once against the player's target and settles the bet in the
+// same statement as slot does.
+func playDice(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, bet Money, target hundredths, over bool, balance Money) {
+	if bet <= 0 {
+		respondEphemeral(s, i, "❌ Bet amount must be greater than 0!", nil)
+		return
+	}
+	if balance < bet {
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	}
+	if target < minDiceTarget || target > maxDiceTarget {
+		respondEphemeral(s, i, fmt.Sprintf("❌ The target must be from %s to %s!", minDiceTarget, maxDiceTarget), nil)
+		return
+	}
+	rtp := diceRTP.For(i.GuildID)
+	multiplier := diceMultiplier(target, over, rtp)
+	if multiplier.Cmp(ratio(1, 1)) <= 0 {
+		respondEphemeral(s, i, "❌ That target pays back less than your bet, pick a riskier one!", nil)
+		return
+	}
+
+	seed, err := drawSeed(store, userID)
+	if err != nil {
+		log.Println("Error drawing seed:", err)
+		respondEphemeral(s, i, "❌ Failed to roll!", nil)
+		return
+	}
+	roll := hundredths(newFairStream(seed).Intn(diceRolls))
+	outcome := diceOutcome(bet, roll, target, over, rtp)
+	side := pick(over, "over", "under")
+
+	gameID, err := store.SettleGame(GameResult{
+		UserID:   userID,
+		GameType: "dice",
+		Bet:      bet,
+		Outcome:  outcome,
+		OneShot:  true,
+		SeedID:   seed.ID,
+		Nonce:    seed.Nonce,
+		Params:   fmt.Sprintf("target=%s,side=%s,rtp=%s", target, side, rtp.RatString()),
+	})
+	if err == ErrInsufficientBalance {
+		respondEphemeral(s, i, "❌ Insufficient balance or concurrent transaction!", nil)
+		return
+	} else if err != nil {
+		log.Printf("DB error settling dice for user %s: %v", userID, err)
+		respondEphemeral(s, i, "❌ Database error!", nil)
+		return
+	}
+
+	msg := fmt.Sprintf("🎲 **Dice**: roll %s %s to win %sx\n", side, target, formatMultiplier(multiplier))
+	msg += fmt.Sprintf("🎯 Rolled **%s**\n", roll)
+	if outcome >= 0 {
+		msg += fmt.Sprintf("💵 Profit: +%s\n", outcome)
+	} else {
+		msg += fmt.Sprintf("💸 Loss: %s\n", -outcome)
+	}
+	msg += fmt.Sprintf("👤 Balance: %s\n", balance+outcome)
+	msg += fmt.Sprintf("🎲 Game #%d (`/verify %d`)\n", gameID, gameID)
+
+	again := fmt.Sprintf("diceagain_%s_%s_%s", bet, target, side)
+	if err := sendNewMessage(s, i, msg, []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Roll Again 🎲", Style: discordgo.PrimaryButton, CustomID: again},
+	}}}); err != nil {
+		log.Println("sendNewMessage error (dice):", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import "testing"
+
+func TestDiceRTP(t *testing.T) {
+	// Every target returns RTP to the cent, give or take truncating the payout
+	const bet Money = 10000
+	for _, target := range []hundredths{minDiceTarget, 4950, 5050, 9000, maxDiceTarget} {
+		for _, over := range []bool{true, false} {
+			var returned Money
+			for roll := hundredths(0); roll < diceRolls; roll++ {
+				returned += bet + diceOutcome(bet, roll, target, over, defaultDiceRTP)
+			}
+			avg := returned / diceRolls
+			if want := bet.MulRat(defaultDiceRTP); avg < want-1 || avg > want {
+				t.Errorf("target %s %s returns %s a roll; want %s", target, pick(over, "over", "under"), avg, want)
+			}
+		}
+	}
+}
+
+func TestDiceBounds(t *testing.T) {
+	// The riskiest targets win on 1% of the rolls and the safest still pay more than the bet
+	for _, over := range []bool{true, false} {
+		risky, safe := minDiceTarget, maxDiceTarget
+		if over {
+			risky, safe = safe, risky
+		}
+		if wins := diceWins(risky, over); wins != 100 {
+			t.Errorf("%s %s wins on %d rolls; want 100", pick(over, "over", "under"), risky, wins)
+		}
+		if m := diceMultiplier(safe, over, defaultDiceRTP); m.Cmp(ratio(1, 1)) <= 0 {
+			t.Errorf("%s %s pays %sx; want more than 1x", pick(over, "over", "under"), safe, formatMultiplier(m))
+		}
+	}
+	// A roll on the target loses either way
+	for _, over := range []bool{true, false} {
+		if outcome := diceOutcome(100, 5000, 5000, over, defaultDiceRTP); outcome != -100 {
+			t.Errorf("roll on the target paid %s", outcome)
+		}
+	}
+}
+
+func TestLimboOutcome(t *testing.T) {
+	for _, c := range []struct {
+		result, target hundredths
+		want           Money
+	}{
+		{200, 200, 100},
+		{199, 200, -100},
+		{300, 101, 1},
+		{100, 101, -100},
+		{maxLimboTarget, maxLimboTarget, 999900},
+	} {
+		if got := limboOutcome(100, c.result, c.target); got != c.want {
+			t.Errorf("limboOutcome(1.00, %sx, %sx) = %s; want %s", c.result, c.target, got, c.want)
+		}
+	}
+}
+
+func TestWinsTruncatedToTheBet(t *testing.T) {
+	// 0.01 at 1.01x and under 98.99 both pay 0.0101, which truncates to the bet back
+	if got := limboOutcome(1, 150, 101); got != 0 {
+		t.Errorf("limbo win of 0.01 at 1.01x = %s; want 0.00", got)
+	}
+	if got := diceOutcome(1, 0, maxDiceTarget, false, defaultDiceRTP); got != 0 {
+		t.Errorf("dice win of 0.01 under %s = %s; want 0.00", maxDiceTarget, got)
+	}
+
+	st := newMemoryStore()
+	st.AddUser("1", "alice")
+	for _, gameType := range []string{"dice", "limbo"} {
+		if _, err := st.SettleGame(GameResult{UserID: "1", GameType: gameType, Bet: 1, Outcome: 0, OneShot: true}); err != nil {
+			t.Errorf("settling an even %s win error = %v", gameType, err)
+		}
+	}
+}
+
+func TestDrawMultiplier(t *testing.T) {
+	// A 2.00x target is reached on RTP/2 of the draws
+	rng := newSeededRNG(1)
+	const draws = 20000
+	reached := 0
+	for n := 0; n < draws; n++ {
+		m := drawMultiplier(rng, defaultDiceRTP)
+		if m < 100 {
+			t.Fatalf("drew %sx, below 1.00x", hundredths(m))
+		}
+		if m >= 200 {
+			reached++
+		}
+	}
+	if rate := float64(reached) / draws; rate < 0.48 || rate > 0.51 {
+		t.Errorf("%.3f of the draws reached 2.00x; want about 0.495", rate)
+	}
+}
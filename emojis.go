@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// emojiSet maps an emoji name to its formatted form, e.g. "<a:7_rounded_tile_0_0:123>".
+type emojiSet map[string]string
+
+func newEmojiSet(emojis []*discordgo.Emoji) emojiSet {
+	set := make(emojiSet, len(emojis))
+	for _, emoji := range emojis {
+		if emoji.Animated {
+			set[emoji.Name] = fmt.Sprintf("<a:%s:%s>", emoji.Name, emoji.ID)
+		} else {
+			set[emoji.Name] = fmt.Sprintf("<:%s:%s>", emoji.Name, emoji.ID)
+		}
+	}
+	return set
+}
+
+// Emoji cache of the guilds in guildIDs and of the application's own emojis.
+// Sets are replaced whole, never modified, so a spin only holds the read lock
+// for its lookups.
+var (
+	emojiMutex  sync.RWMutex
+	guildEmojis = make(map[string]emojiSet) // guildID -> emojis, once loaded
+	appEmojis   emojiSet                    // nil until loaded
+)
+
+// loadEmojis fetches every emoji source that isn't cached yet. A source that
+// fails to load is cached empty and filled by its next GuildEmojisUpdate.
+func loadEmojis(s *discordgo.Session) {
+	emojiMutex.RLock()
+	var missing []string
+	for _, guildID := range guildIDs {
+		if _, ok := guildEmojis[guildID]; !ok && guildID != "" {
+			missing = append(missing, guildID)
+		}
+	}
+	loadApp := appEmojis == nil
+	emojiMutex.RUnlock()
+	if len(missing) == 0 && !loadApp {
+		return
+	}
+
+	fetched := make(map[string]emojiSet)
+	for _, guildID := range missing {
+		emojis, err := s.GuildEmojis(guildID)
+		if err != nil {
+			log.Printf("Failed to load emojis of guild %s: %v", guildID, err)
+		}
+		fetched[guildID] = newEmojiSet(emojis)
+		log.Printf("Loaded %d emojis of guild %s", len(fetched[guildID]), guildID)
+	}
+	var app emojiSet
+	if loadApp {
+		var emojis []*discordgo.Emoji
+		if s.State != nil && s.State.User != nil {
+			var err error
+			if emojis, err = s.ApplicationEmojis(s.State.User.ID); err != nil {
+				log.Printf("Failed to load application emojis: %v", err)
+			}
+		}
+		app = newEmojiSet(emojis)
+		log.Printf("Loaded %d application emojis", len(app))
+	}
+
+	emojiMutex.Lock()
+	defer emojiMutex.Unlock()
+	for guildID, set := range fetched {
+		if _, ok := guildEmojis[guildID]; !ok {
+			guildEmojis[guildID] = set
+		}
+	}
+	if app != nil && appEmojis == nil {
+		appEmojis = app
+	}
+}
+
+// onGuildEmojisUpdate replaces the cached emojis of a guild in guildIDs
+// whenever they're added, renamed or removed.
+func onGuildEmojisUpdate(s *discordgo.Session, e *discordgo.GuildEmojisUpdate) {
+	for _, guildID := range guildIDs {
+		if guildID == e.GuildID {
+			set := newEmojiSet(e.Emojis)
+			emojiMutex.Lock()
+			guildEmojis[e.GuildID] = set
+			emojiMutex.Unlock()
+			log.Printf("Reloaded %d emojis of guild %s", len(set), e.GuildID)
+			return
+		}
+	}
+}
+
+// lookupEmoji finds an emoji by name in the guilds in order, then among the
+// application's emojis.
+func lookupEmoji(s *discordgo.Session, name string) (string, bool) {
+	loadEmojis(s)
+	emojiMutex.RLock()
+	defer emojiMutex.RUnlock()
+	for _, guildID := range guildIDs {
+		if emoji, ok := guildEmojis[guildID][name]; ok {
+			return emoji, true
+		}
+	}
+	emoji, ok := appEmojis[name]
+	return emoji, ok
+}
+
+// getEmoji is lookupEmoji with a fallback for emojis that don't exist.
+func getEmoji(name string, fallback string, s *discordgo.Session) string {
+	if emoji, ok := lookupEmoji(s, name); ok {
+		return emoji
+	}
+	return fallback
+}
+
+// tileEmojiName is the emoji of one tile of a reel, e.g. "7F_rounded_tile_2_1".
+func tileEmojiName(tile string, row, col int) string {
+	return fmt.Sprintf("%s_rounded_tile_%d_%d", tile, row, col)
+}
+
+// emojiTileNames lists every emoji buildEmojiGrid may show for machines:
+// each classic symbol spinning and stopped, and the loading reel.
+func emojiTileNames(machines map[string]*SlotMachine) []string {
+	tiles := map[string]bool{"loading": true}
+	for _, m := range machines {
+		if m.linePays() {
+			continue
+		}
+		for _, asset := range m.Assets() {
+			tiles[asset] = true
+			tiles[asset+"F"] = true
+		}
+	}
+	var names []string
+	for tile := range tiles {
+		for row := 0; row < tileRows; row++ {
+			for col := 0; col < tileCols; col++ {
+				names = append(names, tileEmojiName(tile, row, col))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateEmojis reports the emojis machines need that no source has.
+func validateEmojis(s *discordgo.Session, machines map[string]*SlotMachine) error {
+	var missing []string
+	for _, name := range emojiTileNames(machines) {
+		if _, ok := lookupEmoji(s, name); !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%d slot emojis are missing: %s", len(missing), strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// withEmojis swaps in the emojis of guild g1 (g2 has none) and of the
+// application as if they had been loaded.
+func withEmojis(t *testing.T, g1, app []*discordgo.Emoji) {
+	t.Helper()
+	oldIDs, oldGuilds, oldApp := guildIDs, guildEmojis, appEmojis
+	t.Cleanup(func() { guildIDs, guildEmojis, appEmojis = oldIDs, oldGuilds, oldApp })
+	guildIDs = []string{"g1", "g2"}
+	guildEmojis = map[string]emojiSet{"g1": newEmojiSet(g1), "g2": {}}
+	appEmojis = newEmojiSet(app)
+}
+
+func TestEmojiCache(t *testing.T) {
+	withEmojis(t,
+		[]*discordgo.Emoji{{ID: "1", Name: "a", Animated: true}},
+		[]*discordgo.Emoji{{ID: "2", Name: "a"}, {ID: "3", Name: "b"}})
+
+	if got := getEmoji("a", "?", nil); got != "<a:a:1>" {
+		t.Errorf("a = %q; want the guild's animated emoji", got)
+	}
+	if got := getEmoji("b", "?", nil); got != "<:b:3>" {
+		t.Errorf("b = %q; want the application's emoji", got)
+	}
+	if got := getEmoji("c", "?", nil); got != "?" {
+		t.Errorf("c = %q; want the fallback", got)
+	}
+
+	onGuildEmojisUpdate(nil, &discordgo.GuildEmojisUpdate{GuildID: "g2", Emojis: []*discordgo.Emoji{{ID: "4", Name: "c"}}})
+	onGuildEmojisUpdate(nil, &discordgo.GuildEmojisUpdate{GuildID: "g1"})
+	onGuildEmojisUpdate(nil, &discordgo.GuildEmojisUpdate{GuildID: "other", Emojis: []*discordgo.Emoji{{ID: "5", Name: "d"}}})
+	for name, want := range map[string]string{"a": "<:a:2>", "c": "<:c:4>", "d": "?"} {
+		if got := getEmoji(name, "?", nil); got != want {
+			t.Errorf("after the updates %s = %q; want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidateEmojis(t *testing.T) {
+	machines := map[string]*SlotMachine{"classic": slotMachines["classic"], "video": slotMachines["video"]}
+	names := emojiTileNames(machines)
+	if want := (2*len(slotMachines["classic"].Symbols) + 1) * tileRows * tileCols; len(names) != want {
+		t.Fatalf("%d tile names; want %d", len(names), want)
+	}
+
+	var emojis []*discordgo.Emoji
+	for _, name := range names {
+		emojis = append(emojis, &discordgo.Emoji{ID: "1", Name: name})
+	}
+	withEmojis(t, emojis[1:], nil)
+	err := validateEmojis(nil, machines)
+	if err == nil || !strings.Contains(err.Error(), names[0]) || strings.Contains(err.Error(), names[1]) {
+		t.Errorf("validateEmojis = %v; want only %s missing", err, names[0])
+	}
+	withEmojis(t, emojis[1:], emojis[:1])
+	if err := validateEmojis(nil, machines); err != nil {
+		t.Errorf("validateEmojis with the last one uploaded to the application = %v", err)
+	}
+}
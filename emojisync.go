@@ -0,0 +1,335 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Emoji slots of a guild by boost tier, for static and animated emojis each.
+var guildEmojiLimits = map[discordgo.PremiumTier]int{
+	discordgo.PremiumTierNone: 50,
+	discordgo.PremiumTier1:    100,
+	discordgo.PremiumTier2:    150,
+	discordgo.PremiumTier3:    250,
+}
+
+// appEmojiLimit is how many emojis an application can own, of either kind.
+const appEmojiLimit = 2000
+
+// maxEmojiSize is the largest image Discord takes for an emoji.
+const maxEmojiSize = 256 * 1024
+
+// emojiAsset is a tile image in gifs/ and the emoji name it's uploaded as.
+type emojiAsset struct {
+	Name     string
+	Path     string
+	Animated bool
+	Data     []byte
+}
+
+// emojiTarget is a guild, or the application when Guild is "", that tile
+// emojis are synced to.
+type emojiTarget struct {
+	Guild       string
+	Emojis      []*discordgo.Emoji
+	MaxStatic   int
+	MaxAnimated int
+	MaxTotal    int // 0 when only the per-kind limits apply
+}
+
+func (t *emojiTarget) String() string {
+	if t.Guild == "" {
+		return "application"
+	}
+	return "guild " + t.Guild
+}
+
+// emojiAction is one step of a sync plan. Replacing an emoji deletes it and
+// uploads the new image, in the same target if there's room.
+type emojiAction struct {
+	Op     string // "upload", "replace" or "delete"
+	Target *emojiTarget
+	Asset  *emojiAsset      // upload, replace
+	Emoji  *discordgo.Emoji // replace, delete
+}
+
+func (a emojiAction) String() string {
+	name := ""
+	if a.Asset != nil {
+		name = a.Asset.Name
+	} else {
+		name = a.Emoji.Name
+	}
+	return fmt.Sprintf("%-7s %-28s %s", a.Op, name, a.Target)
+}
+
+// managedEmoji reports whether name is a tile emoji sync-emojis looks after;
+// anything else in a guild is left alone.
+func managedEmoji(name string) bool {
+	return strings.Contains(name, "_rounded_tile_")
+}
+
+// loadEmojiAssets reads the tiles names need from dir, laid out like gifs/,
+// preferring an animated gif over a still png of the same name.
+func loadEmojiAssets(dir fs.FS, names []string) ([]*emojiAsset, error) {
+	var assets []*emojiAsset
+	var missing []string
+	for _, name := range names {
+		tile, _, _ := strings.Cut(name, "_rounded_tile_")
+		base := path.Join("tiles_"+strings.TrimSuffix(tile, "F"), name)
+		asset := &emojiAsset{Name: name, Path: base + ".gif", Animated: true}
+		data, err := fs.ReadFile(dir, asset.Path)
+		if err != nil {
+			asset.Path, asset.Animated = base+".png", false
+			if data, err = fs.ReadFile(dir, asset.Path); err != nil {
+				missing = append(missing, name)
+				continue
+			}
+		}
+		if len(data) > maxEmojiSize {
+			return nil, fmt.Errorf("%s is %d bytes, emojis can be at most %d", asset.Path, len(data), maxEmojiSize)
+		}
+		asset.Data = data
+		assets = append(assets, asset)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no tile image for %s", strings.Join(missing, ", "))
+	}
+	return assets, nil
+}
+
+// planEmojiSync works out how to make targets hold exactly one emoji per
+// asset. Stale and duplicate tile emojis are deleted, changed ones are
+// replaced, and missing ones go to the first target with a free slot of
+// their kind. changed compares an asset with the emoji of its name.
+func planEmojiSync(assets []*emojiAsset, targets []*emojiTarget, changed func(*emojiAsset, *discordgo.Emoji) bool) ([]emojiAction, error) {
+	wanted := make(map[string]*emojiAsset, len(assets))
+	for _, asset := range assets {
+		wanted[asset.Name] = asset
+	}
+
+	// Slots used per target once the plan has run
+	static := make([]int, len(targets))
+	animated := make([]int, len(targets))
+	for k, t := range targets {
+		for _, e := range t.Emojis {
+			if e.Animated {
+				animated[k]++
+			} else {
+				static[k]++
+			}
+		}
+	}
+	free := func(k int, e *discordgo.Emoji) {
+		if e.Animated {
+			animated[k]--
+		} else {
+			static[k]--
+		}
+	}
+
+	var deletes, uploads []emojiAction
+	replacing := make(map[string]int) // asset name -> its delete in deletes
+	kept := make(map[string]bool)
+	for k, t := range targets {
+		for _, e := range t.Emojis {
+			if !managedEmoji(e.Name) {
+				continue
+			}
+			asset := wanted[e.Name]
+			switch {
+			case asset == nil || kept[e.Name]:
+				deletes = append(deletes, emojiAction{Op: "delete", Target: t, Emoji: e})
+				free(k, e)
+			case changed(asset, e):
+				replacing[e.Name] = len(deletes)
+				deletes = append(deletes, emojiAction{Op: "delete", Target: t, Emoji: e})
+				free(k, e)
+			default:
+				kept[e.Name] = true
+			}
+		}
+	}
+
+	fits := func(k int, a *emojiAsset) bool {
+		t := targets[k]
+		if t.MaxTotal > 0 && static[k]+animated[k] >= t.MaxTotal {
+			return false
+		}
+		if a.Animated {
+			return animated[k] < t.MaxAnimated
+		}
+		return static[k] < t.MaxStatic
+	}
+	var full []string
+	for _, asset := range assets {
+		if kept[asset.Name] {
+			continue
+		}
+		// A replacement stays where the old image was if it fits there
+		k := 0
+		if d, ok := replacing[asset.Name]; ok {
+			for targets[k] != deletes[d].Target {
+				k++
+			}
+		}
+		if !fits(k, asset) {
+			for k = 0; k < len(targets) && !fits(k, asset); k++ {
+			}
+		}
+		if k == len(targets) {
+			full = append(full, asset.Name)
+			continue
+		}
+		if asset.Animated {
+			animated[k]++
+		} else {
+			static[k]++
+		}
+		if d, ok := replacing[asset.Name]; ok && deletes[d].Target == targets[k] {
+			deletes[d].Op, deletes[d].Asset = "replace", asset
+			continue
+		}
+		uploads = append(uploads, emojiAction{Op: "upload", Target: targets[k], Asset: asset})
+	}
+	if len(full) > 0 {
+		return nil, fmt.Errorf("no free emoji slots for %d tiles (%s), add another guild", len(full), strings.Join(full, ", "))
+	}
+	return append(deletes, uploads...), nil
+}
+
+// runSyncEmojis implements `sync-emojis [-dry-run] [-dir gifs] [-app | -guilds id,...]`.
+func runSyncEmojis(args []string) error {
+	fset := flag.NewFlagSet("sync-emojis", flag.ExitOnError)
+	dryRun := fset.Bool("dry-run", false, "print the plan without changing any emojis")
+	dir := fset.String("dir", "gifs", "directory of tiles_* folders to upload")
+	app := fset.Bool("app", false, "sync the application's own emojis instead of guild emojis")
+	guildList := fset.String("guilds", strings.Join(guildIDs, ","), "comma-separated guilds to sync, filled in order")
+	fset.Parse(args)
+
+	machines, err := loadSlotMachines(os.Getenv("slotConfig"))
+	if err != nil {
+		return err
+	}
+	assets, err := loadEmojiAssets(os.DirFS(*dir), emojiTileNames(machines))
+	if err != nil {
+		return err
+	}
+
+	s, err := discordgo.New("Bot " + os.Getenv("gamblingBotToken"))
+	if err != nil {
+		return err
+	}
+	var targets []*emojiTarget
+	appID := ""
+	if *app {
+		me, err := s.User("@me")
+		if err != nil {
+			return err
+		}
+		appID = me.ID
+		emojis, err := s.ApplicationEmojis(appID)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, &emojiTarget{Emojis: emojis, MaxStatic: appEmojiLimit, MaxAnimated: appEmojiLimit, MaxTotal: appEmojiLimit})
+	} else {
+		for _, id := range strings.Split(*guildList, ",") {
+			if id = strings.TrimSpace(id); id == "" {
+				continue
+			}
+			guild, err := s.Guild(id)
+			if err != nil {
+				return fmt.Errorf("guild %s: %w", id, err)
+			}
+			limit := guildEmojiLimits[guild.PremiumTier]
+			targets = append(targets, &emojiTarget{Guild: id, Emojis: guild.Emojis, MaxStatic: limit, MaxAnimated: limit})
+		}
+		if len(targets) == 0 {
+			return fmt.Errorf("no guilds to sync, pass -guilds or -app")
+		}
+	}
+
+	plan, err := planEmojiSync(assets, targets, func(a *emojiAsset, e *discordgo.Emoji) bool {
+		if a.Animated != e.Animated {
+			return true
+		}
+		current, err := fetchEmojiImage(s, e)
+		if err != nil {
+			log.Printf("Can't compare %s, keeping it: %v", e.Name, err)
+			return false
+		}
+		return sha256.Sum256(current) != sha256.Sum256(a.Data)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range plan {
+		fmt.Println(a)
+		if *dryRun {
+			continue
+		}
+		if err := applyEmojiAction(s, appID, a); err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(strings.Fields(a.String()), " "), err)
+		}
+	}
+	fmt.Printf("%d emoji change(s) %s, %d tiles\n", len(plan), pick(*dryRun, "planned", "made"), len(assets))
+	return nil
+}
+
+// fetchEmojiImage downloads the image an emoji shows.
+func fetchEmojiImage(s *discordgo.Session, e *discordgo.Emoji) ([]byte, error) {
+	url := discordgo.EndpointEmoji(e.ID)
+	if e.Animated {
+		url = discordgo.EndpointEmojiAnimated(e.ID)
+	}
+	resp, err := s.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// applyEmojiAction carries out one step of a plan; appID is set when the
+// target is the application.
+func applyEmojiAction(s *discordgo.Session, appID string, a emojiAction) error {
+	if a.Emoji != nil {
+		var err error
+		if a.Target.Guild == "" {
+			err = s.ApplicationEmojiDelete(appID, a.Emoji.ID)
+		} else {
+			err = s.GuildEmojiDelete(a.Target.Guild, a.Emoji.ID)
+		}
+		if err != nil || a.Asset == nil {
+			return err
+		}
+	}
+
+	params := &discordgo.EmojiParams{
+		Name:  a.Asset.Name,
+		Image: fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(a.Asset.Data), base64.StdEncoding.EncodeToString(a.Asset.Data)),
+	}
+	var err error
+	if a.Target.Guild == "" {
+		_, err = s.ApplicationEmojiCreate(appID, params)
+	} else {
+		_, err = s.GuildEmojiCreate(a.Target.Guild, params)
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLoadEmojiAssets(t *testing.T) {
+	dir := fstest.MapFS{
+		"tiles_7/7_rounded_tile_0_0.gif":             {Data: []byte("gif")},
+		"tiles_7/7_rounded_tile_0_0.png":             {Data: []byte("png")},
+		"tiles_7/7F_rounded_tile_0_0.png":            {Data: []byte("png")},
+		"tiles_loading/loading_rounded_tile_0_0.png": {Data: make([]byte, maxEmojiSize+1)},
+	}
+	assets, err := loadEmojiAssets(dir, []string{"7_rounded_tile_0_0", "7F_rounded_tile_0_0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || !assets[0].Animated || string(assets[0].Data) != "gif" || assets[1].Animated {
+		t.Errorf("assets = %+v; want the spinning gif and the stopped png", assets)
+	}
+	if _, err := loadEmojiAssets(dir, []string{"7_rounded_tile_1_0"}); err == nil {
+		t.Error("loaded a tile that isn't there")
+	}
+	if _, err := loadEmojiAssets(dir, []string{"loading_rounded_tile_0_0"}); err == nil {
+		t.Error("loaded a tile too big for an emoji")
+	}
+}
+
+func TestPlanEmojiSync(t *testing.T) {
+	tile := func(name string, animated bool) *emojiAsset {
+		return &emojiAsset{Name: name + "_rounded_tile_0_0", Animated: animated}
+	}
+	emoji := func(id, name string, animated bool) *discordgo.Emoji {
+		return &discordgo.Emoji{ID: id, Name: name + "_rounded_tile_0_0", Animated: animated}
+	}
+	assets := []*emojiAsset{tile("1", true), tile("2", true), tile("3", true), tile("1F", false), tile("2F", false)}
+	g1 := &emojiTarget{Guild: "g1", MaxStatic: 2, MaxAnimated: 2, Emojis: []*discordgo.Emoji{
+		emoji("a", "1", true),
+		emoji("b", "2", true), // changed
+		emoji("c", "old", false),
+		{ID: "d", Name: "party"}, // not a tile, left alone
+	}}
+	g2 := &emojiTarget{Guild: "g2", MaxStatic: 2, MaxAnimated: 2, Emojis: []*discordgo.Emoji{
+		emoji("e", "1", true), // duplicate
+	}}
+
+	plan, err := planEmojiSync(assets, []*emojiTarget{g1, g2}, func(a *emojiAsset, e *discordgo.Emoji) bool { return e.ID == "b" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range plan {
+		got = append(got, strings.Join(strings.Fields(a.String()), " "))
+	}
+	want := []string{
+		"replace 2_rounded_tile_0_0 guild g1",
+		"delete old_rounded_tile_0_0 guild g1",
+		"delete 1_rounded_tile_0_0 guild g2",
+		"upload 3_rounded_tile_0_0 guild g2", // g1 has no animated slot left
+		"upload 1F_rounded_tile_0_0 guild g1",
+		"upload 2F_rounded_tile_0_0 guild g2", // party takes g1's other static slot
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("plan:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	g2.MaxAnimated = 1
+	if _, err := planEmojiSync(append(assets, tile("4", true)), []*emojiTarget{g1, g2}, func(*emojiAsset, *discordgo.Emoji) bool { return false }); err == nil {
+		t.Error("planned more animated emojis than the guilds hold")
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// FairSeed is one provably-fair seed pair of a user. The server seed stays
+// secret while the pair is active (only its hash is shown) and is revealed
+// when the user rotates to a new pair. Every game draws the next nonce, and
+// its outcome is derived from HMAC-SHA256(server seed, "client:nonce:round").
+type FairSeed struct {
+	ID             int64
+	UserID         string
+	ServerSeed     string
+	ServerSeedHash string
+	ClientSeed     string
+	Nonce          int64 // the next nonce to be used
+	Active         bool
+}
+
+// newFairSeed generates a fresh server seed for userID with the given client seed.
+func newFairSeed(userID, clientSeed string) (*FairSeed, error) {
+	serverSeed, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	return &FairSeed{
+		UserID:         userID,
+		ServerSeed:     serverSeed,
+		ServerSeedHash: hashServerSeed(serverSeed),
+		ClientSeed:     clientSeed,
+		Active:         true,
+	}, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashServerSeed is the commitment shown to the player before they play.
+func hashServerSeed(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// validClientSeed keeps client seeds short and printable.
+func validClientSeed(s string) bool {
+	if len(s) == 0 || len(s) > 32 {
+		return false
+	}
+	for _, r := range s {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// seededGameTypes keep drawing from the seed their game started on until it
+// is settled, so that seed can't be revealed while one of them is open.
+var seededGameTypes = []string{"mines", "blackjack"}
+
+// drawSeed returns the user's active seed with the nonce this game uses,
+// creating a seed pair on first play.
+func drawSeed(st Store, userID string) (*FairSeed, error) {
+	seed, err := st.UseSeed(userID)
+	if err != ErrNotFound {
+		return seed, err
+	}
+
+	clientSeed, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := newFairSeed(userID, clientSeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := st.RotateSeed(userID, fresh); err != nil {
+		// a concurrent game may have created it first
+		log.Printf("Creating seed for %s: %v", userID, err)
+	}
+	return st.UseSeed(userID)
+}
+
+// fairStream turns a seed pair and nonce into a stream of bytes. Each round is
+// HMAC-SHA256(serverSeed, "<clientSeed>:<nonce>:<round>") and yields 32 bytes.
+type fairStream struct {
+	serverSeed string
+	clientSeed string
+	nonce      int64
+	cursor     int
+	block      []byte
+}
+
+func newFairStream(seed *FairSeed) *fairStream {
+	return &fairStream{serverSeed: seed.ServerSeed, clientSeed: seed.ClientSeed, nonce: seed.Nonce}
+}
+
+func (f *fairStream) nextByte() byte {
+	if f.cursor%sha256.Size == 0 {
+		mac := hmac.New(sha256.New, []byte(f.serverSeed))
+		fmt.Fprintf(mac, "%s:%d:%d", f.clientSeed, f.nonce, f.cursor/sha256.Size)
+		f.block = mac.Sum(nil)
+	}
+	b := f.block[f.cursor%sha256.Size]
+	f.cursor++
+	return b
+}
+
+// Float returns a number in [0, 1) built from the next 4 bytes.
+func (f *fairStream) Float() float64 {
+	var v, scale float64 = 0, 1
+	for k := 0; k < 4; k++ {
+		scale /= 256
+		v += float64(f.nextByte()) * scale
+	}
+	return v
+}
+
+// Intn returns a number in [0, n).
+func (f *fairStream) Intn(n int) int {
+	return int(f.Float() * float64(n))
+}
+
+// Int63n returns a number in [0, n).
+func (f *fairStream) Int63n(n int64) int64 {
+	return int64(f.Float() * float64(n))
+}
+
+// drawMultiplier draws a multiplier in hundredths, at least 1.00x, that
+// reaches any m with probability rtp/m: with h one draw of k = 2³², it's
+// 100·rtp / (1 − h/2³²) rounded down.
+func drawMultiplier(rng RNG, rtp *big.Rat) int64 {
+	h := rng.Int63n(1 << 32)
+	p := new(big.Rat).Mul(rtp, ratio(100<<32, 1<<32-h))
+	m := new(big.Int).Quo(p.Num(), p.Denom()).Int64()
+	if m < 100 {
+		return 100
+	}
+	return m
+}
+
+// drawMinesBoard places numMines mines on a size x size board by drawing them
+// one at a time from the tiles still free, numbered row by row from 0.
+func drawMinesBoard(rng RNG, size, numMines int) [][]bool {
+	board := newMinesGrid(size)
+	free := make([]int, size*size)
+	for k := range free {
+		free[k] = k
+	}
+	for k := 0; k < numMines; k++ {
+		idx := rng.Intn(len(free))
+		pos := free[idx]
+		free = append(free[:idx], free[idx+1:]...)
+		board[pos/size][pos%size] = true
+	}
+	return board
+}
+
+// HandleFairnessCommand shows the active seed pair or rotates it, revealing the old server seed.
+func HandleFairnessCommand(s *discordgo.Session, i *discordgo.InteractionCreate, st Store, userID string) {
+	action, clientSeed := "show", ""
+	for _, opt := range i.ApplicationCommandData().Options {
+		switch opt.Name {
+		case "action":
+			action = opt.StringValue()
+		case "client_seed":
+			clientSeed = strings.TrimSpace(opt.StringValue())
+		}
+	}
+
+	current, err := st.CurrentSeed(userID)
+	if err != nil && err != ErrNotFound {
+		log.Println("DB error (fairness):", err)
+		respondEphemeral(s, i, "⚠️ Database error, please try again later.", nil)
+		return
+	}
+
+	if action == "show" && current != nil && clientSeed == "" {
+		respondEphemeral(s, i, fmt.Sprintf(
+			"🔐 Server seed hash: `%s`\n🌱 Client seed: `%s`\n🔢 Next nonce: %d\n\nUse `/fairness rotate` to reveal the server seed and start a new pair.",
+			current.ServerSeedHash, current.ClientSeed, current.Nonce), nil)
+		return
+	}
+
+	// Rotating (or setting a client seed, or first use) starts a new pair
+	if clientSeed == "" {
+		if current != nil {
+			clientSeed = current.ClientSeed
+		} else if clientSeed, err = randomHex(8); err != nil {
+			log.Println("Error generating client seed:", err)
+			respondEphemeral(s, i, "❌ Failed to create seed!", nil)
+			return
+		}
+	}
+	if !validClientSeed(clientSeed) {
+		respondEphemeral(s, i, "❌ Client seed must be 1-32 printable characters without spaces!", nil)
+		return
+	}
+
+	next, err := newFairSeed(userID, clientSeed)
+	if err != nil {
+		log.Println("Error generating server seed:", err)
+		respondEphemeral(s, i, "❌ Failed to create seed!", nil)
+		return
+	}
+	previous, err := st.RotateSeed(userID, next)
+	if err == ErrGameActive {
+		respondEphemeral(s, i, "❌ Finish your game first, its outcome depends on the current server seed!", nil)
+		return
+	} else if err != nil {
+		log.Println("DB error (rotate seed):", err)
+		respondEphemeral(s, i, "⚠️ Database error, please try again later.", nil)
+		return
+	}
+
+	msg := ""
+	if previous != nil {
+		msg += fmt.Sprintf("🔓 Previous server seed: `%s`\n   hash `%s`, client seed `%s`, %d game(s) played\n\n",
+			previous.ServerSeed, previous.ServerSeedHash, previous.ClientSeed, previous.Nonce)
+	}
+	msg += fmt.Sprintf("🔐 New server seed hash: `%s`\n🌱 Client seed: `%s`\n🔢 Next nonce: 0", next.ServerSeedHash, next.ClientSeed)
+	respondEphemeral(s, i, msg, nil)
+}
+
+// HandleVerifyCommand recomputes a past game from its revealed seed pair.
+func HandleVerifyCommand(s *discordgo.Session, i *discordgo.InteractionCreate, st Store, gameID int64) {
+	game, err := st.GetGame(gameID)
+	if err == ErrNotFound {
+		respondEphemeral(s, i, "❌ Game not found!", nil)
+		return
+	} else if err != nil {
+		log.Println("DB error (verify):", err)
+		respondEphemeral(s, i, "⚠️ Database error, please try again later.", nil)
+		return
+	}
+	// Crash rounds and coinflip duels are drawn from a seed of their own, not the player's
+	switch game.GameType {
+	case "crash":
+		respondEphemeral(s, i, verifyCrash(st, game), nil)
+		return
+	case "coinflip":
+		respondEphemeral(s, i, verifyCoinflip(st, game), nil)
+		return
+	}
+	if game.SeedID == 0 {
+		respondEphemeral(s, i, "❌ This game was played before provably fair seeds and can't be verified.", nil)
+		return
+	}
+
+	seed, err := st.GetSeed(game.SeedID)
+	if err != nil {
+		log.Println("DB error (verify seed):", err)
+		respondEphemeral(s, i, "⚠️ Database error, please try again later.", nil)
+		return
+	}
+	if seed.Active {
+		respondEphemeral(s, i, fmt.Sprintf(
+			"🔒 Game #%d used a server seed that is still active (hash `%s`).\nUse `/fairness rotate` to reveal it, then verify again.",
+			game.ID, seed.ServerSeedHash), nil)
+		return
+	}
+
+	seed.Nonce = game.Nonce
+	msg := fmt.Sprintf("🎲 Game #%d (%s) by <@%s>\n🔓 Server seed: `%s`\n🔐 Hash: `%s` %s\n🌱 Client seed: `%s`\n🔢 Nonce: %d\n\n",
+		game.ID, game.GameType, game.UserID, seed.ServerSeed, seed.ServerSeedHash,
+		pick(hashServerSeed(seed.ServerSeed) == seed.ServerSeedHash, "✅", "❌ does not match"),
+		seed.ClientSeed, game.Nonce)
+
+	switch game.GameType {
+	case "mines":
+		// Games from before board sizes were logged without one
+		size := defaultMinesSize
+		if v := gameParam(game.Params, "size"); v != "" {
+			size, _ = strconv.Atoi(v)
+		}
+		if size < minMinesSize || size > maxMinesSize {
+			msg += fmt.Sprintf("❌ Unknown board size %q", gameParam(game.Params, "size"))
+			break
+		}
+		numMines, err := strconv.Atoi(gameParam(game.Params, "mines"))
+		if err != nil || numMines < 1 || numMines > size*size-1 {
+			msg += fmt.Sprintf("❌ Unknown mines count %q", gameParam(game.Params, "mines"))
+			break
+		}
+		board := drawMinesBoard(newFairStream(seed), size, numMines)
+		msg += fmt.Sprintf("💣 %dx%d board with %d mines:\n", size, size, numMines)
+		for _, row := range board {
+			for _, mine := range row {
+				msg += pick(mine, "💣", "💎")
+			}
+			msg += "\n"
+		}
+	case "slot":
+		m, err := machineFromParams(game.Params)
+		if err != nil {
+			msg += "❌ " + err.Error()
+			break
+		}
+		var expected Money
+		if m.linePays() {
+			ss := m.PlaySession(newFairStream(seed), game.Amount/Money(len(m.Paylines)))
+			msg += fmt.Sprintf("🎰 Spin (%s):\n%s\n", m.Name, m.formatGrid(ss.Spins[0].Grid, len(m.Reels)))
+			// Every free spin's grid would overflow the message; their total is enough to check
+			if free := len(ss.Spins) - 1; free > 0 {
+				msg += fmt.Sprintf("🎁 %d free spins paid %s\n", free, ss.Win-ss.Spins[0].Win)
+			}
+			expected = ss.Outcome()
+		} else {
+			reels := m.Spin(newFairStream(seed))
+			expected = m.Outcome(game.Amount, reels)
+			msg += fmt.Sprintf("🎰 Reels (%s): `%s`\n", m.Name, m.formatLine(reels))
+			if m.JackpotLine(reels) {
+				// The pool depends on every spin before this one, not on the seeds
+				msg += fmt.Sprintf("🏆 Jackpot line: paid %s from the pool", game.Outcome)
+				break
+			}
+		}
+		msg += fmt.Sprintf("💵 Outcome: %s %s", expected, pick(expected == game.Outcome, "✅", "❌ does not match the logged "+game.Outcome.String()))
+	case "blackjack":
+		decks, _ := strconv.Atoi(gameParam(game.Params, "decks"))
+		dealt, _ := strconv.Atoi(gameParam(game.Params, "cards"))
+		if decks < 1 || dealt < 1 || dealt > 52*decks {
+			msg += fmt.Sprintf("❌ Unknown shoe %q", game.Params)
+			break
+		}
+		// The cards went to the player, the dealer, the player, the hole card,
+		// then in the order they were drawn
+		shoe := drawShoe(newFairStream(seed), decks)
+		msg += fmt.Sprintf("🃏 First %d cards of the %d-deck shoe:\n%s", dealt, decks, formatCards(shoe[:dealt]))
+	case "roulette":
+		bets, err := parseRouletteBets(gameParam(game.Params, "bets"))
+		if err != nil {
+			msg += "❌ " + err.Error()
+			break
+		}
+		pocket := newFairStream(seed).Intn(roulettePockets)
+		expected := roulettePayout(bets, pocket) - rouletteStake(bets)
+		msg += fmt.Sprintf("🎡 Pocket: %s\n", pocketLabel(pocket))
+		msg += fmt.Sprintf("💵 Outcome: %s %s", expected, pick(expected == game.Outcome, "✅", "❌ does not match the logged "+game.Outcome.String()))
+	case "dice", "limbo":
+		target, err := parseHundredths(gameParam(game.Params, "target"))
+		rtp, ok := new(big.Rat).SetString(gameParam(game.Params, "rtp"))
+		if err != nil || !ok {
+			msg += fmt.Sprintf("❌ Unknown target %q", game.Params)
+			break
+		}
+		var expected Money
+		if game.GameType == "dice" {
+			over := gameParam(game.Params, "side") == "over"
+			roll := hundredths(newFairStream(seed).Intn(diceRolls))
+			expected = diceOutcome(game.Amount, roll, target, over, rtp)
+			msg += fmt.Sprintf("🎲 Roll: %s, %s %s\n", roll, pick(over, "over", "under"), target)
+		} else {
+			result := hundredths(drawMultiplier(newFairStream(seed), rtp))
+			expected = limboOutcome(game.Amount, result, target)
+			msg += fmt.Sprintf("🚀 Result: %sx, target %sx\n", result, target)
+		}
+		msg += fmt.Sprintf("💵 Outcome: %s %s", expected, pick(expected == game.Outcome, "✅", "❌ does not match the logged "+game.Outcome.String()))
+	default:
+		msg += "This game type has nothing to recompute."
+	}
+	respondEphemeral(s, i, msg, nil)
+}
+
+// gameParam reads key from a games.params string like "mines=3".
+func gameParam(params, key string) string {
+	for _, kv := range strings.Split(params, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
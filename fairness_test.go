@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testSeed is a fixed seed pair so boards drawn in tests are reproducible.
+var testSeed = &FairSeed{
+	ID:             1,
+	UserID:         "1",
+	ServerSeed:     "5e1f0c7d2a9b4e8f6c3d1a0b7e9f2c4d5e1f0c7d2a9b4e8f6c3d1a0b7e9f2c4d",
+	ServerSeedHash: hashServerSeed("5e1f0c7d2a9b4e8f6c3d1a0b7e9f2c4d5e1f0c7d2a9b4e8f6c3d1a0b7e9f2c4d"),
+	ClientSeed:     "lucky",
+	Active:         true,
+}
+
+func TestFairMinesBoard(t *testing.T) {
+	for size := minMinesSize; size <= maxMinesSize; size++ {
+		for numMines := 1; numMines < size*size; numMines++ {
+			board := drawMinesBoard(newFairStream(testSeed), size, numMines)
+			mines := 0
+			for r := 0; r < size; r++ {
+				for c := 0; c < size; c++ {
+					if board[r][c] {
+						mines++
+					}
+				}
+			}
+			if len(board) != size || mines != numMines {
+				t.Errorf("%dx%d board for %d mines has %d rows and %d mines", size, size, numMines, len(board), mines)
+			}
+			if again := drawMinesBoard(newFairStream(testSeed), size, numMines); !reflect.DeepEqual(again, board) {
+				t.Errorf("%dx%d board for %d mines isn't reproducible", size, size, numMines)
+			}
+		}
+	}
+
+	next := *testSeed
+	next.Nonce++
+	if reflect.DeepEqual(drawMinesBoard(newFairStream(&next), 4, 3), drawMinesBoard(newFairStream(testSeed), 4, 3)) {
+		t.Error("the next nonce drew the same board")
+	}
+}
+
+func TestDrawMinesBoard(t *testing.T) {
+	// every tile should come up as a mine about numMines/16 of the time
+	rng := newSeededRNG(1)
+	var hits [4][4]int
+	const boards = 16000
+	for k := 0; k < boards; k++ {
+		board := drawMinesBoard(rng, 4, 4)
+		for r := 0; r < 4; r++ {
+			for c := 0; c < 4; c++ {
+				if board[r][c] {
+					hits[r][c]++
+				}
+			}
+		}
+	}
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			if hits[r][c] < boards/4*9/10 || hits[r][c] > boards/4*11/10 {
+				t.Errorf("tile %d,%d was a mine %d times in %d boards; want about %d", r, c, hits[r][c], boards, boards/4)
+			}
+		}
+	}
+
+	if !reflect.DeepEqual(drawMinesBoard(newSeededRNG(5), 4, 6), drawMinesBoard(newSeededRNG(5), 4, 6)) {
+		t.Error("the same seed drew different boards")
+	}
+}
+
+func TestFairStream(t *testing.T) {
+	f := newFairStream(testSeed)
+	for k := 0; k < 100; k++ { // crosses several HMAC blocks
+		if v := f.Float(); v < 0 || v >= 1 {
+			t.Fatalf("draw %d = %v; want [0, 1)", k, v)
+		}
+	}
+
+	m := slotMachines[defaultSlotMachine]
+	reels := m.Spin(newFairStream(testSeed))
+	for _, r := range reels {
+		if r < 0 || r >= 12 {
+			t.Fatalf("reels = %v; want symbols 0-11", reels)
+		}
+	}
+	if again := m.Spin(newFairStream(testSeed)); again[0] != reels[0] || again[1] != reels[1] || again[2] != reels[2] {
+		t.Errorf("reels %v then %v from the same seed", reels, again)
+	}
+}
+
+func TestValidClientSeed(t *testing.T) {
+	for _, s := range []string{"lucky", "a", "0123456789abcdef0123456789abcdef"} {
+		if !validClientSeed(s) {
+			t.Errorf("validClientSeed(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"", "two words", "0123456789abcdef0123456789abcdef0", "🎰"} {
+		if validClientSeed(s) {
+			t.Errorf("validClientSeed(%q) = true", s)
+		}
+	}
+	if got := gameParam("size=4,mines=3", "mines"); got != "3" {
+		t.Errorf(`gameParam("size=4,mines=3", "mines") = %q; want "3"`, got)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// defaultMinesRTP is the share of every stake mines pays back on average when
+// the minesRTP env var doesn't say otherwise: a 4% house edge.
+var defaultMinesRTP = mustRat("0.96")
+
+// minesRTP is read from the minesRTP env var at startup.
+var minesRTP = rtpConfig{Default: defaultMinesRTP}
+
+// defaultDiceRTP is what dice and limbo pay back on average when the diceRTP
+// env var doesn't say otherwise: a 1% house edge.
+var defaultDiceRTP = mustRat("0.99")
+
+// diceRTP is read from the diceRTP env var at startup and covers limbo too.
+var diceRTP = rtpConfig{Default: defaultDiceRTP}
+
+// rtpConfig is a game's return-to-player, optionally overridden per guild.
+type rtpConfig struct {
+	Default *big.Rat
+	Guilds  map[string]*big.Rat // guild id -> RTP
+}
+
+// For returns the RTP for games started in guildID ("" outside a guild).
+func (c rtpConfig) For(guildID string) *big.Rat {
+	if r, ok := c.Guilds[guildID]; ok {
+		return r
+	}
+	return c.Default
+}
+
+// parseShare parses a share of the stake written as "0.96" or "96%".
+func parseShare(s string) (*big.Rat, bool) {
+	percent := strings.HasSuffix(s, "%")
+	r, ok := new(big.Rat).SetString(strings.TrimSuffix(s, "%"))
+	if ok && percent {
+		r.Quo(r, ratio(100, 1))
+	}
+	return r, ok
+}
+
+// parseRTP accepts "0.96" or "96%"; anything outside (0, 1] is refused so a
+// typo can't hand the house's money away.
+func parseRTP(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	r, ok := parseShare(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid RTP %q", s)
+	}
+	if r.Sign() <= 0 || r.Cmp(ratio(1, 1)) > 0 {
+		return nil, fmt.Errorf("RTP %q must be above 0 and at most 100%%", s)
+	}
+	return r, nil
+}
+
+// parseRake accepts "0.02" or "2%", and "" for no rake. A rake of 100% or
+// more would leave the winner nothing, so it's refused.
+func parseRake(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return new(big.Rat), nil
+	}
+	r, ok := parseShare(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid rake %q", s)
+	}
+	if r.Sign() < 0 || r.Cmp(ratio(1, 1)) >= 0 {
+		return nil, fmt.Errorf("rake %q must be at least 0 and under 100%%", s)
+	}
+	return r, nil
+}
+
+// parseRTPConfig parses a spec like "0.96,123456789012345678=0.98": an
+// optional default, def if left out, followed by per-guild overrides.
+func parseRTPConfig(spec string, def *big.Rat) (rtpConfig, error) {
+	cfg := rtpConfig{Default: def, Guilds: make(map[string]*big.Rat)}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		guildID, value, isGuild := strings.Cut(item, "=")
+		if !isGuild {
+			value = item
+		}
+		r, err := parseRTP(value)
+		if err != nil {
+			return cfg, err
+		}
+		if isGuild {
+			cfg.Guilds[strings.TrimSpace(guildID)] = r
+		} else {
+			cfg.Default = r
+		}
+	}
+	return cfg, nil
+}
+
+// rtpConfigFromEnv reads the RTP spec in env var name, falling back to def.
+func rtpConfigFromEnv(name string, def *big.Rat) (rtpConfig, error) {
+	return parseRTPConfig(os.Getenv(name), def)
+}
+
+// rtpFromFlag returns the RTP given on the command line, or the one the
+// minesRTP env var configures for guildID.
+func rtpFromFlag(flagValue, guildID string) (*big.Rat, error) {
+	if flagValue != "" {
+		return parseRTP(flagValue)
+	}
+	cfg, err := rtpConfigFromEnv("minesRTP", defaultMinesRTP)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.For(guildID), nil
+}
+
+// runMultipliers implements `multipliers [-rtp r] [-guild id] [-size n]`.
+func runMultipliers(args []string) error {
+	fs := flag.NewFlagSet("multipliers", flag.ExitOnError)
+	rtpFlag := fs.String("rtp", "", "mines RTP, e.g. 0.96 or 96% (default from the minesRTP env var)")
+	guildID := fs.String("guild", "", "show the minesRTP override for this guild")
+	size := fs.Int("size", defaultMinesSize, "board side, 3 to 5")
+	fs.Parse(args)
+
+	if *size < minMinesSize || *size > maxMinesSize {
+		return fmt.Errorf("-size must be between %d and %d", minMinesSize, maxMinesSize)
+	}
+
+	rtp, err := rtpFromFlag(*rtpFlag, *guildID)
+	if err != nil {
+		return err
+	}
+	printMultiplierTable(*size, rtp)
+	return nil
+}
+
+// printMultiplierTable writes the mines multiplier after every safe reveal,
+// one row per mines count, as players would see it at rtp on a size x size board.
+func printMultiplierTable(size int, rtp *big.Rat) {
+	tiles := size * size
+	fmt.Printf("%dx%d mines multipliers at %s%% RTP\n\n", size, size, new(big.Rat).Mul(rtp, ratio(100, 1)).FloatString(2))
+	fmt.Printf("%5s", "mines")
+	for step := 1; step < tiles; step++ {
+		fmt.Printf(" %9d", step)
+	}
+	fmt.Println()
+	for numMines := 1; numMines < tiles; numMines++ {
+		fmt.Printf("%5d", numMines)
+		for step := 1; step <= tiles-numMines; step++ {
+			fmt.Printf(" %9s", formatMultiplier(calculateMultiplier(step, numMines, tiles, rtp)))
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCalculateMultiplier(t *testing.T) {
+	tests := []struct {
+		revealed, mines, tiles int
+		rtp                    string
+		want                   string
+	}{
+		{0, 3, 16, "0.96", "1"}, // nothing revealed: the stake, no edge
+		{1, 1, 16, "1", "16/15"},
+		{1, 15, 16, "1", "16"},
+		{1, 15, 16, "0.96", "15.36"},
+		{2, 3, 16, "0.96", "96/65"}, // 16/13 × 15/12 × 0.96
+		{13, 3, 16, "1", "560"},     // C(16,3)
+		{13, 3, 16, "0.96", "537.6"},
+		{1, 1, 9, "1", "9/8"},
+		{6, 3, 9, "1", "84"},     // C(9,3)
+		{22, 3, 25, "1", "2300"}, // C(25,3)
+	}
+	for _, tt := range tests {
+		got := calculateMultiplier(tt.revealed, tt.mines, tt.tiles, mustRat(tt.rtp))
+		if got.Cmp(mustRat(tt.want)) != 0 {
+			t.Errorf("calculateMultiplier(%d, %d, %d, %s) = %s; want %s", tt.revealed, tt.mines, tt.tiles, tt.rtp, got.RatString(), tt.want)
+		}
+	}
+
+	// the displayed multiplier and the cashout agree
+	game := createMinesGame("1", "alice", 1000, 3, 4, testSeed, mustRat("0.96"))
+	game.RevealedSafe = 2
+	if got := formatMultiplier(game.multiplier()); got != "1.48" {
+		t.Errorf("displayed multiplier = %s; want 1.48", got)
+	}
+	if got := minesProfit(game.BetAmount, game.multiplier()); got != 476 {
+		t.Errorf("profit on 10.00 = %s; want 4.76", got)
+	}
+	game.RTP = nil // recorded before the house edge
+	if got := minesProfit(game.BetAmount, game.multiplier()); got != 538 {
+		t.Errorf("profit at fair odds = %s; want 5.38", got)
+	}
+}
+
+func TestParseRTPConfig(t *testing.T) {
+	cfg, err := parseRTPConfig(" 97% , 111=0.9,222 = 1", defaultMinesRTP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for guildID, want := range map[string]*big.Rat{"": mustRat("0.97"), "111": mustRat("0.9"), "222": mustRat("1"), "333": mustRat("0.97")} {
+		if got := cfg.For(guildID); got.Cmp(want) != 0 {
+			t.Errorf("For(%q) = %s; want %s", guildID, got.RatString(), want.RatString())
+		}
+	}
+
+	if cfg, err := parseRTPConfig("", defaultMinesRTP); err != nil || cfg.For("111").Cmp(defaultMinesRTP) != 0 {
+		t.Errorf("empty spec = %v, %v; want the default", cfg, err)
+	}
+	if cfg, err := parseRTPConfig("111=0.97", defaultDiceRTP); err != nil || cfg.For("222").Cmp(defaultDiceRTP) != 0 || cfg.For("111").Cmp(mustRat("0.97")) != 0 {
+		t.Errorf("dice spec = %v, %v; want 0.97 in guild 111 and the dice default elsewhere", cfg, err)
+	}
+	for _, spec := range []string{"abc", "0", "1.01", "-5%", "111=150%"} {
+		if _, err := parseRTPConfig(spec, defaultMinesRTP); err == nil {
+			t.Errorf("parseRTPConfig(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestParseRake(t *testing.T) {
+	for spec, want := range map[string]*big.Rat{"": new(big.Rat), "2%": mustRat("0.02"), " 0.05 ": mustRat("0.05"), "0": new(big.Rat)} {
+		if got, err := parseRake(spec); err != nil || got.Cmp(want) != 0 {
+			t.Errorf("parseRake(%q) = %v, %v; want %s", spec, got, err, want.RatString())
+		}
+	}
+	for _, spec := range []string{"abc", "1", "100%", "-1%"} {
+		if _, err := parseRake(spec); err == nil {
+			t.Errorf("parseRake(%q) succeeded", spec)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Ledger reasons. Every balance movement is one ledger transaction with a
+// reason and a reference (game id, transfer id, claim id, admin id), made of
+// postings that sum to zero.
+const (
+	reasonSignup         = "signup"
+	reasonGame           = "game"
+	reasonEscrow         = "escrow"
+	reasonTransfer       = "transfer"
+	reasonDaily          = "daily"
+	reasonAdminGrant     = "admin_grant"
+	reasonOpeningBalance = "opening_balance"
+)
+
+// posting is one side of a ledger transaction.
+type posting struct {
+	Account string
+	Amount  Money
+}
+
+func userAccount(userID string) string { return "user:" + userID }
+func houseAccount(name string) string  { return "house:" + name }
+
+// escrowAccount holds stakes of games that are still running.
+var escrowAccount = houseAccount(reasonEscrow)
+
+// jackpotAccount holds a slot machine's progressive jackpot pool.
+func jackpotAccount(machine string) string { return houseAccount("jackpot:" + machine) }
+
+// credit is what settling r adds to the user's balance.
+func (r GameResult) credit() Money {
+	if r.Escrowed {
+		return r.Bet + r.Outcome
+	}
+	return r.Outcome
+}
+
+// settlementPostings are the ledger postings for settling r. An escrowed stake
+// is released from escrow and the house side only carries the net outcome.
+// A jackpot's fee moves from the house into the pool and a jackpot win, already
+// counted in Outcome, is paid from the pool rather than the house.
+func settlementPostings(r GameResult) []posting {
+	var postings []posting
+	if r.Escrowed {
+		postings = []posting{
+			{userAccount(r.UserID), r.credit()},
+			{escrowAccount, -r.Bet},
+			{houseAccount(r.GameType), -r.Outcome},
+		}
+	} else {
+		postings = []posting{
+			{userAccount(r.UserID), r.Outcome},
+			{houseAccount(r.GameType), -r.Outcome},
+		}
+	}
+	if j := r.Jackpot; j != nil {
+		postings[len(postings)-1].Amount += j.Paid - j.Fee
+		postings = append(postings, posting{jackpotAccount(j.Machine), j.Fee - j.Paid})
+	}
+	return postings
+}
+
+// BalanceMismatch is a user whose stored balance disagrees with the ledger.
+type BalanceMismatch struct {
+	UserID  string
+	Stored  Money
+	Ledger  Money
+	Missing Money // Stored - Ledger
+}
+
+// ReconcileReport is the result of recomputing balances from the ledger.
+type ReconcileReport struct {
+	Users      int
+	Mismatches []BalanceMismatch
+	// UnbalancedTxns are ledger transactions whose postings don't sum to zero.
+	UnbalancedTxns []int64
+}
+
+// runReconcile implements the `reconcile` subcommand. It exits non-zero when
+// anything is out of balance so it can run from cron.
+func runReconcile(args []string) error {
+	st, err := openStore(os.Getenv("dbDriver"), os.Getenv("dbPath"))
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	report, err := st.Reconcile()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range report.Mismatches {
+		fmt.Printf("user %s: stored %s, ledger %s, difference %s\n", m.UserID, m.Stored, m.Ledger, m.Missing)
+	}
+	for _, id := range report.UnbalancedTxns {
+		fmt.Printf("ledger transaction %d does not sum to zero\n", id)
+	}
+	fmt.Printf("%d users checked, %d mismatched, %d unbalanced ledger transactions\n",
+		report.Users, len(report.Mismatches), len(report.UnbalancedTxns))
+
+	if len(report.Mismatches) > 0 || len(report.UnbalancedTxns) > 0 {
+		return fmt.Errorf("ledger does not reconcile")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Limbo targets run from 1.01x to 10000x.
+const (
+	minLimboTarget hundredths = 101
+	maxLimboTarget hundredths = 1000000
+)
+
+// minLimboOption is the /limbo target_multiplier option's minimum, minLimboTarget.
+var minLimboOption = 1.01
+
+// limboOutcome is the net outcome of bet on a drawn multiplier: reaching the
+// target pays it. The draw reaches a target m with probability RTP/m, so every
+// target returns RTP on average.
+func limboOutcome(bet Money, result, target hundredths) Money {
+	if result >= target {
+		return bet.MulRat(ratio(int64(target), 100)) - bet
+	}
+	return -bet
+}
+
+// playLimbo draws one multiplier against the player's target and settles the
+// bet in the same statement as slot does.
+func playLimbo(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, bet Money, target hundredths, balance Money) {
+	if bet <= 0 {
+		respondEphemeral(s, i, "❌ Bet amount must be greater than 0!", nil)
+		return
+	}
+	if balance < bet {
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	}
+	if target < minLimboTarget || target > maxLimboTarget {
+		respondEphemeral(s, i, fmt.Sprintf("❌ The target multiplier must be from %sx to %sx!", minLimboTarget, maxLimboTarget), nil)
+		return
+	}
+
+	seed, err := drawSeed(store, userID)
+	if err != nil {
+		log.Println("Error drawing seed:", err)
+		respondEphemeral(s, i, "❌ Failed to play!", nil)
+		return
+	}
+	rtp := diceRTP.For(i.GuildID)
+	result := hundredths(drawMultiplier(newFairStream(seed), rtp))
+	outcome := limboOutcome(bet, result, target)
+
+	gameID, err := store.SettleGame(GameResult{
+		UserID:   userID,
+		GameType: "limbo",
+		Bet:      bet,
+		Outcome:  outcome,
+		OneShot:  true,
+		SeedID:   seed.ID,
+		Nonce:    seed.Nonce,
+		Params:   fmt.Sprintf("target=%s,rtp=%s", target, rtp.RatString()),
+	})
+	if err == ErrInsufficientBalance {
+		respondEphemeral(s, i, "❌ Insufficient balance or concurrent transaction!", nil)
+		return
+	} else if err != nil {
+		log.Printf("DB error settling limbo for user %s: %v", userID, err)
+		respondEphemeral(s, i, "❌ Database error!", nil)
+		return
+	}
+
+	msg := fmt.Sprintf("🚀 **Limbo**: target %sx\n", target)
+	msg += fmt.Sprintf("🎯 Result **%sx**\n", result)
+	if outcome >= 0 {
+		msg += fmt.Sprintf("💵 Profit: +%s\n", outcome)
+	} else {
+		msg += fmt.Sprintf("💸 Loss: %s\n", -outcome)
+	}
+	msg += fmt.Sprintf("👤 Balance: %s\n", balance+outcome)
+	msg += fmt.Sprintf("🎲 Game #%d (`/verify %d`)\n", gameID, gameID)
+
+	again := fmt.Sprintf("limboagain_%s_%s", bet, target)
+	if err := sendNewMessage(s, i, msg, []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Play Again 🚀", Style: discordgo.PrimaryButton, CustomID: again},
+	}}}); err != nil {
+		log.Println("sendNewMessage error (limbo):", err)
+	}
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migration is one numbered schema change. Up and Down are run statement by
+// statement since the MySQL driver doesn't allow multi-statement Exec by default.
+// A migration without Down can't be reverted, and migrate down stops at it.
+//
+// MySQL commits DDL implicitly, so a migration that fails halfway has to be
+// fixed by hand; keep each one small.
+type migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// migrations must stay in Version order. Never edit one that has shipped; add a new one instead.
+var migrations = []migration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		// users.banned was added by hand with an ALTER before migrations existed,
+		// so it's part of the baseline every deployment already has.
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS users (
+				userid BIGINT UNSIGNED PRIMARY KEY,
+				username VARCHAR(32) NOT NULL,
+				balance DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+				wins DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+				losses DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+				admin TINYINT NOT NULL DEFAULT 0,
+				banned TINYINT NOT NULL DEFAULT 0
+			)`,
+			`CREATE TABLE IF NOT EXISTS active_games (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				userid BIGINT UNSIGNED,
+				type VARCHAR(32) NOT NULL,
+				username VARCHAR(32) NOT NULL,
+				bet_amount DECIMAL(10,2) NOT NULL,
+				num_mines INT NOT NULL,
+				board JSON NOT NULL,
+				revealed JSON NOT NULL,
+				safe_spots INT NOT NULL,
+				revealed_safe INT NOT NULL,
+				game_over BOOLEAN NOT NULL DEFAULT FALSE,
+				won BOOLEAN NOT NULL DEFAULT FALSE,
+				current_profit DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+				start_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+				UNIQUE KEY (userid, type),
+				FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE
+			)`,
+			`CREATE TABLE IF NOT EXISTS games (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				userid BIGINT UNSIGNED NOT NULL,
+				game_type VARCHAR(32) NOT NULL,
+				amount DECIMAL(10,2) NOT NULL,
+				outcome DECIMAL(10,2) NOT NULL,
+				played_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE
+			)`,
+			`CREATE TABLE IF NOT EXISTS transactions (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				sender BIGINT UNSIGNED NOT NULL,
+				sendername VARCHAR(32) NOT NULL,
+				receiver BIGINT UNSIGNED NOT NULL,
+				receivername VARCHAR(32) NOT NULL,
+				amount DECIMAL(12,2) NOT NULL,
+				status VARCHAR(32) NOT NULL,
+				played_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (sender) REFERENCES users(userid) ON DELETE CASCADE,
+				FOREIGN KEY (receiver) REFERENCES users(userid) ON DELETE CASCADE
+			)`,
+			`CREATE TABLE IF NOT EXISTS daily_rewards (
+				id INT AUTO_INCREMENT PRIMARY KEY,
+				userid BIGINT UNSIGNED NOT NULL,
+				claim_date DATE NOT NULL,
+				streak INT NOT NULL,
+				reward_amount DECIMAL(10,2) NOT NULL,
+				claimed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE,
+				UNIQUE(userid, claim_date) -- ensures 1 claim per day
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS daily_rewards`,
+			`DROP TABLE IF EXISTS transactions`,
+			`DROP TABLE IF EXISTS games`,
+			`DROP TABLE IF EXISTS active_games`,
+			`DROP TABLE IF EXISTS users`,
+		},
+	},
+	{
+		Version: 2,
+		Name:    "widen money columns to DECIMAL(19,2)",
+		// DECIMAL(10,2) overflows at 99,999,999.99; 19 digits covers any int64 cent amount.
+		Up: []string{
+			`ALTER TABLE users
+				MODIFY balance DECIMAL(19,2) NOT NULL DEFAULT 0.00,
+				MODIFY wins DECIMAL(19,2) NOT NULL DEFAULT 0.00,
+				MODIFY losses DECIMAL(19,2) NOT NULL DEFAULT 0.00`,
+			`ALTER TABLE active_games
+				MODIFY bet_amount DECIMAL(19,2) NOT NULL,
+				MODIFY current_profit DECIMAL(19,2) NOT NULL DEFAULT 0.00`,
+			`ALTER TABLE games
+				MODIFY amount DECIMAL(19,2) NOT NULL,
+				MODIFY outcome DECIMAL(19,2) NOT NULL`,
+			`ALTER TABLE transactions MODIFY amount DECIMAL(19,2) NOT NULL`,
+			`ALTER TABLE daily_rewards MODIFY reward_amount DECIMAL(19,2) NOT NULL`,
+		},
+		Down: []string{
+			`ALTER TABLE daily_rewards MODIFY reward_amount DECIMAL(10,2) NOT NULL`,
+			`ALTER TABLE transactions MODIFY amount DECIMAL(12,2) NOT NULL`,
+			`ALTER TABLE games
+				MODIFY amount DECIMAL(10,2) NOT NULL,
+				MODIFY outcome DECIMAL(10,2) NOT NULL`,
+			`ALTER TABLE active_games
+				MODIFY bet_amount DECIMAL(10,2) NOT NULL,
+				MODIFY current_profit DECIMAL(10,2) NOT NULL DEFAULT 0.00`,
+			`ALTER TABLE users
+				MODIFY balance DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+				MODIFY wins DECIMAL(10,2) NOT NULL DEFAULT 0.00,
+				MODIFY losses DECIMAL(10,2) NOT NULL DEFAULT 0.00`,
+		},
+	},
+	{
+		Version: 3,
+		Name:    "double-entry ledger",
+		// Existing balances predate the ledger, so they're posted as one
+		// opening_balance transaction balanced against house:opening_balance.
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS ledger_txns (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				reason VARCHAR(32) NOT NULL,
+				ref VARCHAR(64) NOT NULL DEFAULT '',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				INDEX (reason, ref)
+			)`,
+			`CREATE TABLE IF NOT EXISTS ledger_entries (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				txn_id BIGINT NOT NULL,
+				account VARCHAR(64) NOT NULL,
+				amount DECIMAL(19,2) NOT NULL,
+				INDEX (account),
+				FOREIGN KEY (txn_id) REFERENCES ledger_txns(id)
+			)`,
+			`INSERT INTO ledger_txns (reason, ref) VALUES ('opening_balance', 'migration 0003')`,
+			`INSERT INTO ledger_entries (txn_id, account, amount)
+				SELECT t.id, CONCAT('user:', u.userid), u.balance
+				FROM users u, ledger_txns t
+				WHERE t.reason = 'opening_balance' AND u.balance <> 0`,
+			`INSERT INTO ledger_entries (txn_id, account, amount)
+				SELECT t.id, 'house:opening_balance', -COALESCE(SUM(u.balance), 0)
+				FROM users u, ledger_txns t
+				WHERE t.reason = 'opening_balance'
+				GROUP BY t.id`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS ledger_entries`,
+			`DROP TABLE IF EXISTS ledger_txns`,
+		},
+	},
+	{
+		Version: 4,
+		Name:    "escrow mines stakes",
+		// Mines games started before this version never took their stake, so
+		// they can't be settled as escrowed games. They're cashed out at their
+		// current profit the way they always paid, like the reaper would, then
+		// closed. Nothing records what they were, so there is no Down.
+		Up: []string{
+			`INSERT INTO games (userid, game_type, amount, outcome)
+				SELECT userid, type, bet_amount, current_profit
+				FROM active_games
+				WHERE type = 'mines' AND game_over = FALSE`,
+			`UPDATE users u
+				JOIN active_games a ON a.userid = u.userid AND a.type = 'mines' AND a.game_over = FALSE
+				SET u.balance = u.balance + a.current_profit,
+				    u.wins = u.wins + a.current_profit`,
+			`INSERT INTO ledger_txns (reason, ref) VALUES ('game', 'migration 0004')`,
+			`INSERT INTO ledger_entries (txn_id, account, amount)
+				SELECT t.id, CONCAT('user:', a.userid), a.current_profit
+				FROM active_games a, ledger_txns t
+				WHERE t.reason = 'game' AND t.ref = 'migration 0004'
+				  AND a.type = 'mines' AND a.game_over = FALSE AND a.current_profit <> 0`,
+			`INSERT INTO ledger_entries (txn_id, account, amount)
+				SELECT t.id, 'house:mines', -COALESCE(SUM(a.current_profit), 0)
+				FROM ledger_txns t
+				LEFT JOIN active_games a ON a.type = 'mines' AND a.game_over = FALSE
+				WHERE t.reason = 'game' AND t.ref = 'migration 0004'
+				GROUP BY t.id`,
+			`DELETE FROM active_games WHERE type = 'mines'`,
+		},
+	},
+	{
+		Version: 5,
+		Name:    "provably fair seeds",
+		// active is TRUE or NULL, so the unique key allows one active pair per
+		// user and any number of retired ones.
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS fairness_seeds (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				userid BIGINT UNSIGNED NOT NULL,
+				server_seed CHAR(64) NOT NULL,
+				server_seed_hash CHAR(64) NOT NULL,
+				client_seed VARCHAR(32) NOT NULL,
+				nonce BIGINT NOT NULL DEFAULT 0,
+				active BOOLEAN NULL DEFAULT TRUE,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				revealed_at TIMESTAMP NULL,
+				UNIQUE KEY (userid, active),
+				FOREIGN KEY (userid) REFERENCES users(userid) ON DELETE CASCADE
+			)`,
+			`ALTER TABLE games
+				ADD COLUMN seed_id BIGINT NULL,
+				ADD COLUMN nonce BIGINT NULL,
+				ADD COLUMN params VARCHAR(64) NOT NULL DEFAULT ''`,
+			`ALTER TABLE active_games
+				ADD COLUMN seed_id BIGINT NULL,
+				ADD COLUMN nonce BIGINT NULL`,
+		},
+		Down: []string{
+			`ALTER TABLE active_games DROP COLUMN seed_id, DROP COLUMN nonce`,
+			`ALTER TABLE games DROP COLUMN seed_id, DROP COLUMN nonce, DROP COLUMN params`,
+			`DROP TABLE IF EXISTS fairness_seeds`,
+		},
+	},
+	{
+		Version: 6,
+		Name:    "mines rtp",
+		// Games already running keep the fair odds they were started with.
+		Up: []string{
+			`ALTER TABLE active_games ADD COLUMN rtp VARCHAR(32) NOT NULL DEFAULT '1'`,
+		},
+		Down: []string{
+			`ALTER TABLE active_games DROP COLUMN rtp`,
+		},
+	},
+	{
+		Version: 7,
+		Name:    "slot jackpots",
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS jackpots (
+				machine VARCHAR(20) PRIMARY KEY,
+				pool DECIMAL(19,2) NOT NULL DEFAULT 0.00,
+				last_won_at TIMESTAMP NULL
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS jackpots`,
+		},
+	},
+	{
+		Version: 8,
+		Name:    "mines board size",
+		// Games already running were all played on 4x4 boards.
+		Up: []string{
+			`ALTER TABLE active_games ADD COLUMN board_size TINYINT NOT NULL DEFAULT 4 AFTER num_mines`,
+		},
+		Down: []string{
+			`ALTER TABLE active_games DROP COLUMN board_size`,
+		},
+	},
+	{
+		Version: 9,
+		Name:    "active game versions",
+		// Bumped by every save, so a stale click can't overwrite a newer move.
+		Up: []string{
+			`ALTER TABLE active_games ADD COLUMN version INT NOT NULL DEFAULT 0 AFTER current_profit`,
+		},
+		Down: []string{
+			`ALTER TABLE active_games DROP COLUMN version`,
+		},
+	},
+	{
+		Version: 10,
+		Name:    "blackjack state",
+		// Games other than mines keep their state as one JSON document. Let
+		// running rounds finish before reverting: their stakes stay in escrow.
+		Up: []string{
+			`ALTER TABLE active_games ADD COLUMN state JSON NULL AFTER revealed`,
+		},
+		Down: []string{
+			`ALTER TABLE active_games DROP COLUMN state`,
+		},
+	},
+	{
+		Version: 11,
+		Name:    "wider game params",
+		// A full roulette slip logs every bet, far past 64 characters. Going
+		// back down fails while such games are logged.
+		Up: []string{
+			`ALTER TABLE games MODIFY params VARCHAR(2048) NOT NULL DEFAULT ''`,
+		},
+		Down: []string{
+			`ALTER TABLE games MODIFY params VARCHAR(64) NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		Version: 12,
+		Name:    "crash rounds",
+		// crash_point stays NULL until the round is settled; a round the bot
+		// restarted through never is, and its bets are refunded by the reaper.
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS crash_rounds (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				channel_id BIGINT UNSIGNED NOT NULL,
+				server_seed CHAR(64) NOT NULL,
+				server_seed_hash CHAR(64) NOT NULL,
+				crash_point INT NULL,
+				started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				settled_at TIMESTAMP NULL
+			)`,
+			`ALTER TABLE games ADD COLUMN round_id BIGINT NULL, ADD INDEX (round_id)`,
+		},
+		Down: []string{
+			`ALTER TABLE games DROP INDEX round_id, DROP COLUMN round_id`,
+			`DROP TABLE IF EXISTS crash_rounds`,
+		},
+	},
+	{
+		Version: 13,
+		Name:    "coinflip duels",
+		// Both sides of a flip are logged to games with the duel's id as round_id.
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS coinflip_duels (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				challenger BIGINT UNSIGNED NOT NULL,
+				challenger_name VARCHAR(32) NOT NULL,
+				opponent BIGINT UNSIGNED NOT NULL,
+				opponent_name VARCHAR(32) NOT NULL,
+				amount DECIMAL(19,2) NOT NULL,
+				server_seed CHAR(64) NOT NULL,
+				server_seed_hash CHAR(64) NOT NULL,
+				status VARCHAR(16) NOT NULL DEFAULT 'open',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				closed_at TIMESTAMP NULL,
+				INDEX (status, created_at)
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS coinflip_duels`,
+		},
+	},
+}
+
+// migrationState pairs a migration with when it was applied, if it was.
+type migrationState struct {
+	migration
+	AppliedAt *time.Time
+}
+
+func ensureMigrationsTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// migrationStatus returns every known migration with its applied time (nil if pending).
+func migrationStatus(db *sql.DB) ([]migrationState, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt sql.NullString
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		t, err := parseAppliedAt(appliedAt.String)
+		if err != nil {
+			return nil, fmt.Errorf("migration %04d: %w", version, err)
+		}
+		applied[version] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	states := make([]migrationState, 0, len(migrations))
+	for _, m := range migrations {
+		st := migrationState{migration: m}
+		if t, ok := applied[m.Version]; ok {
+			st.AppliedAt = &t
+		}
+		states = append(states, st)
+	}
+	return states, nil
+}
+
+// parseAppliedAt reads an applied_at timestamp. The driver returns it as
+// MySQL's own text, or as RFC 3339 when the DSN sets parseTime=true.
+func parseAppliedAt(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02 15:04:05", s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unreadable applied_at %q", s)
+	}
+	return t, nil
+}
+
+// migrateUp applies every pending migration in order. With dryRun it only
+// prints the statements it would run.
+func migrateUp(db *sql.DB, dryRun bool) (int, error) {
+	states, err := migrationStatus(db)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, st := range states {
+		if st.AppliedAt != nil {
+			continue
+		}
+		if dryRun {
+			fmt.Printf("-- would apply %04d %s\n", st.Version, st.Name)
+			for _, stmt := range st.Up {
+				fmt.Printf("%s;\n", stmt)
+			}
+			applied++
+			continue
+		}
+
+		for _, stmt := range st.Up {
+			if _, err := db.Exec(stmt); err != nil {
+				return applied, fmt.Errorf("migration %04d %s: %w", st.Version, st.Name, err)
+			}
+		}
+		if _, err := db.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", st.Version, st.Name); err != nil {
+			return applied, fmt.Errorf("recording migration %04d: %w", st.Version, err)
+		}
+		log.Printf("Applied migration %04d %s", st.Version, st.Name)
+		applied++
+	}
+	return applied, nil
+}
+
+// migrateDown rolls back the last n applied migrations, newest first.
+func migrateDown(db *sql.DB, n int, dryRun bool) (int, error) {
+	states, err := migrationStatus(db)
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	for idx := len(states) - 1; idx >= 0 && reverted < n; idx-- {
+		st := states[idx]
+		if st.AppliedAt == nil {
+			continue
+		}
+		if len(st.Down) == 0 {
+			return reverted, fmt.Errorf("migration %04d %s can't be reverted", st.Version, st.Name)
+		}
+		if dryRun {
+			fmt.Printf("-- would revert %04d %s\n", st.Version, st.Name)
+			for _, stmt := range st.Down {
+				fmt.Printf("%s;\n", stmt)
+			}
+			reverted++
+			continue
+		}
+
+		for _, stmt := range st.Down {
+			if _, err := db.Exec(stmt); err != nil {
+				return reverted, fmt.Errorf("reverting migration %04d %s: %w", st.Version, st.Name, err)
+			}
+		}
+		if _, err := db.Exec("DELETE FROM schema_migrations WHERE version = ?", st.Version); err != nil {
+			return reverted, fmt.Errorf("unrecording migration %04d: %w", st.Version, err)
+		}
+		log.Printf("Reverted migration %04d %s", st.Version, st.Name)
+		reverted++
+	}
+	return reverted, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMigrationsInOrder(t *testing.T) {
+	for k, m := range migrations {
+		if m.Version != k+1 {
+			t.Errorf("migration %d %q has version %d; versions must run 1, 2, 3 …", k, m.Name, m.Version)
+		}
+		if m.Name == "" || len(m.Up) == 0 {
+			t.Errorf("migration %04d needs a name and at least one Up statement", m.Version)
+		}
+	}
+}
+
+func TestParseAppliedAt(t *testing.T) {
+	want := time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC)
+	for _, s := range []string{"2024-05-01 12:30:45", "2024-05-01T12:30:45Z"} {
+		if got, err := parseAppliedAt(s); err != nil || !got.Equal(want) {
+			t.Errorf("parseAppliedAt(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := parseAppliedAt("yesterday"); err == nil {
+		t.Error("parseAppliedAt(\"yesterday\") succeeded")
+	}
+}
+
+// openTestMySQL opens the scratch database in TEST_MYSQL_DSN, skipping the
+// test if it isn't set. Tests drop and recreate its tables.
+func openTestMySQL(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN not set; skipping the MySQL tests")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	db := openTestMySQL(t)
+
+	if _, err := migrateUp(db, false); err != nil {
+		t.Fatal(err)
+	}
+	pending := func() int {
+		states, err := migrationStatus(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for _, st := range states {
+			if st.AppliedAt == nil {
+				n++
+			} else if st.AppliedAt.IsZero() {
+				t.Errorf("migration %04d applied at the zero time", st.Version)
+			}
+		}
+		return n
+	}
+	if n := pending(); n != 0 {
+		t.Fatalf("%d migration(s) pending after migrate up", n)
+	}
+
+	// migrate down stops with an error at the newest migration that has no Down
+	reversible := 0
+	for k := len(migrations) - 1; k >= 0 && len(migrations[k].Down) > 0; k-- {
+		reversible++
+	}
+	reverted, err := migrateDown(db, len(migrations), false)
+	if (err != nil) != (reversible < len(migrations)) {
+		t.Fatalf("migrate down error = %v with %d of %d migrations reversible", err, reversible, len(migrations))
+	}
+	if reverted != reversible {
+		t.Fatalf("reverted %d migration(s); want %d", reverted, reversible)
+	}
+	if n := pending(); n != reverted {
+		t.Fatalf("%d migration(s) pending after reverting %d", n, reverted)
+	}
+
+	// A dry run reports what would apply without applying it
+	if n, err := migrateUp(db, true); err != nil || n != reverted {
+		t.Fatalf("dry run = %d, %v; want %d, nil", n, err, reverted)
+	}
+	if n := pending(); n != reverted {
+		t.Fatalf("dry run applied migrations: %d pending, want %d", n, reverted)
+	}
+
+	if n, err := migrateUp(db, false); err != nil || n != reverted {
+		t.Fatalf("migrating back up = %d, %v; want %d, nil", n, err, reverted)
+	}
+}
@@ -1,788 +1,984 @@
-package main
-
-import (
-	"database/sql"
-	"fmt"
-	"log"
-	"math"
-	"math/rand"
-	"strconv"
-	"strings"
-	"sync"
-	"time"
-
-	"github.com/bwmarrin/discordgo"
-)
-
-type MinesGame struct {
-	mu            sync.Mutex
-	UserID        string
-	Type          string
-	UserName      string
-	BetAmount     float64
-	NumMines      int64
-	Board         [4][4]bool // true = mine, false = safe
-	Revealed      [4][4]bool // true = revealed, false = hidden
-	SafeSpots     int
-	RevealedSafe  int
-	GameOver      bool
-	Won           bool
-	CurrentProfit float64
-	StartTime     time.Time
-	deferred      bool
-}
-
-// Calculate multiplier based on revealed safe spots and total mines
-func calculateMultiplier(revealedSafe, totalMines int) float64 {
-	totalSpots := 16.0
-	mineCount := float64(totalMines)
-	safeSpots := totalSpots - mineCount
-	revealed := float64(revealedSafe)
-
-	if revealed == 0 {
-		return 1.0
-	}
-
-	// Calculate probability-based multiplier
-	multiplier := 1.0
-	for i := 0.0; i < revealed; i++ {
-		remaining := totalSpots - i
-		safesRemaining := safeSpots - i
-		prob := safesRemaining / remaining
-		multiplier /= prob
-	}
-
-	// Apply house edge (96% RTP)
-	multiplier *= 1
-
-	return math.Max(multiplier, 1.0)
-}
-
-// createMinesGame initializes a new Mines game for a user.
-func createMinesGame(userID string, userName string, betAmount float64, numMines int64) *MinesGame {
-	// Ensure the number of mines is valid (between 1 and 15 for a 4x4 board).
-	if numMines < 1 || numMines > 15 {
-		return nil
-	}
-
-	game := &MinesGame{
-		UserID:        userID,
-		UserName:      userName,
-		Type:          "mines",
-		BetAmount:     betAmount,
-		NumMines:      numMines,
-		SafeSpots:     16 - int(numMines),
-		RevealedSafe:  0,
-		GameOver:      false,
-		Won:           false,
-		CurrentProfit: 0.0,
-		StartTime:     time.Now(),
-	}
-
-	// Initialize a 4x4 board (all spots safe and unrevealed at start). row = horizontal, col = vertical
-	for row := 0; row < 4; row++ {
-		for col := 0; col < 4; col++ {
-			game.Board[row][col] = false    // no mine
-			game.Revealed[row][col] = false // not revealed
-		}
-	}
-
-	// Generate all positions
-	positions := rand.Perm(16)
-
-	for i := 0; i < int(numMines); i++ {
-		row, col := positions[i]/4, positions[i]%4
-		game.Board[row][col] = true
-	}
-	// // Debug output: show mine positions in the console.
-	// for row := 0; row < 4; row++ {
-	// 	for col := 0; col < 4; col++ {
-	// 		if game.Board[row][col] {
-	// 			fmt.Printf("💣 at (%d, %d)\n", col, row)
-	// 		}
-	// 	}
-	// }
-
-	return game
-}
-
-// generateMinesButtons builds the interactive Discord button grid for the Mines game
-func generateMinesButtons(game *MinesGame) []discordgo.MessageComponent {
-	var rows []discordgo.MessageComponent
-
-	// Build a 4x4 grid of buttons (game board).
-	for row := 0; row < 4; row++ {
-		var buttons []discordgo.MessageComponent
-		for col := 0; col < 4; col++ {
-			// Default button state (unrevealed).
-			label := "⠀"
-			style := discordgo.SecondaryButton
-			disabled := game.GameOver // disable everything if the game is over
-			buttonID := fmt.Sprintf("mine_%s_%d_%d", game.UserID, row, col)
-
-			// Update button appearance if the spot has been revealed.
-			if game.Revealed[row][col] {
-				if game.Board[row][col] { //checks if the spot is mine
-					label = "💣"
-					style = discordgo.DangerButton
-				} else {
-					label = "💎"
-					style = discordgo.SuccessButton
-				}
-				disabled = true // disable the button after press
-			}
-
-			buttons = append(buttons, discordgo.Button{
-				Label:    label,
-				Style:    style,
-				Disabled: disabled,
-				CustomID: buttonID,
-			})
-		}
-
-		rows = append(rows, discordgo.ActionsRow{
-			Components: buttons,
-		})
-	}
-
-	// Add extra control buttons.
-	if !game.GameOver {
-		rows = append(rows, discordgo.ActionsRow{
-			Components: []discordgo.MessageComponent{
-				discordgo.Button{
-					Label:    "💰 Cash Out",
-					Style:    discordgo.PrimaryButton,
-					CustomID: fmt.Sprintf("cashout_%s", game.UserID),
-					Disabled: game.RevealedSafe <= 0, // disabled if no safe spots revealed yet
-				},
-			},
-		})
-	} else if game.GameOver {
-		// Offer a play again button once the game ends.
-		rows = append(rows, discordgo.ActionsRow{
-			Components: []discordgo.MessageComponent{
-				discordgo.Button{
-					Label:    "Play Again",
-					Style:    discordgo.PrimaryButton,
-					CustomID: fmt.Sprintf("playagain_%v_%v", game.BetAmount, game.NumMines),
-				},
-			},
-		})
-	}
-
-	return rows
-}
-
-// generateGameStatus builds the status message for the Mines game.
-func generateGameStatus(game *MinesGame, balance float64) string {
-	var status string
-
-	if game.GameOver {
-		if game.Won {
-			// Won → show "Game"
-			status = fmt.Sprintf("> **%s's Won**\n", fmt.Sprintf("<@%s>", game.UserID))
-		} else {
-			// Lost → show "Lost"
-			status = fmt.Sprintf("> **%s Lost**\n", fmt.Sprintf("<@%s>", game.UserID))
-		}
-	} else {
-		// Still playing → show "Game"
-		status = fmt.Sprintf("> **%s's Game**\n", fmt.Sprintf("<@%s>", game.UserID))
-	}
-	status += fmt.Sprintf("👤 Balance: %.2f\n", balance)
-	status += fmt.Sprintf("💰 Bet: %.2f\n", game.BetAmount)
-	status += fmt.Sprintf("💣 Mines: %d\n", game.NumMines)
-	status += fmt.Sprintf("✅ Safe spots found: %d/%d\n", game.RevealedSafe, game.SafeSpots)
-
-	if game.GameOver {
-		// --- Game finished ---
-		if game.Won {
-			multiplier := calculateMultiplier(game.RevealedSafe, int(game.NumMines))
-			profit := game.CurrentProfit
-			status += fmt.Sprintf("👤 Balance: %.2f\n", balance)
-			status += fmt.Sprintf("📈 Multiplier: %.2fx\n", multiplier)
-			status += fmt.Sprintf("💵 Profit: +%.2f\n", profit)
-
-			for r := 0; r < 4; r++ {
-				for c := 0; c < 4; c++ {
-					if game.Board[r][c] {
-						game.Revealed[r][c] = true
-					}
-				}
-			}
-
-		} else {
-			// Player lost → show bet loss and update DB.
-			status += fmt.Sprintf("💸 Loss: %.2f\n", game.BetAmount)
-			logGame(game.UserID, "mines", game.BetAmount, -game.BetAmount)
-
-		}
-
-	} else {
-		// --- Game still in progress ---
-		multiplier := calculateMultiplier(game.RevealedSafe, int(game.NumMines))
-		// profit := float64(game.BetAmount) * (multiplier - 1)
-		profit := game.CurrentProfit
-
-		status += fmt.Sprintf("📈 Multiplier: %.2fx\n", multiplier)
-		status += fmt.Sprintf("💵 Potential profit: +%.2f\n", profit)
-	}
-	return status
-}
-
-// messageComponentHandlers handles button and select menu interactions for the Mines game.
-func handleBtns(s *discordgo.Session, i *discordgo.InteractionCreate) {
-
-	// startTime := time.Now() // Start timing
-
-	if i.Type != discordgo.InteractionMessageComponent {
-		return
-	}
-	customID := i.MessageComponentData().CustomID
-
-	// Only enforce "active game required" for actions that actually need it.
-	// Safely get user ID for ban check
-	var userID string
-	if i.Member != nil && i.Member.User != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		// Log this case and return - shouldn't happen normally
-		log.Printf("Warning: Could not determine user ID for interaction in guild %s", i.GuildID)
-		return
-	}
-	if banChk(s, i, userID) {
-		return // stop here if banned
-	}
-	var userBalance float64
-	err := db.QueryRow("SELECT balance FROM users WHERE userid = ?", userID).Scan(&userBalance)
-	if err == sql.ErrNoRows {
-		if err := respondEphemeral(s, i, "❌ You're not registered! registring user...", nil); err != nil {
-			log.Println("respondUpdate error (not registered):", err)
-		}
-		addUser(s, i)
-		return
-	} else if err != nil {
-		log.Println("DB error checking balance:", err)
-		if err := respondEphemeral(s, i, "❌ Database error!", nil); err != nil {
-			log.Println("respondUpdate error (DB error):", err)
-		}
-		return
-	}
-	if strings.HasPrefix(customID, "playagainSlot_") {
-		parts := strings.Split(customID, "_")
-		if len(parts) >= 2 {
-			amountStr := parts[1]
-			betAmount, err := strconv.ParseFloat(amountStr, 64)
-			if err == nil {
-				// Restart the slot game with same bet amount
-				slot(s, i, betAmount)
-			}
-		}
-		return
-	}
-	if strings.HasPrefix(customID, "daily_claim") {
-		HandleDailyClaimButton(s, i, db, userID)
-		return
-	}
-
-	mineGame, err := getActiveGameFromDB(userID)
-	if err != nil {
-		// No active game found in DB
-		if !strings.HasPrefix(customID, "playagain_") {
-			respondEphemeral(s, i, "❌ You don't have an active mines game!", nil)
-			return
-		}
-		// if "playagain_*", just continue with mineGame == nil
-	}
-
-	// If there is a game and it is over, block further interactions except "Play Again".
-	if mineGame != nil && mineGame.GameOver && !strings.HasPrefix(customID, "playagain_") {
-		respondEphemeral(s, i, "❌ This game has already ended!", nil)
-		return
-	}
-
-	done := make(chan struct{})
-	if mineGame != nil {
-		game := mineGame // capture
-		go func() {
-			timer := time.NewTimer(2500 * time.Millisecond)
-			defer timer.Stop()
-
-			select {
-			case <-timer.C:
-				game.mu.Lock()
-				game.deferred = true
-				game.mu.Unlock()
-			case <-done:
-				// Timer cancelled, no need to set deferred
-			}
-		}()
-	}
-
-	// ticker := time.NewTicker(2500 * time.Millisecond)
-	// done := make(chan bool)  // This should be closed when the interaction is done (e.g., game over)
-	// startTimeD := time.Now() // Start timing
-
-	// go func() {
-	// 	for {
-	// 		select {
-	// 		case <-ticker.C:
-	// 			// fmt.Println("Tick at second:", t.Second())
-	// 			log.Printf("Dfferdede %v\n", time.Since(startTimeD))
-
-	// 			// Send deferred update
-	// 			err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-	// 				Type: discordgo.InteractionResponseDeferredMessageUpdate,
-	// 			})
-	// 			if err != nil {
-	// 				log.Println("Deferred update failed:", err)
-	// 				continue // Or break, depending on your error handling policy
-	// 			}
-
-	// 			// Lock to safely update game.deferred
-	// 			mineGame.mu.Lock()
-	// 			mineGame.deferred = true
-	// 			mineGame.mu.Unlock()
-
-	// 		case <-done:
-	// 			ticker.Stop()
-	// 			fmt.Println("Ticker stopped")
-	// 			return
-	// 		}
-	// 	}
-	// }()
-
-	// When you want to stop the ticker later
-	switch {
-	case strings.HasPrefix(customID, "cashout_"):
-		handleCashout(s, i, mineGame, userID, customID, userBalance)
-	case strings.HasPrefix(customID, "playagain_"):
-		handlePlayAgain(s, i, userID, userBalance)
-	case strings.HasPrefix(customID, "mine_"):
-		handleMineClick(s, i, mineGame, customID, userBalance)
-	}
-	// done <- true
-	close(done) // cancels if within 2.5s
-	// log.Printf("generateGameStatus hit mine %v\n", time.Since(startTime))
-
-}
-
-func startMinesGame(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, betAmount float64, numMines int64, balance float64) {
-	if banChk(s, i, userID) {
-		return // stop here if banned
-	}
-	// Check user balance
-
-	// startTime := time.Now() // Start timing
-	// If user already has an active game, show it instead of starting a new one
-	if game, err := getActiveGameFromDB(userID); err == nil && game.Type == "mines" {
-		status := generateGameStatus(game, balance)
-		buttons := generateMinesButtons(game)
-		if err := respondEphemeral(s, i, "🟢 You already have an active game! Continue playing:\n\n"+status, buttons); err != nil {
-			log.Println("respondUpdate error (active game):", err)
-		}
-		return
-	}
-
-	// Validate bet amount
-	if betAmount <= 0 {
-		if err := respondEphemeral(s, i, "❌ Bet amount must be greater than 0!", nil); err != nil {
-			log.Println("respondUpdate error (invalid bet):", err)
-		}
-		return
-	}
-
-	// Validate mines count
-	if numMines < 1 || numMines > 15 {
-		if err := respondEphemeral(s, i, "❌ Number of mines must be between 1 and 15!", nil); err != nil {
-			log.Println("respondUpdate error (invalid mines):", err)
-		}
-		return
-	}
-
-	// Balance check
-	if balance < betAmount {
-		if err := respondEphemeral(s, i, "❌ Insufficient balance!", nil); err != nil {
-			log.Println("respondUpdate error (insufficient balance):", err)
-		}
-		return
-	}
-	var username string
-	if i.Member != nil && i.Member.User != nil {
-		username = i.Member.User.Username
-	} else if i.User != nil {
-		username = i.User.Username
-	} else {
-		// Log this case and return - shouldn't happen normally
-		log.Printf("Warning: Could not determine username for interaction in guild %s", i.GuildID)
-		return
-	}
-	// Create new game
-	game := createMinesGame(userID, username, betAmount, numMines)
-	if game == nil {
-		if err := respondEphemeral(s, i, "❌ Failed to create game!", nil); err != nil {
-			log.Println("respondUpdate error (create game):", err)
-		}
-		return
-	}
-
-	// Save DB in background (non-blocking)
-	if err := saveActiveGameToDB(game); err != nil {
-		log.Println("Error saving game:", err)
-	}
-
-	// Respond with new game state
-	if err := sendNewMessage(s, i, generateGameStatus(game, balance), generateMinesButtons(game)); err != nil {
-		log.Println("respondUpdate error (new game):", err)
-	}
-	// log.Printf("startMinesGame %v\n", time.Since(startTime))
-
-}
-
-func handleCashout(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame, userID string, customID string, balance float64) {
-	parts := strings.Split(customID, "_")
-	if len(parts) < 2 {
-		return
-	}
-
-	ownerID := parts[1]
-
-	// Block if not the game owner
-	if userID != ownerID {
-		respondEphemeral(s, i, "❌ This isn't your game!", nil)
-		return
-	}
-
-	multiplier := calculateMultiplier(game.RevealedSafe, int(game.NumMines))
-	winAmount := float64(game.BetAmount) * (multiplier - 1)
-
-	// // Update balance
-	// if _, err := db.Exec("UPDATE users SET balance = ROUND(balance + ?, 2) WHERE userid = ?", winAmount, userID); err != nil {
-	// 	log.Println("DB error on cashout:", err)
-	// 	respondEphemeral(s, i, "❌ Error processing cashout!", nil)
-	// 	return
-	// }
-	// logGame(game.UserID, "mines", game.BetAmount, winAmount)
-	// // Reveal all mines
-	// for r := 0; r < 4; r++ {
-	// 	for c := 0; c < 4; c++ {
-	// 		if game.Board[r][c] {
-	// 			game.Revealed[r][c] = true
-	// 		}
-	// 	}
-	// }
-	// // Update stats
-	// if _, err := db.Exec("UPDATE users SET wins = ROUND(wins + ?, 2) WHERE userid = ?", winAmount, userID); err != nil {
-	// 	log.Println("DB error updating wins:", err)
-	// }
-
-	// Start a transaction
-	tx, err := db.Begin()
-
-	if err != nil {
-		log.Println("DB error starting transaction:", err)
-		respondEphemeral(s, i, "❌ Error processing cashout!", nil)
-		return
-	}
-	defer tx.Rollback() // rollback on failure
-
-	// Update balance
-	if _, err := tx.Exec(
-		"UPDATE users SET balance = ROUND(balance + ?, 2) WHERE userid = ?",
-		winAmount, userID,
-	); err != nil {
-		log.Println("DB error on cashout:", err)
-		respondEphemeral(s, i, "❌ Error processing cashout!", nil)
-		return
-	}
-
-	// Update stats (wins)
-	if _, err := tx.Exec(
-		"UPDATE users SET wins = ROUND(wins + ?, 2) WHERE userid = ?",
-		winAmount, userID,
-	); err != nil {
-		log.Println("DB error updating wins:", err)
-		return
-	}
-
-	// Commit transaction (only applies if both queries succeed)
-	if err := tx.Commit(); err != nil {
-		log.Println("DB error committing transaction:", err)
-		respondEphemeral(s, i, "❌ Error processing cashout!", nil)
-		return
-	}
-
-	// Logging + game state updates happen after successful commit
-	logGame(game.UserID, "mines", game.BetAmount, winAmount)
-
-	// Reveal all mines
-	for r := 0; r < 4; r++ {
-		for c := 0; c < 4; c++ {
-			if game.Board[r][c] {
-				game.Revealed[r][c] = true
-			}
-		}
-	}
-
-	// Mark game over
-	game.GameOver = true
-	game.Won = true
-
-	deleteActiveGameFromDB(userID, game.Type)
-	status := fmt.Sprintf(
-		"> **%s CASHED OUT!**\n"+
-			"👤 Balance: %.2f\n"+
-			"💰 Bet: %.2f\n"+
-			"💣 Mines: %d\n"+
-			"✅ Safe spots found: %d/%d\n"+
-			"📈 Multiplier: %.2fx\n"+
-			"💵 Profit: +%.2f\n",
-		fmt.Sprintf("<@%s>", userID), balance+winAmount, game.BetAmount, game.NumMines, game.RevealedSafe, game.SafeSpots, multiplier, winAmount,
-	)
-
-	respondUpdate(s, i, status, generateMinesButtons(game), game)
-}
-
-// handlePlayAgain handles the "Play Again" button click.
-// The button's CustomID must be in the format: playagain_<betAmount>_<numMines>
-func handlePlayAgain(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, balance float64) {
-	parts := strings.Split(i.MessageComponentData().CustomID, "_")
-	if len(parts) != 3 {
-		respondEphemeral(s, i, "❌ Invalid play again button data!", nil)
-		return
-	}
-
-	var betAmount float64
-	var numMines int64
-	if _, err := fmt.Sscanf(parts[1]+" "+parts[2], "%f %d", &betAmount, &numMines); err != nil {
-		log.Println("Error parsing playagain CustomID:", err)
-		respondEphemeral(s, i, "❌ Invalid play again data format!", nil)
-		return
-	}
-
-	startMinesGame(s, i, userID, betAmount, numMines, balance)
-}
-
-func handleMineClick(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame, customID string, balance float64) {
-	// Parse button ID: "mine_<row>_<col>"
-
-	parts := strings.Split(customID, "_")
-	if len(parts) < 4 {
-		return
-	}
-
-	ownerID := parts[1] // the player who owns this game
-	row, _ := strconv.Atoi(parts[2])
-	col, _ := strconv.Atoi(parts[3])
-	var userID string
-	if i.Member != nil && i.Member.User != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	} else {
-		// Log this case and return - shouldn't happen normally
-		log.Printf("Warning: Could not determine user ID for interaction in guild %s", i.GuildID)
-		return
-	}
-	// Block clicks from non-owners
-	if userID != ownerID {
-		respondEphemeral(s, i, "❌ This isn't your game!", nil)
-		return
-	}
-
-	// Validate coordinates
-	if row < 0 || row >= 4 || col < 0 || col >= 4 {
-		log.Printf("Out-of-range mine coordinates: row=%d col=%d", row, col)
-		respondEphemeral(s, i, "❌ Invalid tile!", nil)
-		return
-	}
-
-	// Already revealed
-	if game.Revealed[row][col] {
-		respondEphemeral(s, i, "❌ This tile is already revealed!", nil)
-		return
-	}
-	// s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-	// 	Type: discordgo.InteractionResponseDeferredMessageUpdate,
-	// })
-	game.Revealed[row][col] = true
-
-	// // --- Case 1: Hit a mine ---
-	// if game.Board[row][col] {
-	// 	game.GameOver, game.Won = true, false
-
-	// 	for r := 0; r < 4; r++ {
-	// 		for c := 0; c < 4; c++ {
-	// 			if game.Board[r][c] {
-	// 				game.Revealed[r][c] = true
-	// 			}
-	// 		}
-	// 	}
-	// 	if err := respondUpdate(s, i, generateGameStatus(game), generateMinesButtons(game), game); err != nil {
-	// 		log.Println("respondUpdate error (hit mine):", err)
-	// 	}
-	// 	// Handle DB
-	// 	if _, err := db.Exec(
-	// 		`UPDATE users
-	// 			SET balance = ROUND(balance - ?, 2),
-	// 			 losses  = ROUND(losses + ?, 2)
-	// 		 WHERE userid = ?`,
-	// 		game.BetAmount, game.BetAmount, game.UserID,
-	// 	); err != nil {
-	// 		log.Println("DB error updating balance and losses:", err)
-	// 	}
-
-	// 	if err := deleteActiveGameFromDB(game.UserID); err != nil {
-	// 		log.Printf("DB error deleting game for user %s: %v", game.UserID, err)
-	// 	}
-
-	// 	return
-	// }
-	// --- Case 1: Hit a mine ---
-	if game.Board[row][col] {
-		game.GameOver, game.Won = true, false
-
-		// --- DB handling with transaction ---
-		tx, err := db.Begin()
-		if err != nil {
-			log.Println("DB error starting transaction:", err)
-			return
-		}
-		defer tx.Rollback()
-
-		// Update balance and losses
-		if _, err := tx.Exec(
-			`UPDATE users
-			SET balance = ROUND(balance - ?, 2),
-			    losses  = ROUND(losses + ?, 2)
-		 WHERE userid = ?`,
-			game.BetAmount, game.BetAmount, game.UserID,
-		); err != nil {
-			log.Println("DB error updating balance and losses:", err)
-			return
-		}
-
-		// Delete active game
-		if err := deleteActiveGameFromDBTx(tx, game.UserID, "mines"); err != nil {
-			log.Printf("DB error deleting game for user %s: %v", game.UserID, err)
-			return
-		}
-
-		// Commit if both succeed
-		if err := tx.Commit(); err != nil {
-			log.Println("DB error committing transaction:", err)
-			return
-		}
-		// Reveal all mines
-		for r := 0; r < 4; r++ {
-			for c := 0; c < 4; c++ {
-				if game.Board[r][c] {
-					game.Revealed[r][c] = true
-				}
-			}
-		}
-
-		// First try to update UI
-		if err := respondUpdate(s, i, generateGameStatus(game, balance-game.BetAmount), generateMinesButtons(game), game); err != nil {
-			log.Println("respondUpdate error (hit mine):", err)
-		}
-
-		return
-	}
-
-	// time.Sleep(3 * time.Second)
-	// --- Case 2: Safe tile ---
-	game.RevealedSafe++
-	multiplier := calculateMultiplier(game.RevealedSafe, int(game.NumMines))
-	game.CurrentProfit = float64(game.BetAmount) * (multiplier - 1)
-
-	if game.RevealedSafe >= game.SafeSpots {
-		// Auto-win
-		game.GameOver, game.Won = true, true
-		winAmount := game.CurrentProfit
-
-		// Handle DB first (synchronously for security)
-		logGame(game.UserID, "mines", game.BetAmount, winAmount)
-
-		// if _, err := db.Exec(
-		// 	"UPDATE users SET wins = ROUND(wins + ?, 2) WHERE userid = ?",
-		// 	winAmount, game.UserID,
-		// ); err != nil {
-		// 	log.Println("DB error adding wins:", err)
-		// }
-
-		// if _, err := db.Exec(
-		// 	"UPDATE users SET balance = ROUND(balance + ?, 2) WHERE userid = ?",
-		// 	winAmount, game.UserID,
-		// ); err != nil {
-		// 	log.Println("DB error updating balance:", err)
-		// }
-
-		// if err := deleteActiveGameFromDB(game.UserID); err != nil {
-		// 	log.Println("DB error deleting game:", err)
-		// }
-		// // Respond only after DB is done
-		// if err := respondUpdate(s, i, generateGameStatus(game), generateMinesButtons(game), game); err != nil {
-		// 	log.Println("respondUpdate error (auto-win):", err)
-		// }
-		tx, err := db.Begin()
-		if err != nil {
-			log.Println("DB error starting transaction:", err)
-			return
-		}
-		defer tx.Rollback()
-
-		// Update wins
-		if _, err := tx.Exec(
-			"UPDATE users SET wins = ROUND(wins + ?, 2) WHERE userid = ?",
-			winAmount, game.UserID,
-		); err != nil {
-			log.Println("DB error adding wins:", err)
-			return
-		}
-
-		// Update balance
-		if _, err := tx.Exec(
-			"UPDATE users SET balance = ROUND(balance + ?, 2) WHERE userid = ?",
-			winAmount, game.UserID,
-		); err != nil {
-			log.Println("DB error updating balance:", err)
-			return
-		}
-
-		// Delete active game
-		if err := deleteActiveGameFromDBTx(tx, game.UserID, "mines"); err != nil {
-			log.Println("DB error deleting game:", err)
-			return
-		}
-
-		// Commit if all succeed
-		if err := tx.Commit(); err != nil {
-			log.Println("DB error committing transaction:", err)
-			return
-		}
-
-		// Respond only after DB is fully committed
-		if err := respondUpdate(s, i, generateGameStatus(game, balance+winAmount), generateMinesButtons(game), game); err != nil {
-			log.Println("respondUpdate error (auto-win):", err)
-		}
-
-		return
-	}
-
-	// Save DB in background
-	if err := saveActiveGameToDB(game); err != nil {
-		log.Println("Error saving game:", err)
-	}
-	// time.Sleep(5 * time.Second)
-	// startTime := time.Now() // Start timing
-
-	// --- Case 3: Normal safe tile (game continues) ---
-	if err := respondUpdate(s, i, generateGameStatus(game, balance), generateMinesButtons(game), game); err != nil {
-		log.Println("respondUpdate error (safe tile):", err)
-	}
-	// log.Printf("generateGameStatushit safe %v\n", time.Since(startTime))
-
-}
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type MinesGame struct {
+	mu            sync.Mutex
+	UserID        string
+	Type          string
+	UserName      string
+	BetAmount     Money
+	NumMines      int64
+	Size          int      // board side, minMinesSize to maxMinesSize tiles
+	Board         [][]bool // true = mine, false = safe
+	Revealed      [][]bool // true = revealed, false = hidden
+	SafeSpots     int
+	RevealedSafe  int
+	GameOver      bool
+	Won           bool
+	CurrentProfit Money
+	StartTime     time.Time
+	RTP           *big.Rat // return to player the multipliers are scaled by; nil for fair odds
+	SeedID        int64    // provably-fair seed pair the board was drawn from
+	Nonce         int64
+	GameID        int64 // games row id once settled
+	Auto          int   // tiles /mines auto picks before cashing out, 0 when played by hand
+	Version       int64 // active_games row version the game was loaded at
+	deferred      bool
+}
+
+// result settles game with the given net outcome, closing it and releasing its escrowed stake.
+func (game *MinesGame) result(outcome Money) GameResult {
+	r := GameResult{
+		UserID:      game.UserID,
+		GameType:    game.Type,
+		Bet:         game.BetAmount,
+		Outcome:     outcome,
+		CloseActive: true,
+		Escrowed:    true,
+		Version:     game.Version,
+		SeedID:      game.SeedID,
+		Nonce:       game.Nonce,
+	}
+	// Crash bets the reaper refunds have no board to describe
+	if game.Type == "mines" {
+		r.Params = fmt.Sprintf("mines=%d,size=%d", game.NumMines, game.Size)
+	}
+	return r
+}
+
+// Mines boards are square, from 3x3 up to 5x5: Discord messages hold at most
+// five rows of five buttons. Games from before sizes were chosen are 4x4.
+const (
+	minMinesSize     = 3
+	maxMinesSize     = 5
+	defaultMinesSize = 4
+)
+
+// minAutoTiles is the /mines auto option's minimum.
+var minAutoTiles float64 = 1
+
+// newMinesGrid is a size x size grid with every tile false.
+func newMinesGrid(size int) [][]bool {
+	grid := make([][]bool, size)
+	for r := range grid {
+		grid[r] = make([]bool, size)
+	}
+	return grid
+}
+
+// checkSize makes sure a loaded game's board and revealed tiles are Size
+// square, so clicks can index them.
+func (game *MinesGame) checkSize() error {
+	if game.Size < minMinesSize || game.Size > maxMinesSize || len(game.Board) != game.Size || len(game.Revealed) != game.Size {
+		return fmt.Errorf("board isn't %dx%d", game.Size, game.Size)
+	}
+	for r := range game.Board {
+		if len(game.Board[r]) != game.Size || len(game.Revealed[r]) != game.Size {
+			return fmt.Errorf("board isn't %dx%d", game.Size, game.Size)
+		}
+	}
+	return nil
+}
+
+// tiles is how many tiles game's board has.
+func (game *MinesGame) tiles() int {
+	return game.Size * game.Size
+}
+
+// revealMines turns over every mine on the board.
+func (game *MinesGame) revealMines() {
+	for r := range game.Board {
+		for c := range game.Board[r] {
+			if game.Board[r][c] {
+				game.Revealed[r][c] = true
+			}
+		}
+	}
+}
+
+// rtp is the game's return to player, 1 for games started before it was recorded.
+func (game *MinesGame) rtp() *big.Rat {
+	if game.RTP == nil {
+		return ratio(1, 1)
+	}
+	return game.RTP
+}
+
+// multiplier is the current cashout multiplier of game.
+func (game *MinesGame) multiplier() *big.Rat {
+	return calculateMultiplier(game.RevealedSafe, int(game.NumMines), game.tiles(), game.rtp())
+}
+
+// Calculate multiplier based on revealed safe spots and total mines on a board of totalTiles.
+// The result is exact: the product of (tiles left / safe tiles left) per reveal,
+// scaled by rtp once at least one tile is revealed.
+func calculateMultiplier(revealedSafe, totalMines, totalTiles int, rtp *big.Rat) *big.Rat {
+	totalSpots := int64(totalTiles)
+	safeSpots := totalSpots - int64(totalMines)
+
+	// Calculate probability-based multiplier
+	multiplier := ratio(1, 1)
+	for i := int64(0); i < int64(revealedSafe); i++ {
+		remaining := totalSpots - i
+		safesRemaining := safeSpots - i
+		multiplier.Mul(multiplier, ratio(remaining, safesRemaining))
+	}
+
+	// Apply house edge
+	if revealedSafe > 0 {
+		multiplier.Mul(multiplier, rtp)
+	}
+	return multiplier
+}
+
+// minesProfit is the profit on top of the stake at the given multiplier. The
+// gross payout (bet × multiplier) is truncated to the cent.
+func minesProfit(bet Money, multiplier *big.Rat) Money {
+	return bet.MulRat(multiplier) - bet
+}
+
+// createMinesGame initializes a new Mines game for a user on a size x size board, with the board drawn
+// from seed at its nonce and multipliers paying rtp.
+func createMinesGame(userID string, userName string, betAmount Money, numMines int64, size int, seed *FairSeed, rtp *big.Rat) *MinesGame {
+	// Ensure the board size and number of mines are valid (at least one safe tile).
+	if size < minMinesSize || size > maxMinesSize || numMines < 1 || numMines > int64(size*size-1) {
+		return nil
+	}
+
+	game := &MinesGame{
+		UserID:        userID,
+		UserName:      userName,
+		Type:          "mines",
+		BetAmount:     betAmount,
+		NumMines:      numMines,
+		Size:          size,
+		SafeSpots:     size*size - int(numMines),
+		RevealedSafe:  0,
+		GameOver:      false,
+		Won:           false,
+		CurrentProfit: 0,
+		StartTime:     time.Now(),
+		RTP:           rtp,
+		SeedID:        seed.ID,
+		Nonce:         seed.Nonce,
+	}
+
+	// Place the mines on the board (all spots unrevealed at start). row = horizontal, col = vertical
+	game.Board = drawMinesBoard(newFairStream(seed), size, int(numMines))
+	game.Revealed = newMinesGrid(size)
+	// // Debug output: show mine positions in the console.
+	// for row := 0; row < size; row++ {
+	// 	for col := 0; col < size; col++ {
+	// 		if game.Board[row][col] {
+	// 			fmt.Printf("💣 at (%d, %d)\n", col, row)
+	// 		}
+	// 	}
+	// }
+
+	return game
+}
+
+// generateMinesButtons builds the interactive Discord button grid for the Mines game.
+// A 5x5 board fills all five action rows, so its controls go in a follow-up message.
+func generateMinesButtons(game *MinesGame) []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+
+	// Build the grid of buttons (game board).
+	for row := 0; row < game.Size; row++ {
+		var buttons []discordgo.MessageComponent
+		for col := 0; col < game.Size; col++ {
+			// Default button state (unrevealed).
+			label := "⠀"
+			style := discordgo.SecondaryButton
+			disabled := game.GameOver // disable everything if the game is over
+			buttonID := fmt.Sprintf("mine_%s_%d_%d", game.UserID, row, col)
+
+			// Update button appearance if the spot has been revealed.
+			if game.Revealed[row][col] {
+				if game.Board[row][col] { //checks if the spot is mine
+					label = "💣"
+					style = discordgo.DangerButton
+				} else {
+					label = "💎"
+					style = discordgo.SuccessButton
+				}
+				disabled = true // disable the button after press
+			}
+
+			buttons = append(buttons, discordgo.Button{
+				Label:    label,
+				Style:    style,
+				Disabled: disabled,
+				CustomID: buttonID,
+			})
+		}
+
+		rows = append(rows, discordgo.ActionsRow{
+			Components: buttons,
+		})
+	}
+
+	// Add extra control buttons.
+	if len(rows) < maxMinesSize {
+		rows = append(rows, minesControls(game, ""))
+	}
+
+	return rows
+}
+
+// minesControls is the Cash Out and Pick random buttons, or Play Again once
+// the game ends. In a follow-up to a 5x5 board Cash Out stays enabled, and
+// both carry the board's message ID to update it.
+func minesControls(game *MinesGame, boardMessageID string) discordgo.ActionsRow {
+	if game.GameOver {
+		// Offer a play again button once the game ends.
+		return discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Play Again",
+					Style:    discordgo.PrimaryButton,
+					CustomID: fmt.Sprintf("playagain_%s_%d_%d_%d", game.BetAmount, game.NumMines, game.Size, game.Auto),
+				},
+			},
+		}
+	}
+	suffix := ""
+	if boardMessageID != "" {
+		suffix = "_" + boardMessageID
+	}
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "💰 Cash Out",
+			Style:    discordgo.PrimaryButton,
+			CustomID: fmt.Sprintf("cashout_%s%s", game.UserID, suffix),
+			Disabled: game.RevealedSafe <= 0 && game.Size < maxMinesSize, // disabled if no safe spots revealed yet
+		},
+		discordgo.Button{
+			Label:    "🎲 Pick random",
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("minerandom_%s%s", game.UserID, suffix),
+		},
+	}}
+}
+
+// minesControlsContent is the text of a 5x5 game's controls follow-up.
+func minesControlsContent(game *MinesGame) string {
+	if game.GameOver {
+		return "🔁 Play your 5x5 game again?"
+	}
+	return "💰 Cash out your 5x5 game here once you've found a gem."
+}
+
+// sendMinesControls posts the controls of a 5x5 game as a follow-up to its board.
+func sendMinesControls(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame) {
+	if game.Size < maxMinesSize {
+		return
+	}
+	boardMessageID := ""
+	if board, err := s.InteractionResponse(i.Interaction); err == nil {
+		boardMessageID = board.ID
+	}
+	if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content:    minesControlsContent(game),
+		Components: []discordgo.MessageComponent{minesControls(game, boardMessageID)},
+	}); err != nil {
+		log.Println("Error sending mines controls:", err)
+	}
+}
+
+// generateGameStatus builds the status message for the Mines game.
+func generateGameStatus(game *MinesGame, balance Money) string {
+	var status string
+
+	if game.GameOver {
+		if game.Won {
+			// Won → show "Game"
+			status = fmt.Sprintf("> **%s's Won**\n", fmt.Sprintf("<@%s>", game.UserID))
+		} else {
+			// Lost → show "Lost"
+			status = fmt.Sprintf("> **%s Lost**\n", fmt.Sprintf("<@%s>", game.UserID))
+		}
+	} else {
+		// Still playing → show "Game"
+		status = fmt.Sprintf("> **%s's Game**\n", fmt.Sprintf("<@%s>", game.UserID))
+	}
+	status += fmt.Sprintf("👤 Balance: %s\n", balance)
+	status += fmt.Sprintf("💰 Bet: %s\n", game.BetAmount)
+	status += fmt.Sprintf("💣 Mines: %d on %dx%d\n", game.NumMines, game.Size, game.Size)
+	status += fmt.Sprintf("✅ Safe spots found: %d/%d\n", game.RevealedSafe, game.SafeSpots)
+	if game.GameID != 0 {
+		status += fmt.Sprintf("🎲 Game #%d (`/verify %d`)\n", game.GameID, game.GameID)
+	}
+
+	if game.GameOver {
+		// --- Game finished ---
+		if game.Won {
+			multiplier := game.multiplier()
+			profit := game.CurrentProfit
+			status += fmt.Sprintf("👤 Balance: %s\n", balance)
+			status += fmt.Sprintf("📈 Multiplier: %sx\n", formatMultiplier(multiplier))
+			status += fmt.Sprintf("💵 Profit: +%s\n", profit)
+
+			game.revealMines()
+
+		} else {
+			// Player lost → show bet loss.
+			status += fmt.Sprintf("💸 Loss: %s\n", game.BetAmount)
+
+		}
+
+	} else {
+		// --- Game still in progress ---
+		multiplier := game.multiplier()
+		profit := game.CurrentProfit
+
+		status += fmt.Sprintf("📈 Multiplier: %sx\n", formatMultiplier(multiplier))
+		status += fmt.Sprintf("💵 Potential profit: +%s\n", profit)
+	}
+	return status
+}
+
+// messageComponentHandlers handles button and select menu interactions for the Mines game.
+func handleBtns(s *discordgo.Session, i *discordgo.InteractionCreate) {
+
+	// startTime := time.Now() // Start timing
+
+	if i.Type != discordgo.InteractionMessageComponent {
+		return
+	}
+	customID := i.MessageComponentData().CustomID
+
+	// Only enforce "active game required" for actions that actually need it.
+	// Safely get user ID for ban check
+	var userID string
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	} else {
+		// Log this case and return - shouldn't happen normally
+		log.Printf("Warning: Could not determine user ID for interaction in guild %s", i.GuildID)
+		return
+	}
+	if banChk(s, i, userID) {
+		return // stop here if banned
+	}
+	user, err := store.GetUser(userID)
+	if err == ErrNotFound {
+		if err := respondEphemeral(s, i, "❌ You're not registered! registring user...", nil); err != nil {
+			log.Println("respondUpdate error (not registered):", err)
+		}
+		addUser(s, i)
+		return
+	} else if err != nil {
+		log.Println("DB error checking balance:", err)
+		if err := respondEphemeral(s, i, "❌ Database error!", nil); err != nil {
+			log.Println("respondUpdate error (DB error):", err)
+		}
+		return
+	}
+	userBalance := user.Balance
+	if strings.HasPrefix(customID, "playagainSlot_") {
+		parts := strings.Split(customID, "_")
+		if len(parts) >= 2 {
+			amountStr := parts[1]
+			// Buttons from before machines were configurable carry no machine name
+			m := slotMachines[defaultSlotMachine]
+			if len(parts) >= 3 {
+				m = slotMachines[parts[2]]
+			}
+			betAmount, err := parseMoney(amountStr)
+			if err == nil && m != nil {
+				// Restart the slot game with same bet amount
+				slot(s, i, m, betAmount)
+			}
+		}
+		return
+	}
+	if strings.HasPrefix(customID, "autospinStop_") {
+		stopAutoSpin(s, i, userID)
+		return
+	}
+	if strings.HasPrefix(customID, "daily_claim") {
+		HandleDailyClaimButton(s, i, store, userID)
+		return
+	}
+	if strings.HasPrefix(customID, "roulette") {
+		handleRouletteComponent(s, i, userID, customID, userBalance)
+		return
+	}
+	if strings.HasPrefix(customID, "diceagain_") {
+		parts := strings.Split(customID, "_")
+		if len(parts) == 4 {
+			betAmount, err := parseMoney(parts[1])
+			target, err2 := parseHundredths(parts[2])
+			if err == nil && err2 == nil {
+				playDice(s, i, userID, betAmount, target, parts[3] == "over", userBalance)
+			}
+		}
+		return
+	}
+	if strings.HasPrefix(customID, "limboagain_") {
+		parts := strings.Split(customID, "_")
+		if len(parts) == 3 {
+			betAmount, err := parseMoney(parts[1])
+			target, err2 := parseHundredths(parts[2])
+			if err == nil && err2 == nil {
+				playLimbo(s, i, userID, betAmount, target, userBalance)
+			}
+		}
+		return
+	}
+	if strings.HasPrefix(customID, "coinflip") {
+		handleCoinflipButton(s, i, userID, customID, userBalance)
+		return
+	}
+	if strings.HasPrefix(customID, "crashcashout_") {
+		cashOutCrash(s, i, userID, customID)
+		return
+	}
+	if strings.HasPrefix(customID, "bj_") {
+		handleBlackjackButton(s, i, userID, customID, userBalance)
+		return
+	}
+	if strings.HasPrefix(customID, "bjplayagain_") {
+		if betAmount, err := parseMoney(strings.TrimPrefix(customID, "bjplayagain_")); err == nil {
+			startBlackjack(s, i, userID, betAmount, userBalance)
+		}
+		return
+	}
+
+	mineGame, err := store.GetActiveGame(userID, "mines")
+	if err != nil {
+		// No active game found in DB
+		if !strings.HasPrefix(customID, "playagain_") {
+			respondEphemeral(s, i, "❌ You don't have an active mines game!", nil)
+			return
+		}
+		// if "playagain_*", just continue with mineGame == nil
+	}
+
+	// If there is a game and it is over, block further interactions except "Play Again".
+	if mineGame != nil && mineGame.GameOver && !strings.HasPrefix(customID, "playagain_") {
+		respondEphemeral(s, i, "❌ This game has already ended!", nil)
+		return
+	}
+
+	done := make(chan struct{})
+	if mineGame != nil {
+		game := mineGame // capture
+		go func() {
+			timer := time.NewTimer(2500 * time.Millisecond)
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+				game.mu.Lock()
+				game.deferred = true
+				game.mu.Unlock()
+			case <-done:
+				// Timer cancelled, no need to set deferred
+			}
+		}()
+	}
+
+	// ticker := time.NewTicker(2500 * time.Millisecond)
+	// done := make(chan bool)  // This should be closed when the interaction is done (e.g., game over)
+	// startTimeD := time.Now() // Start timing
+
+	// go func() {
+	// 	for {
+	// 		select {
+	// 		case <-ticker.C:
+	// 			// fmt.Println("Tick at second:", t.Second())
+	// 			log.Printf("Dfferdede %v\n", time.Since(startTimeD))
+
+	// 			// Send deferred update
+	// 			err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+	// 				Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	// 			})
+	// 			if err != nil {
+	// 				log.Println("Deferred update failed:", err)
+	// 				continue // Or break, depending on your error handling policy
+	// 			}
+
+	// 			// Lock to safely update game.deferred
+	// 			mineGame.mu.Lock()
+	// 			mineGame.deferred = true
+	// 			mineGame.mu.Unlock()
+
+	// 		case <-done:
+	// 			ticker.Stop()
+	// 			fmt.Println("Ticker stopped")
+	// 			return
+	// 		}
+	// 	}
+	// }()
+
+	// When you want to stop the ticker later
+	switch {
+	case strings.HasPrefix(customID, "cashout_"):
+		handleCashout(s, i, mineGame, userID, customID, userBalance)
+	case strings.HasPrefix(customID, "playagain_"):
+		handlePlayAgain(s, i, userID, userBalance)
+	case strings.HasPrefix(customID, "mine_"):
+		handleMineClick(s, i, mineGame, customID, userBalance)
+	case strings.HasPrefix(customID, "minerandom_"):
+		handleRandomPick(s, i, mineGame, userID, customID, userBalance)
+	}
+	// done <- true
+	close(done) // cancels if within 2.5s
+	// log.Printf("generateGameStatus hit mine %v\n", time.Since(startTime))
+
+}
+
+// startMinesGame starts a game of numMines on a size x size board; with auto
+// above 0 it plays that many random tiles straight away.
+func startMinesGame(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, betAmount Money, numMines int64, size, auto int, balance Money) {
+	if banChk(s, i, userID) {
+		return // stop here if banned
+	}
+	// Check user balance
+
+	// startTime := time.Now() // Start timing
+	// If user already has an active game, show it instead of starting a new one
+	if game, err := store.GetActiveGame(userID, "mines"); err == nil {
+		status := generateGameStatus(game, balance)
+		buttons := generateMinesButtons(game)
+		if err := respondEphemeral(s, i, "🟢 You already have an active game! Continue playing:\n\n"+status, buttons); err != nil {
+			log.Println("respondUpdate error (active game):", err)
+		}
+		return
+	}
+
+	// Validate bet amount
+	if betAmount <= 0 {
+		if err := respondEphemeral(s, i, "❌ Bet amount must be greater than 0!", nil); err != nil {
+			log.Println("respondUpdate error (invalid bet):", err)
+		}
+		return
+	}
+
+	// Validate board size and mines count
+	if size < minMinesSize || size > maxMinesSize {
+		if err := respondEphemeral(s, i, fmt.Sprintf("❌ The board must be %dx%d to %dx%d!", minMinesSize, minMinesSize, maxMinesSize, maxMinesSize), nil); err != nil {
+			log.Println("respondUpdate error (invalid size):", err)
+		}
+		return
+	}
+	if numMines < 1 || numMines > int64(size*size-1) {
+		if err := respondEphemeral(s, i, fmt.Sprintf("❌ Number of mines must be between 1 and %d on a %dx%d board!", size*size-1, size, size), nil); err != nil {
+			log.Println("respondUpdate error (invalid mines):", err)
+		}
+		return
+	}
+	if safe := size*size - int(numMines); auto < 0 || auto > safe {
+		if err := respondEphemeral(s, i, fmt.Sprintf("❌ Auto can pick 1 to %d tiles with %d mines on a %dx%d board!", safe, numMines, size, size), nil); err != nil {
+			log.Println("respondUpdate error (invalid auto):", err)
+		}
+		return
+	}
+
+	// Balance check
+	if balance < betAmount {
+		if err := respondEphemeral(s, i, "❌ Insufficient balance!", nil); err != nil {
+			log.Println("respondUpdate error (insufficient balance):", err)
+		}
+		return
+	}
+	var username string
+	if i.Member != nil && i.Member.User != nil {
+		username = i.Member.User.Username
+	} else if i.User != nil {
+		username = i.User.Username
+	} else {
+		// Log this case and return - shouldn't happen normally
+		log.Printf("Warning: Could not determine username for interaction in guild %s", i.GuildID)
+		return
+	}
+	// Draw the board from the user's provably-fair seed
+	seed, err := drawSeed(store, userID)
+	if err != nil {
+		log.Println("Error drawing seed:", err)
+		respondEphemeral(s, i, "❌ Failed to create game!", nil)
+		return
+	}
+
+	// Create new game
+	game := createMinesGame(userID, username, betAmount, numMines, size, seed, minesRTP.For(i.GuildID))
+	if game == nil {
+		if err := respondEphemeral(s, i, "❌ Failed to create game!", nil); err != nil {
+			log.Println("respondUpdate error (create game):", err)
+		}
+		return
+	}
+
+	// Take the stake into escrow and save the game in one transaction
+	switch err := store.StartActiveGame(game); err {
+	case nil:
+	case ErrInsufficientBalance:
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	case ErrGameActive:
+		respondEphemeral(s, i, "❌ You already have an active mines game!", nil)
+		return
+	default:
+		log.Println("Error saving game:", err)
+		respondEphemeral(s, i, "❌ Failed to create game!", nil)
+		return
+	}
+
+	if auto > 0 {
+		game.Auto = auto
+		playAutoMines(s, i, game, balance-betAmount)
+		return
+	}
+
+	// Respond with new game state
+	if err := sendNewMessage(s, i, generateGameStatus(game, balance-betAmount), generateMinesButtons(game), minesBoardFiles(game)...); err != nil {
+		log.Println("respondUpdate error (new game):", err)
+	}
+	sendMinesControls(s, i, game)
+	// log.Printf("startMinesGame %v\n", time.Since(startTime))
+
+}
+
+func handleCashout(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame, userID string, customID string, balance Money) {
+	parts := strings.Split(customID, "_")
+	if len(parts) < 2 {
+		return
+	}
+
+	ownerID := parts[1]
+
+	// Block if not the game owner
+	if userID != ownerID {
+		respondEphemeral(s, i, "❌ This isn't your game!", nil)
+		return
+	}
+	// Only a 5x5 game's follow-up button can be pressed this early
+	if game.RevealedSafe == 0 {
+		respondEphemeral(s, i, "❌ Reveal a gem before cashing out!", nil)
+		return
+	}
+
+	if _, err := cashOutMines(game); err == ErrNotFound {
+		respondEphemeral(s, i, "❌ This game has already ended!", nil)
+		return
+	} else if err == ErrGameChanged {
+		respondEphemeral(s, i, "⚠️ The game state changed before your click went through, try again!", nil)
+		return
+	} else if err != nil {
+		log.Println("DB error on cashout:", err)
+		respondEphemeral(s, i, "❌ Error processing cashout!", nil)
+		return
+	}
+	status := cashoutStatus(game, balance)
+
+	if len(parts) < 3 {
+		respondUpdate(s, i, status, generateMinesButtons(game), game)
+		return
+	}
+
+	// Cashed out from a 5x5 board's follow-up: it becomes the result and the
+	// board's tiles are disabled
+	respondUpdate(s, i, status, []discordgo.MessageComponent{minesControls(game, "")}, game)
+	board := generateMinesButtons(game)
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         parts[2],
+		Components: &board,
+	}); err != nil {
+		log.Println("Error disabling the mines board:", err)
+	}
+}
+
+// cashOutMines returns the stake plus profit at the current multiplier, logs
+// the game and closes it in one transaction, then reveals the mines.
+func cashOutMines(game *MinesGame) (Money, error) {
+	winAmount := minesProfit(game.BetAmount, game.multiplier())
+	gameID, err := store.SettleGame(game.result(winAmount))
+	if err != nil {
+		return 0, err
+	}
+	game.GameID = gameID
+	game.CurrentProfit = winAmount
+	game.GameOver, game.Won = true, true
+	game.revealMines()
+	return winAmount, nil
+}
+
+// cashoutStatus is the message of a cashed out game; balance is from before the payout.
+func cashoutStatus(game *MinesGame, balance Money) string {
+	return fmt.Sprintf(
+		"> **%s CASHED OUT!**\n"+
+			"👤 Balance: %s\n"+
+			"💰 Bet: %s\n"+
+			"💣 Mines: %d on %dx%d\n"+
+			"✅ Safe spots found: %d/%d\n"+
+			"📈 Multiplier: %sx\n"+
+			"💵 Profit: +%s\n"+
+			"🎲 Game #%d (`/verify %d`)\n",
+		fmt.Sprintf("<@%s>", game.UserID), balance+game.BetAmount+game.CurrentProfit, game.BetAmount, game.NumMines, game.Size, game.Size, game.RevealedSafe, game.SafeSpots, formatMultiplier(game.multiplier()), game.CurrentProfit,
+		game.GameID, game.GameID,
+	)
+}
+
+// handlePlayAgain handles the "Play Again" button click.
+// The button's CustomID must be in the format: playagain_<betAmount>_<numMines>[_<size>[_<auto>]]
+func handlePlayAgain(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, balance Money) {
+	parts := strings.Split(i.MessageComponentData().CustomID, "_")
+	if len(parts) < 3 || len(parts) > 5 {
+		respondEphemeral(s, i, "❌ Invalid play again button data!", nil)
+		return
+	}
+
+	betAmount, err := parseMoney(parts[1])
+	if err != nil {
+		log.Println("Error parsing playagain CustomID:", err)
+		respondEphemeral(s, i, "❌ Invalid play again data format!", nil)
+		return
+	}
+	numMines, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		log.Println("Error parsing playagain CustomID:", err)
+		respondEphemeral(s, i, "❌ Invalid play again data format!", nil)
+		return
+	}
+
+	// Buttons from before board sizes and auto play carry neither
+	size, auto := defaultMinesSize, 0
+	if len(parts) >= 4 {
+		size, err = strconv.Atoi(parts[3])
+	}
+	if len(parts) == 5 && err == nil {
+		auto, err = strconv.Atoi(parts[4])
+	}
+	if err != nil {
+		log.Println("Error parsing playagain CustomID:", err)
+		respondEphemeral(s, i, "❌ Invalid play again data format!", nil)
+		return
+	}
+
+	startMinesGame(s, i, userID, betAmount, numMines, size, auto, balance)
+}
+
+func handleMineClick(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame, customID string, balance Money) {
+	// Parse button ID: "mine_<row>_<col>"
+
+	parts := strings.Split(customID, "_")
+	if len(parts) < 4 {
+		return
+	}
+
+	ownerID := parts[1] // the player who owns this game
+	row, _ := strconv.Atoi(parts[2])
+	col, _ := strconv.Atoi(parts[3])
+	var userID string
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	} else {
+		// Log this case and return - shouldn't happen normally
+		log.Printf("Warning: Could not determine user ID for interaction in guild %s", i.GuildID)
+		return
+	}
+	// Block clicks from non-owners
+	if userID != ownerID {
+		respondEphemeral(s, i, "❌ This isn't your game!", nil)
+		return
+	}
+
+	// Validate coordinates
+	if row < 0 || row >= game.Size || col < 0 || col >= game.Size {
+		log.Printf("Out-of-range mine coordinates: row=%d col=%d", row, col)
+		respondEphemeral(s, i, "❌ Invalid tile!", nil)
+		return
+	}
+
+	// Already revealed
+	if game.Revealed[row][col] {
+		respondEphemeral(s, i, "❌ This tile is already revealed!", nil)
+		return
+	}
+	playTile(s, i, game, row, col, balance)
+}
+
+// handleRandomPick handles the "Pick random" button:
+// minerandom_<user>[_<board message id>].
+func handleRandomPick(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame, userID string, customID string, balance Money) {
+	parts := strings.Split(customID, "_")
+	if len(parts) < 2 || parts[1] != userID {
+		respondEphemeral(s, i, "❌ This isn't your game!", nil)
+		return
+	}
+	row, col := randomHiddenTile(game, gameRNG)
+	if len(parts) < 3 {
+		playTile(s, i, game, row, col, balance)
+		return
+	}
+	if !pickTile(s, i, game, row, col) {
+		return
+	}
+
+	// Picked from a 5x5 board's follow-up: it keeps the controls and the board
+	// message is edited as on cashout
+	if err := respondUpdateMessage(s, i, minesControlsContent(game), []discordgo.MessageComponent{minesControls(game, parts[2])}); err != nil {
+		log.Println("respondUpdateMessage error (random pick):", err)
+	}
+	status := generateGameStatus(game, minesBalance(game, balance))
+	board := generateMinesButtons(game)
+	files := minesBoardFiles(game)
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:     i.ChannelID,
+		ID:          parts[2],
+		Content:     &status,
+		Components:  &board,
+		Files:       files,
+		Attachments: replaceAttachments(files),
+	}); err != nil {
+		log.Println("Error updating the mines board:", err)
+	}
+}
+
+// playTile reveals a tile the player picked and updates the game message.
+func playTile(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame, row, col int, balance Money) {
+	if !pickTile(s, i, game, row, col) {
+		return
+	}
+	if err := respondUpdate(s, i, generateGameStatus(game, minesBalance(game, balance)), generateMinesButtons(game), game); err != nil {
+		log.Println("respondUpdate error (reveal):", err)
+	}
+}
+
+// pickTile reveals row, col and saves game if it's still running, telling the
+// player when that fails. It reports whether the game message should be updated.
+func pickTile(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame, row, col int) bool {
+	if err := revealTile(game, row, col); err == ErrNotFound {
+		respondEphemeral(s, i, "❌ This game has already ended!", nil)
+		return false
+	} else if err == ErrGameChanged {
+		respondEphemeral(s, i, "⚠️ The game state changed before your click went through, try again!", nil)
+		return false
+	} else if err != nil {
+		log.Println("DB error settling mines game:", err)
+		return false
+	}
+
+	if !game.GameOver {
+		// Another click saved the board since this one loaded it
+		if err := store.SaveActiveGame(game); err == ErrGameChanged {
+			respondEphemeral(s, i, "⚠️ The game state changed before your click went through, try again!", nil)
+			return false
+		} else if err != nil {
+			log.Println("Error saving game:", err)
+		}
+	}
+	return true
+}
+
+// revealTile turns over row, col and settles game if that ends it: a mine
+// keeps the stake, the last safe tile pays out like a cashout. A game still
+// running is left for the caller to save.
+func revealTile(game *MinesGame, row, col int) error {
+	game.Revealed[row][col] = true
+
+	// --- Case 1: Hit a mine ---
+	if game.Board[row][col] {
+		game.GameOver, game.Won = true, false
+
+		// The stake stays with the house: log the loss and close the game in one transaction
+		gameID, err := store.SettleGame(game.result(-game.BetAmount))
+		if err != nil {
+			return err
+		}
+		game.GameID = gameID
+		game.revealMines()
+		return nil
+	}
+
+	// --- Case 2: Safe tile ---
+	game.RevealedSafe++
+	game.CurrentProfit = minesProfit(game.BetAmount, game.multiplier())
+
+	// --- Case 3: Last safe tile, auto-win ---
+	if game.RevealedSafe >= game.SafeSpots {
+		_, err := cashOutMines(game)
+		return err
+	}
+	return nil
+}
+
+// minesBalance is the player's balance once game is where it is now, given
+// their balance with the stake in escrow.
+func minesBalance(game *MinesGame, balance Money) Money {
+	if game.GameOver && game.Won {
+		return balance + game.BetAmount + game.CurrentProfit
+	}
+	return balance
+}
+
+// randomHiddenTile picks one of game's unrevealed tiles; a running game always has one.
+func randomHiddenTile(game *MinesGame, rng RNG) (row, col int) {
+	var hidden [][2]int
+	for r := range game.Revealed {
+		for c := range game.Revealed[r] {
+			if !game.Revealed[r][c] {
+				hidden = append(hidden, [2]int{r, c})
+			}
+		}
+	}
+	tile := hidden[rng.Intn(len(hidden))]
+	return tile[0], tile[1]
+}
+
+// tileName names a tile by row letter and column number, e.g. "B3".
+func tileName(row, col int) string {
+	return fmt.Sprintf("%c%d", 'A'+row, col+1)
+}
+
+// playAutoMines reveals game.Auto random tiles one after another and cashes
+// out if they're all safe, answering the /mines interaction with the result
+// and the order the tiles were turned over in.
+func playAutoMines(s *discordgo.Session, i *discordgo.InteractionCreate, game *MinesGame, balance Money) {
+	var order []string
+	for k := 0; k < game.Auto && !game.GameOver; k++ {
+		row, col := randomHiddenTile(game, gameRNG)
+		if err := revealTile(game, row, col); err != nil {
+			log.Println("DB error settling auto mines game:", err)
+			respondEphemeral(s, i, "❌ Error playing the game!", nil)
+			return
+		}
+		order = append(order, fmt.Sprintf("%s %s", tileName(row, col), pick(game.Board[row][col], "💣", "💎")))
+	}
+
+	var status string
+	if game.GameOver {
+		status = generateGameStatus(game, minesBalance(game, balance))
+	} else {
+		if _, err := cashOutMines(game); err != nil {
+			log.Println("DB error on auto cashout:", err)
+			respondEphemeral(s, i, "❌ Error processing cashout!", nil)
+			return
+		}
+		status = cashoutStatus(game, balance)
+	}
+	status += fmt.Sprintf("🤖 Auto %d: %s\n", game.Auto, strings.Join(order, " → "))
+
+	if err := sendNewMessage(s, i, status, generateMinesButtons(game), minesBoardFiles(game)...); err != nil {
+		log.Println("respondUpdate error (auto game):", err)
+	}
+	sendMinesControls(s, i, game)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMinesBoardSizes(t *testing.T) {
+	for size := minMinesSize; size <= maxMinesSize; size++ {
+		if createMinesGame("1", "alice", 100, int64(size*size), size, testSeed, defaultMinesRTP) != nil {
+			t.Errorf("%dx%d game with no safe tile was created", size, size)
+		}
+		game := createMinesGame("1", "alice", 100, int64(size*size-1), size, testSeed, defaultMinesRTP)
+		if game == nil {
+			t.Fatalf("%dx%d game with one safe tile wasn't created", size, size)
+		}
+		if err := game.checkSize(); err != nil || game.SafeSpots != 1 {
+			t.Errorf("%dx%d game: %v, %d safe spots", size, size, err, game.SafeSpots)
+		}
+
+		// The board and, while there's room, the controls: Discord allows five rows
+		rows := generateMinesButtons(game)
+		want := size + 1
+		if size == maxMinesSize {
+			want = size
+		}
+		if len(rows) != want {
+			t.Errorf("%dx%d board has %d rows; want %d", size, size, len(rows), want)
+		}
+		for _, row := range rows[:size] {
+			if n := len(row.(discordgo.ActionsRow).Components); n != size {
+				t.Errorf("%dx%d board has a row of %d buttons", size, size, n)
+			}
+		}
+	}
+	if createMinesGame("1", "alice", 100, 3, 6, testSeed, defaultMinesRTP) != nil {
+		t.Error("6x6 game was created")
+	}
+
+	game := createMinesGame("1", "alice", 100, 3, 4, testSeed, defaultMinesRTP)
+	game.Revealed = game.Revealed[:3]
+	if game.checkSize() == nil {
+		t.Error("checkSize passed a board missing a row of revealed tiles")
+	}
+}
+
+func TestMinesControls(t *testing.T) {
+	ids := func(row discordgo.ActionsRow) []string {
+		var ids []string
+		for _, c := range row.Components {
+			ids = append(ids, c.(discordgo.Button).CustomID)
+		}
+		return ids
+	}
+
+	// Boards with room for the controls carry them in their last row
+	game := createMinesGame("1", "alice", 100, 3, 4, testSeed, defaultMinesRTP)
+	if got := ids(minesControls(game, "")); len(got) != 2 || got[0] != "cashout_1" || got[1] != "minerandom_1" {
+		t.Errorf("4x4 controls = %v; want cashout_1, minerandom_1", got)
+	}
+
+	// A 5x5 board's follow-up carries the board's message ID to update it
+	game = createMinesGame("1", "alice", 100, 3, maxMinesSize, testSeed, defaultMinesRTP)
+	if got := ids(minesControls(game, "99")); len(got) != 2 || got[0] != "cashout_1_99" || got[1] != "minerandom_1_99" {
+		t.Errorf("5x5 controls = %v; want cashout_1_99, minerandom_1_99", got)
+	}
+	game.GameOver = true
+	if got := ids(minesControls(game, "99")); len(got) != 1 || got[0] != "playagain_1.00_3_5_0" {
+		t.Errorf("5x5 controls of an ended game = %v; want playagain_1.00_3_5_0", got)
+	}
+}
+
+func TestRevealTile(t *testing.T) {
+	old := store
+	t.Cleanup(func() { store = old })
+	st := newMemoryStore()
+	store = st
+	st.AddUser("1", "alice")
+	balance := func() Money {
+		u, _ := st.GetUser("1")
+		return u.Balance
+	}
+
+	// Pick random tiles until the game ends: a mine keeps the stake, clearing
+	// the board pays like a cashout
+	rng := newSeededRNG(3)
+	for _, numMines := range []int64{1, 8} {
+		game := createMinesGame("1", "alice", 100, numMines, 3, testSeed, defaultMinesRTP)
+		if err := st.StartActiveGame(game); err != nil {
+			t.Fatal(err)
+		}
+		before := balance()
+		for !game.GameOver {
+			row, col := randomHiddenTile(game, rng)
+			if game.Revealed[row][col] {
+				t.Fatalf("picked revealed tile %s", tileName(row, col))
+			}
+			if err := revealTile(game, row, col); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := st.GetActiveGame("1", "mines"); err != ErrNotFound {
+			t.Errorf("%d mines: game still active after it ended", numMines)
+		}
+		want := before
+		if game.Won {
+			want = before + game.BetAmount + game.CurrentProfit
+			if game.RevealedSafe != game.SafeSpots {
+				t.Errorf("%d mines: won with %d of %d gems", numMines, game.RevealedSafe, game.SafeSpots)
+			}
+		}
+		if got := balance(); got != want || minesBalance(game, before) != want {
+			t.Errorf("%d mines: balance %s, minesBalance %s; want %s", numMines, got, minesBalance(game, before), want)
+		}
+	}
+	if got := tileName(2, 0); got != "C1" {
+		t.Errorf("tileName(2, 0) = %s; want C1", got)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in integer cents. Balances, bets and payouts are
+// Money everywhere; only multipliers are ratios (*big.Rat).
+//
+// Rounding rules, one per kind of operation:
+//   - user input (command options, button IDs) rounds half away from zero to the cent
+//   - payouts (bet × multiplier) truncate toward zero, so sub-cent dust stays with the house
+//   - everything else (adding, subtracting, comparing) is exact integer math
+type Money int64
+
+const (
+	centsPerUnit = 100
+	// maxMoney keeps amounts within DECIMAL(19,2) and well clear of int64 overflow when summed.
+	maxMoney = Money(math.MaxInt64 / 1000)
+)
+
+var errInvalidAmount = errors.New("invalid amount")
+
+// moneyFromFloat converts a Discord number option, rounding to the nearest cent.
+func moneyFromFloat(f float64) (Money, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, errInvalidAmount
+	}
+	cents := math.Round(f * centsPerUnit)
+	if math.Abs(cents) > float64(maxMoney) {
+		return 0, errInvalidAmount
+	}
+	return Money(cents), nil
+}
+
+// parseMoney parses a decimal string like "12", "-3.5" or "1000.25". More than
+// two decimals are rounded half away from zero.
+func parseMoney(s string) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", errInvalidAmount, s)
+	}
+	r.Mul(r, big.NewRat(centsPerUnit, 1))
+
+	// round half away from zero
+	num, den := new(big.Int).Set(r.Num()), r.Denom()
+	half := new(big.Int).Quo(den, big.NewInt(2))
+	if num.Sign() < 0 {
+		num.Sub(num, half)
+	} else {
+		num.Add(num, half)
+	}
+	num.Quo(num, den)
+
+	if !num.IsInt64() || Money(num.Int64()) > maxMoney || Money(num.Int64()) < -maxMoney {
+		return 0, fmt.Errorf("%w: %q", errInvalidAmount, s)
+	}
+	return Money(num.Int64()), nil
+}
+
+// String formats m with exactly two decimals, e.g. "-12.05".
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/centsPerUnit, m%centsPerUnit)
+}
+
+// Abs returns the absolute value of m.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// MulRat returns m × r truncated toward zero to the cent (the payout rule).
+func (m Money) MulRat(r *big.Rat) Money {
+	num := new(big.Int).Mul(big.NewInt(int64(m)), r.Num())
+	num.Quo(num, r.Denom()) // Quo truncates toward zero
+	if !num.IsInt64() {
+		if num.Sign() < 0 {
+			return -maxMoney
+		}
+		return maxMoney
+	}
+	return Money(num.Int64())
+}
+
+// Scan implements sql.Scanner for DECIMAL columns.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		parsed, err := parseMoney(string(v))
+		*m = parsed
+		return err
+	case string:
+		parsed, err := parseMoney(v)
+		*m = parsed
+		return err
+	case int64:
+		*m = Money(v * centsPerUnit)
+		return nil
+	case float64:
+		parsed, err := moneyFromFloat(v)
+		*m = parsed
+		return err
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+}
+
+// Value implements driver.Valuer, sending the exact decimal string. Use
+// CAST(? AS DECIMAL(19,2)) when the value takes part in SQL arithmetic,
+// otherwise MySQL would do the math in DOUBLE.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// ratio returns num/den as a multiplier.
+func ratio(num, den int64) *big.Rat {
+	return big.NewRat(num, den)
+}
+
+// mustRat parses a decimal multiplier literal such as "77.7"; it panics on bad input
+// and is meant for constants.
+func mustRat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid multiplier " + strconv.Quote(s))
+	}
+	return r
+}
+
+// formatMultiplier shows a multiplier with two decimals, rounded for display only.
+func formatMultiplier(r *big.Rat) string {
+	return r.FloatString(2)
+}
+
+// hundredths is a multiplier or a dice target in hundredths, e.g. 157 for
+// 1.57x or a roll of 1.57. It is written with two decimals like Money but is
+// never an amount, so it parses and formats on its own.
+type hundredths int64
+
+// maxHundredths bounds what parses, far above any multiplier or target.
+const maxHundredths hundredths = 1e12
+
+var errInvalidHundredths = errors.New("invalid multiplier or target")
+
+// hundredthsFromFloat converts a Discord number option, rounding to the
+// nearest hundredth.
+func hundredthsFromFloat(f float64) (hundredths, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+		return 0, errInvalidHundredths
+	}
+	h := math.Round(f * 100)
+	if h > float64(maxHundredths) {
+		return 0, errInvalidHundredths
+	}
+	return hundredths(h), nil
+}
+
+// parseHundredths parses what String writes, e.g. "1.57" or "12". Anything
+// negative or finer than a hundredth is refused rather than rounded.
+func parseHundredths(s string) (hundredths, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", errInvalidHundredths, s)
+	}
+	r.Mul(r, big.NewRat(100, 1))
+	if !r.IsInt() || r.Sign() < 0 || r.Cmp(big.NewRat(int64(maxHundredths), 1)) > 0 {
+		return 0, fmt.Errorf("%w: %q", errInvalidHundredths, s)
+	}
+	return hundredths(r.Num().Int64()), nil
+}
+
+// String formats h with exactly two decimals, e.g. "1.57".
+func (h hundredths) String() string {
+	return fmt.Sprintf("%d.%02d", h/100, h%100)
+}
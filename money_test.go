@@ -0,0 +1,107 @@
+package main
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		ok   bool
+	}{
+		{"12", 1200, true},
+		{" 1000.25 ", 100025, true},
+		{"-3.5", -350, true},
+		{"0.005", 1, true}, // half a cent rounds away from zero
+		{"0.0049", 0, true},
+		{"-0.005", -1, true},
+		{"1/3", 33, true},
+		{"abc", 0, false},
+		{"", 0, false},
+		{"1e30", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseMoney(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseMoney(%q) = %d, %v; want %d, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{1205, "12.05"},
+		{-1205, "-12.05"},
+		{-5, "-0.05"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q; want %q", tt.in, got, tt.want)
+		}
+		if back, err := parseMoney(tt.want); err != nil || back != tt.in {
+			t.Errorf("parseMoney(%q) = %d, %v; want it to round-trip", tt.want, back, err)
+		}
+	}
+}
+
+func TestMulRatTruncates(t *testing.T) {
+	tests := []struct {
+		m    Money
+		num  int64
+		den  int64
+		want Money
+	}{
+		{100, 777, 10, 7770},
+		{333, 1, 3, 111},
+		{100, 1, 3, 33},               // 33.33… cents, the dust stays with the house
+		{-100, 1, 3, -33},             // toward zero, not down
+		{maxMoney, 2000, 1, maxMoney}, // past int64, clamped
+	}
+	for _, tt := range tests {
+		if got := tt.m.MulRat(ratio(tt.num, tt.den)); got != tt.want {
+			t.Errorf("%d × %d/%d = %d; want %d", tt.m, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyFromFloat(t *testing.T) {
+	if got, err := moneyFromFloat(0.1 + 0.2); err != nil || got != 30 {
+		t.Errorf("moneyFromFloat(0.1+0.2) = %d, %v; want 30", got, err)
+	}
+	if _, err := moneyFromFloat(1e300); err == nil {
+		t.Error("moneyFromFloat(1e300) succeeded")
+	}
+}
+
+func TestParseHundredths(t *testing.T) {
+	tests := []struct {
+		in   string
+		want hundredths
+		str  string
+	}{
+		{"1.57", 157, "1.57"},
+		{"12", 1200, "12.00"},
+		{" 0.5 ", 50, "0.50"},
+		{"10000.00", 1000000, "10000.00"},
+	}
+	for _, tt := range tests {
+		if got, err := parseHundredths(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseHundredths(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+		if got := tt.want.String(); got != tt.str {
+			t.Errorf("hundredths(%d).String() = %q; want %q", tt.want, got, tt.str)
+		}
+	}
+	for _, in := range []string{"1.015", "-1", "abc", "", "1e30"} {
+		if _, err := parseHundredths(in); err == nil {
+			t.Errorf("parseHundredths(%q) succeeded", in)
+		}
+	}
+	if got, err := hundredthsFromFloat(1.1 + 0.2); err != nil || got != 130 {
+		t.Errorf("hundredthsFromFloat(1.1+0.2) = %d, %v; want 130", got, err)
+	}
+}
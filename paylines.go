@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxFreeSpins caps one session's free spins, retriggers included.
+const maxFreeSpins = 50
+
+// LineWin is one payline that paid.
+type LineWin struct {
+	Line   int // index into Paylines
+	Symbol int
+	Count  int // reels from the left the run covers
+	Win    Money
+}
+
+// SpinResult is one spin of a payline machine.
+type SpinResult struct {
+	Grid       [][]int // [reel][row]
+	LineWins   []LineWin
+	Scatters   int
+	ScatterWin Money
+	FreeSpins  int // free spins this spin awarded
+	Win        Money
+}
+
+// SlotSession is a paid spin followed by the free spins it triggered. It is
+// settled and logged to games as one row.
+type SlotSession struct {
+	LineBet Money
+	Stake   Money
+	Spins   []SpinResult // Spins[0] is the paid spin
+	Win     Money        // gross paid over every spin
+}
+
+// Outcome is the net result of the session.
+func (ss *SlotSession) Outcome() Money {
+	return ss.Win - ss.Stake
+}
+
+// linePays reports whether m takes line bets and pays runs along paylines.
+func (m *SlotMachine) linePays() bool {
+	return len(m.Paylines) > 0
+}
+
+// Stake is what a spin costs at bet: the bet itself, or bet on every line.
+func (m *SlotMachine) Stake(bet Money) Money {
+	if m.linePays() {
+		return bet * Money(len(m.Paylines))
+	}
+	return bet
+}
+
+// SpinGrid stops every reel on Rows symbols, drawn reel by reel from the top.
+func (m *SlotMachine) SpinGrid(rng RNG) [][]int {
+	grid := make([][]int, len(m.Reels))
+	for reel := range m.Reels {
+		grid[reel] = make([]int, m.Rows)
+		for row := range grid[reel] {
+			grid[reel][row] = m.draw(rng, reel)
+		}
+	}
+	return grid
+}
+
+// EvaluateGrid pays grid at lineBet: every payline's best left-to-right run
+// and the scatters anywhere.
+func (m *SlotMachine) EvaluateGrid(grid [][]int, lineBet Money) SpinResult {
+	res := SpinResult{Grid: grid}
+	for k, line := range m.Paylines {
+		symbols := make([]int, len(line))
+		for reel, row := range line {
+			symbols[reel] = grid[reel][row]
+		}
+		if sym, count, pay := m.evaluateLine(symbols); pay != nil {
+			win := lineBet.MulRat(pay)
+			res.LineWins = append(res.LineWins, LineWin{Line: k, Symbol: sym, Count: count, Win: win})
+			res.Win += win
+		}
+	}
+
+	scatter := -1
+	for reel := range grid {
+		for _, sym := range grid[reel] {
+			if m.Symbols[sym].Scatter {
+				scatter = sym
+				res.Scatters++
+			}
+		}
+	}
+	if scatter >= 0 {
+		if pay := m.Symbols[scatter].pays[res.Scatters]; pay != nil {
+			res.ScatterWin = m.Stake(lineBet).MulRat(pay)
+			res.Win += res.ScatterWin
+		}
+		res.FreeSpins = m.Symbols[scatter].FreeSpins[res.Scatters]
+	}
+	return res
+}
+
+// evaluateLine finds the best run from the leftmost reel: the first non-wild
+// symbol with wilds standing in for it, or a run of wilds alone.
+func (m *SlotMachine) evaluateLine(symbols []int) (sym, count int, pay *big.Rat) {
+	wilds := 0
+	for wilds < len(symbols) && m.Symbols[symbols[wilds]].Wild {
+		wilds++
+	}
+	if wilds > 0 {
+		sym, count, pay = symbols[0], wilds, m.Symbols[symbols[0]].pays[wilds]
+	}
+	if wilds == len(symbols) || m.Symbols[symbols[wilds]].Scatter {
+		return
+	}
+
+	target, run := symbols[wilds], wilds
+	for run < len(symbols) && (symbols[run] == target || m.Symbols[symbols[run]].Wild) {
+		run++
+	}
+	if p := m.Symbols[target].pays[run]; p != nil && (pay == nil || p.Cmp(pay) > 0) {
+		sym, count, pay = target, run, p
+	}
+	return
+}
+
+// PlaySession spins once at lineBet, then plays any free spins it triggers
+// from the same rng.
+func (m *SlotMachine) PlaySession(rng RNG, lineBet Money) *SlotSession {
+	ss := &SlotSession{LineBet: lineBet, Stake: m.Stake(lineBet)}
+	pending := 1
+	for pending > 0 && len(ss.Spins) <= maxFreeSpins {
+		pending--
+		res := m.EvaluateGrid(m.SpinGrid(rng), lineBet)
+		pending += res.FreeSpins
+		ss.Spins = append(ss.Spins, res)
+		ss.Win += res.Win
+	}
+	return ss
+}
+
+// formatGrid draws grid row by row; reels from shown on are still spinning.
+func (m *SlotMachine) formatGrid(grid [][]int, shown int) string {
+	rows := make([]string, m.Rows)
+	for row := range rows {
+		cells := make([]string, len(grid))
+		for reel := range grid {
+			if reel < shown {
+				cells[reel] = m.Symbols[grid[reel][row]].Emoji
+			} else {
+				cells[reel] = "❔"
+			}
+		}
+		rows[row] = strings.Join(cells, " ")
+	}
+	return strings.Join(rows, "\n")
+}
+
+// describeSpin lists what a spin paid, one line per win.
+func (m *SlotMachine) describeSpin(res SpinResult) string {
+	var lines []string
+	for _, w := range res.LineWins {
+		lines = append(lines, fmt.Sprintf("Line %d: %d× %s → $%s", w.Line+1, w.Count, m.Symbols[w.Symbol].Emoji, w.Win))
+	}
+	if res.Scatters > 0 && (res.ScatterWin > 0 || res.FreeSpins > 0) {
+		msg := fmt.Sprintf("%d× scatter", res.Scatters)
+		if res.ScatterWin > 0 {
+			msg += fmt.Sprintf(" → $%s", res.ScatterWin)
+		}
+		if res.FreeSpins > 0 {
+			msg += fmt.Sprintf(" 🎁 +%d free spins", res.FreeSpins)
+		}
+		lines = append(lines, msg)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lineSlotEmbed shows a payline machine mid-animation.
+func lineSlotEmbed(m *SlotMachine, ss *SlotSession, spin, shown int, color int, won Money, balance Money) *discordgo.MessageEmbed {
+	res := ss.Spins[spin]
+	desc := m.formatGrid(res.Grid, shown)
+	if shown >= len(res.Grid) {
+		if wins := m.describeSpin(res); wins != "" {
+			desc += "\n\n" + wins
+		}
+	}
+
+	title := fmt.Sprintf("🎰 %s 🎰", m.Name)
+	if spin > 0 {
+		title += fmt.Sprintf(" · 🎁 Free spin %d/%d", spin, len(ss.Spins)-1)
+	}
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: desc,
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "💰 Bet", Value: fmt.Sprintf("`$%s` × %d lines = `$%s`", ss.LineBet, len(m.Paylines), ss.Stake), Inline: true},
+			{Name: "📊 Win", Value: fmt.Sprintf("`$%s`", won), Inline: true},
+			{Value: fmt.Sprintf("👤 Balance `$%s`", balance), Inline: false},
+		},
+	}
+}
+
+// playLineSlot reveals a settled session on a payline machine reel by reel,
+// spin by spin. The interaction is already deferred.
+func playLineSlot(s *discordgo.Session, i *discordgo.InteractionCreate, m *SlotMachine, spin *paidSpin, userID string, balance Money) {
+	ss, gameID, lineBet := spin.Session, spin.GameID, spin.Session.LineBet
+
+	editWithRetry := func(edit *discordgo.WebhookEdit) {
+		for attempt := 0; attempt < 3; attempt++ {
+			_, err := s.InteractionResponseEdit(i.Interaction, edit)
+			if err == nil {
+				return
+			}
+			if attempt < 2 {
+				time.Sleep(100 * time.Millisecond)
+			} else {
+				log.Printf("Failed to edit interaction after 3 attempts: %v", err)
+			}
+		}
+	}
+	playAgain := func(disabled bool) *[]discordgo.MessageComponent {
+		return &[]discordgo.MessageComponent{discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{&discordgo.Button{
+				Label:    "Play Again 🎰",
+				Style:    discordgo.PrimaryButton,
+				CustomID: fmt.Sprintf("playagainSlot_%s_%s", lineBet, m.Name),
+				Disabled: disabled,
+			}},
+		}}
+	}
+
+	content := fmt.Sprintf("> **<@%s>'s Game**", userID)
+	start := balance - ss.Stake
+	won := Money(0)
+	for spin := range ss.Spins {
+		// Same staged reveal as the classic machine, one reel at a time
+		for shown := 0; shown <= len(m.Reels); shown++ {
+			if shown > 0 {
+				time.Sleep(time.Duration(gameRNG.Intn(400)+200) * time.Millisecond)
+			}
+			if shown == len(m.Reels) {
+				won += ss.Spins[spin].Win
+			}
+			editWithRetry(&discordgo.WebhookEdit{
+				Content:    &content,
+				Embeds:     &[]*discordgo.MessageEmbed{lineSlotEmbed(m, ss, spin, shown, 0xFFFF00, won, start+won)},
+				Components: playAgain(true),
+			})
+		}
+		time.Sleep(700 * time.Millisecond)
+	}
+
+	color := 0xFF0000 // red
+	if ss.Win > 0 {
+		color = 0x00FF00 // green
+	}
+	final := lineSlotEmbed(m, ss, len(ss.Spins)-1, len(m.Reels), color, ss.Win, start+ss.Win)
+	final.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("🎲 Game #%d · /verify %d", gameID, gameID)}
+	editWithRetry(&discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{final},
+		Components: playAgain(false),
+	})
+}
@@ -0,0 +1,153 @@
+package main
+
+import "testing"
+
+// testLineMachine has 3 reels of 2 rows: a, b, a wild W and a scatter S.
+func testLineMachine(t *testing.T) *SlotMachine {
+	t.Helper()
+	machines, err := parseSlotConfig([]byte(`{"machines": {
+		"classic": {"symbols": [{"asset": "a"}], "reels": [[1]]},
+		"lines": {
+			"rows": 2,
+			"symbols": [
+				{"emoji": "a", "pays": {"2": "1", "3": "5"}},
+				{"emoji": "b", "pays": {"3": "10"}},
+				{"emoji": "W", "wild": true, "pays": {"3": "50"}},
+				{"emoji": "S", "scatter": true, "pays": {"3": "2"}, "free_spins": {"3": 5}}
+			],
+			"reels": [[1, 1, 1, 1], [1, 1, 1, 1], [1, 1, 1, 1]],
+			"paylines": [[0, 0, 0], [1, 1, 1]]
+		}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return machines["lines"]
+}
+
+func TestEvaluateLine(t *testing.T) {
+	m := testLineMachine(t)
+	const a, b, W, S = 0, 1, 2, 3
+	tests := []struct {
+		line       []int
+		sym, count int
+		want       string // "" = loss
+	}{
+		{[]int{a, a, a}, a, 3, "5"},
+		{[]int{a, a, b}, a, 2, "1"},
+		{[]int{b, a, a}, 0, 0, ""}, // runs start on the leftmost reel
+		{[]int{W, a, W}, a, 3, "5"},
+		{[]int{W, W, b}, b, 3, "10"},
+		{[]int{W, W, W}, W, 3, "50"},
+		{[]int{W, W, a}, a, 3, "5"},
+		{[]int{a, S, a}, a, 1, ""}, // a scatter breaks the run
+		{[]int{W, S, a}, W, 1, ""},
+		{[]int{b, b, S}, b, 2, ""},
+	}
+	for _, tt := range tests {
+		sym, count, pay := m.evaluateLine(tt.line)
+		if tt.want == "" {
+			if pay != nil {
+				t.Errorf("evaluateLine(%v) = %d× %d at %s; want a loss", tt.line, count, sym, pay.FloatString(1))
+			}
+			continue
+		}
+		if pay == nil || pay.Cmp(mustRat(tt.want)) != 0 || sym != tt.sym || count != tt.count {
+			t.Errorf("evaluateLine(%v) = %d× %d at %v; want %d× %d at %s", tt.line, count, sym, pay, tt.count, tt.sym, tt.want)
+		}
+	}
+}
+
+func TestEvaluateGrid(t *testing.T) {
+	m := testLineMachine(t)
+	const a, b, W, S = 0, 1, 2, 3
+	if got := m.Stake(25); got != 50 {
+		t.Errorf("Stake(0.25) on 2 lines = %s; want 0.50", got)
+	}
+
+	// top line a a a, bottom line S W S: the scatters count anywhere
+	res := m.EvaluateGrid([][]int{{a, S}, {a, W}, {a, S}}, 100)
+	if len(res.LineWins) != 1 || res.LineWins[0] != (LineWin{Line: 0, Symbol: a, Count: 3, Win: 500}) {
+		t.Errorf("line wins = %+v; want line 1 paying 5.00", res.LineWins)
+	}
+	if res.Scatters != 2 || res.ScatterWin != 0 || res.FreeSpins != 0 {
+		t.Errorf("2 scatters = %+v; want no pay", res)
+	}
+
+	res = m.EvaluateGrid([][]int{{S, b}, {S, W}, {S, b}}, 100)
+	if res.Scatters != 3 || res.ScatterWin != 400 || res.FreeSpins != 5 {
+		t.Errorf("3 scatters paid %s and %d free spins; want 4.00 (2× the 2.00 stake) and 5", res.ScatterWin, res.FreeSpins)
+	}
+	if res.Win != 1400 {
+		t.Errorf("b W b plus 3 scatters = %s; want 14.00", res.Win)
+	}
+}
+
+func TestSettleSpinWinningTheStake(t *testing.T) {
+	m := testLineMachine(t)
+	const a, b = 0, 1
+	// a a on both lines pays 1× the line bet twice: the 2.00 stake back
+	ss := &SlotSession{LineBet: 100, Stake: m.Stake(100)}
+	res := m.EvaluateGrid([][]int{{a, a}, {a, a}, {b, b}}, 100)
+	ss.Spins, ss.Win = []SpinResult{res}, res.Win
+	if ss.Outcome() != 0 {
+		t.Fatalf("session won %s on a %s stake; want it even", ss.Win, ss.Stake)
+	}
+
+	st := newMemoryStore()
+	st.AddUser("1", "alice")
+	gameID, err := st.SettleGame(GameResult{UserID: "1", GameType: "slot", Bet: ss.Stake, Outcome: ss.Outcome(), OneShot: true})
+	if err != nil {
+		t.Fatalf("settling an even spin error = %v", err)
+	}
+	if g, err := st.GetGame(gameID); err != nil || g.Amount != 200 || g.Outcome != 0 {
+		t.Errorf("GetGame = %+v, %v; want a 2.00 stake with outcome 0", g, err)
+	}
+	if u, _ := st.GetUser("1"); u.Balance != startingBalance {
+		t.Errorf("balance = %s; want %s", u.Balance, startingBalance)
+	}
+}
+
+func TestPlaySession(t *testing.T) {
+	m := testLineMachine(t)
+	for seed := int64(0); seed < 200; seed++ {
+		ss := m.PlaySession(newSeededRNG(seed), 100)
+		if len(ss.Spins) > maxFreeSpins+1 {
+			t.Fatalf("seed %d played %d spins; want at most %d", seed, len(ss.Spins), maxFreeSpins+1)
+		}
+		var win Money
+		free := 0
+		for _, res := range ss.Spins {
+			win += res.Win
+			free += res.FreeSpins
+		}
+		if want := min(free, maxFreeSpins) + 1; len(ss.Spins) != want {
+			t.Errorf("seed %d awarded %d free spins and played %d spins", seed, free, len(ss.Spins))
+		}
+		if ss.Win != win || ss.Outcome() != win-200 {
+			t.Errorf("seed %d: session win %s, outcome %s; spins won %s", seed, ss.Win, ss.Outcome(), win)
+		}
+
+		again := m.PlaySession(newSeededRNG(seed), 100)
+		if again.Win != ss.Win || len(again.Spins) != len(ss.Spins) {
+			t.Fatalf("seed %d replayed differently", seed)
+		}
+	}
+}
+
+func TestParseLineMachine(t *testing.T) {
+	classic := `"classic": {"symbols": [{"asset": "a"}], "reels": [[1]]}`
+	for _, tt := range []struct{ name, machine string }{
+		{"rows without paylines", `{"rows": 2, "symbols": [{"emoji": "a"}], "reels": [[1]]}`},
+		{"payline misses a reel", `{"rows": 2, "symbols": [{"emoji": "a"}], "reels": [[1], [1]], "paylines": [[0]]}`},
+		{"payline off the grid", `{"rows": 2, "symbols": [{"emoji": "a"}], "reels": [[1]], "paylines": [[2]]}`},
+		{"no emoji", `{"symbols": [{"asset": "a"}], "reels": [[1]], "paylines": [[0]]}`},
+		{"wild without paylines", `{"symbols": [{"asset": "a", "wild": true}], "reels": [[1]]}`},
+		{"free spins off a scatter", `{"symbols": [{"emoji": "a", "free_spins": {"1": 3}}], "reels": [[1]], "paylines": [[0]]}`},
+		{"wild scatter", `{"symbols": [{"emoji": "a", "wild": true, "scatter": true}], "reels": [[1]], "paylines": [[0]]}`},
+	} {
+		config := `{"machines": {` + classic + `, "lines": ` + tt.machine + `}}`
+		if _, err := parseSlotConfig([]byte(config)); err == nil {
+			t.Errorf("%s: parseSlotConfig succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// defaultSlotConfig is the built-in slots.json, used unless the slotConfig
+// env var points at another file.
+//
+//go:embed slots.json
+var defaultSlotConfig []byte
+
+// defaultSlotMachine is played when /slot doesn't name a machine.
+const defaultSlotMachine = "classic"
+
+// slotMachines holds every configured machine by name.
+var slotMachines = mustParseSlotConfig(defaultSlotConfig)
+
+// SlotSymbol is one symbol a reel can stop on.
+type SlotSymbol struct {
+	// Asset names the symbol's emoji tiles: "<asset>_rounded_tile_<row>_<col>"
+	// while spinning and "<asset>F_rounded_tile_<row>_<col>" once stopped.
+	Asset string `json:"asset"`
+	// Pays maps how many times the symbol shows on the line, anywhere, to the
+	// multiple of the bet won on top of the stake. Symbols without pays never win.
+	// On payline machines it maps a left-to-right run to the multiple of the
+	// line bet paid, or for scatters a count anywhere to the multiple of the
+	// total bet paid.
+	Pays map[int]string `json:"pays,omitempty"`
+
+	// Emoji shows the symbol in the grid of a payline machine.
+	Emoji string `json:"emoji,omitempty"`
+	// Wild stands in for any other non-scatter symbol on a payline.
+	Wild bool `json:"wild,omitempty"`
+	// Scatter pays wherever it lands and maps a count anywhere to free spins.
+	Scatter   bool        `json:"scatter,omitempty"`
+	FreeSpins map[int]int `json:"free_spins,omitempty"`
+	// Jackpot wins the machine's progressive pool when every reel shows it.
+	Jackpot bool `json:"jackpot,omitempty"`
+
+	pays map[int]*big.Rat
+}
+
+// SlotMachine is a symbol set, the weight of each symbol per reel, and the
+// paytable.
+type SlotMachine struct {
+	Name    string       `json:"-"`
+	Symbols []SlotSymbol `json:"symbols"`
+	// Reels holds one weight per symbol for every reel; a symbol with weight 2
+	// comes up twice as often on that reel as one with weight 1.
+	Reels [][]int `json:"reels"`
+	// Rows is how many symbols each reel shows, 1 unless set.
+	Rows int `json:"rows,omitempty"`
+	// Paylines lists, for each line, the row it crosses on every reel. A
+	// machine with paylines takes a bet per line and pays runs from the left.
+	Paylines [][]int `json:"paylines,omitempty"`
+	// JackpotRate is the share of every bet paid into the progressive pool
+	// of a machine with a jackpot symbol, e.g. "0.01".
+	JackpotRate string `json:"jackpot_rate,omitempty"`
+
+	totals      []int // sum of each reel's weights
+	jackpot     int   // symbol index, -1 for none
+	jackpotRate *big.Rat
+}
+
+// slotConfig is the layout of slots.json.
+type slotConfig struct {
+	Machines map[string]*SlotMachine `json:"machines"`
+}
+
+// loadSlotMachines reads the machines from path, or the built-in config if path is empty.
+func loadSlotMachines(path string) (map[string]*SlotMachine, error) {
+	if path == "" {
+		return parseSlotConfig(defaultSlotConfig)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseSlotConfig(data)
+}
+
+func mustParseSlotConfig(data []byte) map[string]*SlotMachine {
+	machines, err := parseSlotConfig(data)
+	if err != nil {
+		panic("invalid built-in slots.json: " + err.Error())
+	}
+	return machines
+}
+
+// parseSlotConfig decodes and validates a slot config.
+func parseSlotConfig(data []byte) (map[string]*SlotMachine, error) {
+	var cfg slotConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("slot config: %w", err)
+	}
+	if _, ok := cfg.Machines[defaultSlotMachine]; !ok {
+		return nil, fmt.Errorf("slot config has no %q machine", defaultSlotMachine)
+	}
+	for name, m := range cfg.Machines {
+		m.Name = name
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("slot machine %q: %w", name, err)
+		}
+	}
+	return cfg.Machines, nil
+}
+
+// validate checks m and parses its multipliers.
+func (m *SlotMachine) validate() error {
+	if !validMachineName(m.Name) {
+		return fmt.Errorf("name must be 1-20 letters, digits or dashes")
+	}
+	if len(m.Symbols) == 0 || len(m.Reels) == 0 {
+		return fmt.Errorf("needs symbols and reels")
+	}
+	if m.Rows == 0 {
+		m.Rows = 1
+	}
+	m.jackpot = -1
+	if m.Rows > 1 && len(m.Paylines) == 0 {
+		return fmt.Errorf("%d rows need paylines", m.Rows)
+	}
+	for k, line := range m.Paylines {
+		if len(line) != len(m.Reels) {
+			return fmt.Errorf("payline %d crosses %d of %d reels", k+1, len(line), len(m.Reels))
+		}
+		for _, row := range line {
+			if row < 0 || row >= m.Rows {
+				return fmt.Errorf("payline %d uses row %d of %d", k+1, row, m.Rows)
+			}
+		}
+	}
+	for k := range m.Symbols {
+		sym := &m.Symbols[k]
+		if m.linePays() && sym.Emoji == "" {
+			return fmt.Errorf("symbol %d has no emoji", k)
+		} else if !m.linePays() && sym.Asset == "" {
+			return fmt.Errorf("symbol %d has no asset", k)
+		}
+		if !m.linePays() && (sym.Wild || sym.Scatter) {
+			return fmt.Errorf("symbol %s: wilds and scatters need paylines", sym.Asset)
+		}
+		if len(sym.FreeSpins) > 0 && !sym.Scatter {
+			return fmt.Errorf("symbol %s awards free spins but isn't a scatter", sym.Emoji)
+		}
+		if sym.Wild && sym.Scatter {
+			return fmt.Errorf("symbol %s can't be both wild and scatter", sym.Emoji)
+		}
+		if sym.Jackpot {
+			if m.linePays() || m.jackpot >= 0 {
+				return fmt.Errorf("symbol %s: only one jackpot symbol, and not on payline machines", sym.Asset+sym.Emoji)
+			}
+			if _, ok := sym.Pays[len(m.Reels)]; ok {
+				return fmt.Errorf("symbol %s pays for a full line, which is its jackpot", sym.Asset)
+			}
+			m.jackpot = k
+		}
+		maxCount := len(m.Reels)
+		if sym.Scatter {
+			maxCount *= m.Rows
+		}
+		sym.pays = make(map[int]*big.Rat, len(sym.Pays))
+		for count, mult := range sym.Pays {
+			r, ok := new(big.Rat).SetString(mult)
+			if !ok || r.Sign() <= 0 {
+				return fmt.Errorf("symbol %s pays %q for %d", sym.Asset+sym.Emoji, mult, count)
+			}
+			if count < 1 || count > maxCount {
+				return fmt.Errorf("symbol %s pays for %d of a kind on %d reels", sym.Asset+sym.Emoji, count, len(m.Reels))
+			}
+			sym.pays[count] = r
+		}
+	}
+	if (m.jackpot >= 0) != (m.JackpotRate != "") {
+		return fmt.Errorf("a jackpot symbol and jackpot_rate go together")
+	}
+	if m.JackpotRate != "" {
+		r, ok := new(big.Rat).SetString(m.JackpotRate)
+		if !ok || r.Sign() <= 0 || r.Cmp(ratio(1, 1)) >= 0 {
+			return fmt.Errorf("jackpot_rate %q must be above 0 and below 1", m.JackpotRate)
+		}
+		m.jackpotRate = r
+	}
+	m.totals = make([]int, len(m.Reels))
+	for reel, weights := range m.Reels {
+		if len(weights) != len(m.Symbols) {
+			return fmt.Errorf("reel %d has %d weights for %d symbols", reel+1, len(weights), len(m.Symbols))
+		}
+		for _, w := range weights {
+			if w < 0 {
+				return fmt.Errorf("reel %d has a negative weight", reel+1)
+			}
+			m.totals[reel] += w
+		}
+		if m.totals[reel] == 0 {
+			return fmt.Errorf("reel %d can't stop anywhere", reel+1)
+		}
+	}
+	return nil
+}
+
+// validMachineName keeps names usable inside button custom ids.
+func validMachineName(name string) bool {
+	if len(name) == 0 || len(name) > 20 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// slotMachineNames lists the configured machines, sorted.
+func slotMachineNames() []string {
+	names := make([]string, 0, len(slotMachines))
+	for name := range slotMachines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Spin stops every reel, drawing each from rng by its weights.
+func (m *SlotMachine) Spin(rng RNG) []int {
+	line := make([]int, len(m.Reels))
+	for reel := range m.Reels {
+		line[reel] = m.draw(rng, reel)
+	}
+	return line
+}
+
+// draw picks one symbol of reel by its weights.
+func (m *SlotMachine) draw(rng RNG, reel int) int {
+	n := rng.Intn(m.totals[reel])
+	for sym, w := range m.Reels[reel] {
+		if n < w {
+			return sym
+		}
+		n -= w
+	}
+	panic("slot weights changed after validation")
+}
+
+// Evaluate returns the multiplier line wins, nil for a loss or the jackpot.
+// When several symbols pay, the best one counts.
+func (m *SlotMachine) Evaluate(line []int) *big.Rat {
+	counts := make(map[int]int)
+	for _, sym := range line {
+		counts[sym]++
+	}
+
+	var payout *big.Rat // nil = no win
+	for sym, c := range counts {
+		if p := m.Symbols[sym].pays[c]; p != nil && (payout == nil || p.Cmp(payout) > 0) {
+			payout = p
+		}
+	}
+	return payout
+}
+
+// Outcome is the net result of betting bet on line: bet × payout on a win
+// (truncated to the cent), -bet on a loss. The jackpot line returns the stake
+// here; the pool is paid on top when the spin is settled.
+func (m *SlotMachine) Outcome(bet Money, line []int) Money {
+	if m.JackpotLine(line) {
+		return 0
+	}
+	if payout := m.Evaluate(line); payout != nil {
+		return bet.MulRat(payout)
+	}
+	return -bet
+}
+
+// JackpotLine reports whether line wins the progressive pool.
+func (m *SlotMachine) JackpotLine(line []int) bool {
+	if m.jackpot < 0 {
+		return false
+	}
+	for _, sym := range line {
+		if sym != m.jackpot {
+			return false
+		}
+	}
+	return true
+}
+
+// JackpotFee is the part of bet paid into the pool (truncated to the cent).
+func (m *SlotMachine) JackpotFee(bet Money) Money {
+	if m.jackpotRate == nil {
+		return 0
+	}
+	return bet.MulRat(m.jackpotRate)
+}
+
+// Assets lists the emoji asset name of every symbol, in symbol order.
+func (m *SlotMachine) Assets() []string {
+	assets := make([]string, len(m.Symbols))
+	for k, sym := range m.Symbols {
+		assets[k] = sym.Asset
+	}
+	return assets
+}
+
+// reelTiles is the emoji asset of every reel while line is revealed: the
+// first shown-1 reels have stopped, the next one is still spinning on its
+// symbol and the rest are loading. shown = len(line)+1 stops them all.
+func (m *SlotMachine) reelTiles(line []int, shown int) []string {
+	tiles := make([]string, len(line))
+	for reel, sym := range line {
+		switch {
+		case reel < shown-1:
+			tiles[reel] = m.Symbols[sym].Asset + "F"
+		case reel == shown-1:
+			tiles[reel] = m.Symbols[sym].Asset
+		default:
+			tiles[reel] = "loading"
+		}
+	}
+	return tiles
+}
+
+// formatLine shows a line by symbol assets, e.g. "0 7 11".
+func (m *SlotMachine) formatLine(line []int) string {
+	s := ""
+	for k, sym := range line {
+		if k > 0 {
+			s += " "
+		}
+		s += m.Symbols[sym].Asset
+	}
+	return s
+}
+
+// machineParam is the games.params value recording which machine was played.
+func machineParam(m *SlotMachine) string {
+	return "machine=" + m.Name
+}
+
+// machineFromParams finds the machine a games row was played on; rows from
+// before machines were configurable were all played on the classic one.
+func machineFromParams(params string) (*SlotMachine, error) {
+	name := gameParam(params, "machine")
+	if name == "" {
+		name = defaultSlotMachine
+	}
+	m, ok := slotMachines[name]
+	if !ok {
+		return nil, fmt.Errorf("slot machine %s is no longer configured", strconv.Quote(name))
+	}
+	return m, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import "testing"
+
+func TestClassicPaytable(t *testing.T) {
+	m := slotMachines[defaultSlotMachine]
+	tests := []struct {
+		line []int
+		want string // "" = loss
+	}{
+		{[]int{0, 0, 0}, ""}, // the jackpot
+		{[]int{4, 4, 4}, "33.3"},
+		{[]int{0, 5, 0}, "7.7"},
+		{[]int{3, 9, 9}, "3"},
+		{[]int{7, 7, 7}, ""}, // ❌ never pays
+		{[]int{7, 7, 0}, ""},
+		{[]int{1, 2, 3}, ""},
+	}
+	for _, tt := range tests {
+		got := m.Evaluate(tt.line)
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("Evaluate(%v) = %s; want a loss", tt.line, got.FloatString(1))
+			}
+			continue
+		}
+		if got == nil || got.Cmp(mustRat(tt.want)) != 0 {
+			t.Errorf("Evaluate(%v) = %v; want %s", tt.line, got, tt.want)
+		}
+	}
+
+	// payouts truncate to the cent
+	if got := m.Outcome(333, []int{2, 2, 2}); got != 11088 {
+		t.Errorf("3.33 at 33.3x = %s; want 110.88", got)
+	}
+	if got := m.Outcome(100, []int{0, 0, 3}); got != 770 {
+		t.Errorf("1.00 at 7.7x = %s; want 7.70", got)
+	}
+	if got := m.Outcome(100, []int{1, 2, 3}); got != -100 {
+		t.Errorf("a loss = %s; want -1.00", got)
+	}
+}
+
+func TestJackpotLine(t *testing.T) {
+	m := slotMachines[defaultSlotMachine]
+	if !m.JackpotLine([]int{0, 0, 0}) || m.JackpotLine([]int{0, 0, 1}) {
+		t.Error("only triple 7 should hit the jackpot")
+	}
+	// the stake comes back and the pool is paid when settled
+	if got := m.Outcome(100, []int{0, 0, 0}); got != 0 {
+		t.Errorf("jackpot outcome = %s; want 0.00", got)
+	}
+	if got := m.JackpotFee(1000); got != 45 {
+		t.Errorf("fee on 10.00 = %s; want 0.45", got)
+	}
+	if got := m.JackpotFee(10); got != 0 {
+		t.Errorf("fee on 0.10 = %s; want 0.00 (truncated)", got)
+	}
+	if got := slotMachines["video"].JackpotFee(1000); got != 0 {
+		t.Errorf("fee without a jackpot = %s; want 0.00", got)
+	}
+}
+
+func TestSlotSpin(t *testing.T) {
+	m := slotMachines[defaultSlotMachine]
+	rng := newSeededRNG(7)
+	seen := make(map[int]bool)
+	for k := 0; k < 1000; k++ {
+		for _, sym := range m.Spin(rng) {
+			if sym < 0 || sym >= 12 {
+				t.Fatalf("symbol %d; want 0-11", sym)
+			}
+			seen[sym] = true
+		}
+	}
+	if len(seen) != 12 {
+		t.Errorf("1000 spins showed %d of 12 symbols", len(seen))
+	}
+
+	// weights: the second symbol comes up three times as often as the first,
+	// and the third never
+	weighted, err := parseSlotConfig([]byte(`{"machines": {"classic": {
+		"symbols": [{"asset": "a", "pays": {"1": "1"}}, {"asset": "b"}, {"asset": "c"}],
+		"reels": [[1, 3, 0]]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make([]int, 3)
+	for k := 0; k < 40000; k++ {
+		counts[weighted["classic"].Spin(rng)[0]]++
+	}
+	if counts[2] != 0 || counts[1] < 29000 || counts[1] > 31000 {
+		t.Errorf("weights 1:3:0 came up %v times", counts)
+	}
+}
+
+func TestParseSlotConfig(t *testing.T) {
+	for _, tt := range []struct{ name, config string }{
+		{"no classic machine", `{"machines": {"other": {"symbols": [{"asset": "a"}], "reels": [[1]]}}}`},
+		{"weights don't match symbols", `{"machines": {"classic": {"symbols": [{"asset": "a"}], "reels": [[1, 1]]}}}`},
+		{"reel can't stop", `{"machines": {"classic": {"symbols": [{"asset": "a"}], "reels": [[0]]}}}`},
+		{"bad multiplier", `{"machines": {"classic": {"symbols": [{"asset": "a", "pays": {"1": "x"}}], "reels": [[1]]}}}`},
+		{"more of a kind than reels", `{"machines": {"classic": {"symbols": [{"asset": "a", "pays": {"2": "3"}}], "reels": [[1]]}}}`},
+		{"jackpot without a rate", `{"machines": {"classic": {"symbols": [{"asset": "a", "jackpot": true}], "reels": [[1]]}}}`},
+		{"jackpot rate of 1", `{"machines": {"classic": {"symbols": [{"asset": "a", "jackpot": true}], "reels": [[1]], "jackpot_rate": "1"}}}`},
+		{"jackpot symbol pays a full line", `{"machines": {"classic": {"symbols": [{"asset": "a", "jackpot": true, "pays": {"1": "2"}}], "reels": [[1]], "jackpot_rate": "0.01"}}}`},
+		{"name with underscore", `{"machines": {"classic": {"symbols": [{"asset": "a"}], "reels": [[1]]}, "my_slot": {"symbols": [{"asset": "a"}], "reels": [[1]]}}}`},
+	} {
+		if _, err := parseSlotConfig([]byte(tt.config)); err == nil {
+			t.Errorf("%s: parseSlotConfig succeeded", tt.name)
+		}
+	}
+}
+
+func TestReelTiles(t *testing.T) {
+	m := slotMachines[defaultSlotMachine]
+	line := []int{0, 7, 11}
+	for shown, want := range [][]string{
+		{"loading", "loading", "loading"},
+		{"0", "loading", "loading"},
+		{"0F", "7", "loading"},
+		{"0F", "7F", "11"},
+		{"0F", "7F", "11F"},
+	} {
+		got := m.reelTiles(line, shown)
+		for k := range want {
+			if got[k] != want[k] {
+				t.Errorf("reelTiles(%d) = %v; want %v", shown, got, want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// abandonPolicy decides how an abandoned game with an escrowed stake is settled.
+type abandonPolicy string
+
+const (
+	// policyCashout pays the stake plus whatever profit the game shows right now.
+	policyCashout abandonPolicy = "cashout"
+	// policyRefund returns the stake and nothing else.
+	policyRefund abandonPolicy = "refund"
+	// policyForfeit keeps the stake, as if the game was lost.
+	policyForfeit abandonPolicy = "forfeit"
+)
+
+// defaultAbandonAfter is how long a game may sit untouched before the reaper takes it.
+const defaultAbandonAfter = 5 * time.Minute
+
+// defaultAbandonPolicies applies to game types the abandonPolicy env var doesn't mention.
+var defaultAbandonPolicies = map[string]abandonPolicy{
+	"mines":     policyCashout,
+	"blackjack": policyCashout,
+	// A crash bet left over from a restart mid-round never saw the multiplier
+	"crash": policyRefund,
+}
+
+// reaperConfig is read from the abandonPolicy and abandonAfter env vars.
+type reaperConfig struct {
+	After    time.Duration
+	Policies map[string]abandonPolicy // game type -> policy
+}
+
+// parseAbandonPolicies parses a spec like "mines=refund,blackjack=forfeit" on
+// top of the defaults. Only the game types in the defaults hold a stake the
+// reaper can settle; slot guards and coinflip challenges are cleared apart.
+func parseAbandonPolicies(spec string) (map[string]abandonPolicy, error) {
+	policies := make(map[string]abandonPolicy, len(defaultAbandonPolicies))
+	for gameType, p := range defaultAbandonPolicies {
+		policies[gameType] = p
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		gameType, p, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("abandon policy %q is not <game>=<policy>", item)
+		}
+		gameType = strings.TrimSpace(gameType)
+		if _, ok := defaultAbandonPolicies[gameType]; !ok {
+			return nil, fmt.Errorf("no abandon policy applies to game %q", gameType)
+		}
+		switch policy := abandonPolicy(strings.TrimSpace(p)); policy {
+		case policyCashout, policyRefund, policyForfeit:
+			policies[gameType] = policy
+		default:
+			return nil, fmt.Errorf("unknown abandon policy %q for %s", p, gameType)
+		}
+	}
+	return policies, nil
+}
+
+// reaperConfigFromEnv reads the reaper settings, falling back to the defaults.
+func reaperConfigFromEnv() (reaperConfig, error) {
+	cfg := reaperConfig{After: defaultAbandonAfter}
+
+	policies, err := parseAbandonPolicies(os.Getenv("abandonPolicy"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Policies = policies
+
+	if after := os.Getenv("abandonAfter"); after != "" {
+		d, err := time.ParseDuration(after)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid abandonAfter %q", after)
+		}
+		cfg.After = d
+	}
+	return cfg, nil
+}
+
+// runReaper settles abandoned games, clears stale slot guards and expires
+// forgotten coinflip challenges and roulette slips every interval.
+// It replaces the delete_inactive_games MySQL event, which threw games away
+// with their stake and revealed profit.
+func runReaper(s *discordgo.Session, st Store, cfg reaperConfig, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for gameType, policy := range cfg.Policies {
+			if gameType == "blackjack" {
+				reapBlackjack(s, st, policy, cfg.After)
+				continue
+			}
+			reapGames(s, st, gameType, policy, cfg.After)
+		}
+		releaseStaleSlots(st, cfg.After)
+		expireRouletteSlips(cfg.After)
+		// Challenges normally expire on a timer; these outlived it through a restart
+		expireDuels(st, 2*duelTimeout)
+	}
+}
+
+// reapGames settles every abandoned game of one type under policy and tells the player.
+func reapGames(s *discordgo.Session, st Store, gameType string, policy abandonPolicy, after time.Duration) {
+	games, err := st.StaleActiveGames(gameType, after)
+	if err != nil {
+		log.Printf("DB error listing abandoned %s games: %v", gameType, err)
+		return
+	}
+
+	for _, game := range games {
+		if gameType == "crash" && crashRiding(game.UserID) {
+			continue // its round settles it when it crashes
+		}
+
+		var outcome Money
+		switch policy {
+		case policyCashout:
+			outcome = game.CurrentProfit
+		case policyRefund:
+			outcome = 0
+		case policyForfeit:
+			outcome = -game.BetAmount
+		}
+
+		_, err := st.SettleGame(game.result(outcome))
+		if err == ErrNotFound || err == ErrGameChanged {
+			continue // the player settled or moved it meanwhile
+		} else if err != nil {
+			log.Printf("DB error settling abandoned %s game of %s: %v", gameType, game.UserID, err)
+			continue
+		}
+		log.Printf("Settled abandoned %s game of %s (%s): %s", gameType, game.UserID, policy, outcome)
+
+		notifyAbandoned(s, game.UserID, gameType, game.BetAmount, policy, outcome, after)
+	}
+}
+
+// reapBlackjack settles every abandoned blackjack round under policy. Cashing
+// out stands on every hand left and lets the dealer play, so the round pays
+// whatever it would have.
+func reapBlackjack(s *discordgo.Session, st Store, policy abandonPolicy, after time.Duration) {
+	games, err := st.StaleBlackjackGames(after)
+	if err != nil {
+		log.Printf("DB error listing abandoned blackjack games: %v", err)
+		return
+	}
+
+	for _, game := range games {
+		if policy == policyCashout {
+			game.standAll()
+		}
+		r := game.result()
+		switch policy {
+		case policyRefund:
+			r.Outcome = 0
+		case policyForfeit:
+			r.Outcome = -r.Bet
+		}
+
+		_, err := st.SettleGame(r)
+		if err == ErrNotFound || err == ErrGameChanged {
+			continue // the player settled or moved it meanwhile
+		} else if err != nil {
+			log.Printf("DB error settling abandoned blackjack game of %s: %v", game.UserID, err)
+			continue
+		}
+		log.Printf("Settled abandoned blackjack game of %s (%s): %s", game.UserID, policy, r.Outcome)
+
+		notifyAbandoned(s, game.UserID, "blackjack", r.Bet, policy, r.Outcome, after)
+	}
+}
+
+// notifyAbandoned DMs the player what happened to their game. Players with DMs
+// closed just find the game gone next time.
+func notifyAbandoned(s *discordgo.Session, userID, gameType string, stake Money, policy abandonPolicy, outcome Money, after time.Duration) {
+	if s == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("⏰ Your %s game sat idle for %s, so it was ", gameType, after)
+	switch policy {
+	case policyCashout:
+		msg += fmt.Sprintf("%s: 💰 %s returned to your balance (💵 profit %s%s).",
+			pick(gameType == "blackjack", "played out standing", "cashed out"), stake+outcome, pick(outcome >= 0, "+", ""), outcome)
+	case policyRefund:
+		msg += fmt.Sprintf("refunded: 💰 your bet of %s is back in your balance.", stake)
+	case policyForfeit:
+		msg += fmt.Sprintf("forfeited: 💸 your bet of %s was lost.", stake)
+	}
+
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		log.Printf("Could not open DM with %s: %v", userID, err)
+		return
+	}
+	if _, err := s.ChannelMessageSend(channel.ID, msg); err != nil {
+		log.Printf("Could not DM %s about abandoned game: %v", userID, err)
+	}
+}
+
+// releaseStaleSlots deletes slot guards left behind by a crash mid-spin. A spin
+// takes its bet in one statement, so there's nothing to settle.
+func releaseStaleSlots(st Store, after time.Duration) {
+	guards, err := st.StaleActiveGames("slot", after)
+	if err != nil {
+		log.Println("DB error listing stale slot guards:", err)
+		return
+	}
+	for _, g := range guards {
+		if err := st.DeleteActiveGame(g.UserID, "slot"); err != nil {
+			log.Printf("DB error releasing slot guard of %s: %v", g.UserID, err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAbandonPolicies(t *testing.T) {
+	policies, err := parseAbandonPolicies(" blackjack=forfeit, mines = refund ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policies["mines"] != policyRefund || policies["blackjack"] != policyForfeit {
+		t.Errorf("policies = %v; want mines=refund, blackjack=forfeit", policies)
+	}
+	if defaultAbandonPolicies["mines"] != policyCashout {
+		t.Error("parsing a spec changed the defaults")
+	}
+
+	if policies, err := parseAbandonPolicies(""); err != nil || policies["mines"] != policyCashout {
+		t.Errorf("empty spec = %v, %v; want the defaults", policies, err)
+	}
+	for _, spec := range []string{"mines", "mines=keep", "mine=refund", "slot=forfeit", "coinflip=refund"} {
+		if _, err := parseAbandonPolicies(spec); err == nil {
+			t.Errorf("parseAbandonPolicies(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestReapGames(t *testing.T) {
+	tests := []struct {
+		policy      abandonPolicy
+		wantBalance Money
+	}{
+		{policyCashout, startingBalance + 150},
+		{policyRefund, startingBalance},
+		{policyForfeit, startingBalance - 500},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			st := newMemoryStore()
+			st.AddUser("1", "alice")
+			game := createMinesGame("1", "alice", 500, 3, 4, testSeed, defaultMinesRTP)
+			if err := st.StartActiveGame(game); err != nil {
+				t.Fatal(err)
+			}
+			game.CurrentProfit = 150
+			if err := st.SaveActiveGame(game); err != nil {
+				t.Fatal(err)
+			}
+
+			// still fresh, so the reaper leaves it alone
+			reapGames(nil, st, "mines", tt.policy, time.Minute)
+			if _, err := st.GetActiveGame("1", "mines"); err != nil {
+				t.Fatalf("fresh game was reaped: %v", err)
+			}
+
+			row := st.active["1"]["mines"]
+			row.Updated = time.Now().Add(-time.Hour)
+			st.active["1"]["mines"] = row
+			reapGames(nil, st, "mines", tt.policy, time.Minute)
+
+			if _, err := st.GetActiveGame("1", "mines"); err != ErrNotFound {
+				t.Errorf("abandoned game still active: %v", err)
+			}
+			if u, _ := st.GetUser("1"); u.Balance != tt.wantBalance {
+				t.Errorf("balance = %s; want %s", u.Balance, tt.wantBalance)
+			}
+			if report, _ := st.Reconcile(); len(report.Mismatches) != 0 || len(report.UnbalancedTxns) != 0 {
+				t.Errorf("ledger out of balance after reaping: %+v", report)
+			}
+		})
+	}
+}
+
+func TestReapBlackjack(t *testing.T) {
+	st := newMemoryStore()
+	st.AddUser("1", "alice")
+	// 19 against a 6 up and a 10 in the hole: the dealer draws to 26
+	game := newBlackjackGame("1", "alice", 500, testShoe(10, 6, 9, 10, 10))
+	if err := st.StartBlackjack(game); err != nil {
+		t.Fatal(err)
+	}
+	row := st.active["1"]["blackjack"]
+	row.Updated = time.Now().Add(-time.Hour)
+	st.active["1"]["blackjack"] = row
+
+	reapBlackjack(nil, st, policyCashout, time.Minute)
+	if _, err := st.GetBlackjack("1"); err != ErrNotFound {
+		t.Errorf("abandoned round still active: %v", err)
+	}
+	if u, _ := st.GetUser("1"); u.Balance != startingBalance+500 {
+		t.Errorf("balance = %s; want %s after standing on 19", u.Balance, startingBalance+500)
+	}
+	if report, _ := st.Reconcile(); len(report.Mismatches) != 0 || len(report.UnbalancedTxns) != 0 {
+		t.Errorf("ledger out of balance after reaping: %+v", report)
+	}
+}
+
+func TestReapCrashLeavesLiveRounds(t *testing.T) {
+	st := newMemoryStore()
+	st.AddUser("1", "alice")
+	if err := st.StartCrashBet("1", "alice", 500); err != nil {
+		t.Fatal(err)
+	}
+	row := st.active["1"]["crash"]
+	row.Updated = time.Now().Add(-time.Hour)
+	st.active["1"]["crash"] = row
+
+	// a round still climbing past a short abandonAfter settles its own bets
+	round := &crashRound{CrashRound: CrashRound{ID: 1}, Players: []*crashPlayer{{UserID: "1", Name: "alice", Bet: 500}}}
+	crashMutex.Lock()
+	crashUnsettled[round.ID] = round
+	crashMutex.Unlock()
+	reapGames(nil, st, "crash", policyRefund, time.Minute)
+	if _, ok := st.active["1"]["crash"]; !ok {
+		t.Fatal("bet of a live round was refunded")
+	}
+
+	// one a restart left behind is refunded
+	crashMutex.Lock()
+	delete(crashUnsettled, round.ID)
+	crashMutex.Unlock()
+	reapGames(nil, st, "crash", policyRefund, time.Minute)
+	if _, ok := st.active["1"]["crash"]; ok {
+		t.Error("leftover bet is still in escrow")
+	}
+	if u, _ := st.GetUser("1"); u.Balance != startingBalance {
+		t.Errorf("balance = %s; want the bet refunded", u.Balance)
+	}
+}
+
+func TestReleaseStaleSlots(t *testing.T) {
+	st := newMemoryStore()
+	st.AddUser("1", "alice")
+	if err := st.StartActiveSlot("1"); err != nil {
+		t.Fatal(err)
+	}
+	row := st.active["1"]["slot"]
+	row.Updated = time.Now().Add(-time.Hour)
+	st.active["1"]["slot"] = row
+
+	// An autospin still running refreshes its guard every spin
+	if err := st.TouchActiveSlot("1"); err != nil {
+		t.Fatal(err)
+	}
+	releaseStaleSlots(st, time.Minute)
+	if err := st.StartActiveSlot("1"); err != ErrGameActive {
+		t.Errorf("refreshed slot guard: StartActiveSlot = %v; want ErrGameActive", err)
+	}
+
+	row = st.active["1"]["slot"]
+	row.Updated = time.Now().Add(-time.Hour)
+	st.active["1"]["slot"] = row
+	releaseStaleSlots(st, time.Minute)
+	if err := st.StartActiveSlot("1"); err != nil {
+		t.Errorf("stale slot guard not released: %v", err)
+	}
+}
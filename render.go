@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// tileFS holds the slot tiles: gifs/tiles_<asset>/<tile>_rounded_tile_<row>_<col>.{gif,png},
+// the same files the emoji servers host.
+//
+//go:embed gifs
+var tileFS embed.FS
+
+// displayMode picks how games are drawn, from the display env var. "image"
+// renders classic slot reels as one attachment per reveal step from tileFS
+// and attaches a picture of every mines board; "emoji" stitches the reels from
+// the custom emojis hosted on guildIDs, as the bot did before images.
+var displayMode = "image"
+
+// displayModeFromEnv reads the display env var, defaulting to images.
+func displayModeFromEnv() (string, error) {
+	switch mode := os.Getenv("display"); mode {
+	case "", "image":
+		return "image", nil
+	case "emoji":
+		return mode, nil
+	default:
+		return "", fmt.Errorf("display must be image or emoji, not %q", mode)
+	}
+}
+
+const (
+	tileSize  = 75 // px, every tile is square
+	tileRows  = 3  // a symbol is 3 tiles high and 2 wide
+	tileCols  = 2
+	reelWidth = tileCols * tileSize
+	reelGap   = 10 // px between reels and around the edge
+)
+
+// background matches Discord's dark embed so the images sit flush in it.
+var background = color.NRGBA{0x2b, 0x2d, 0x31, 0xff}
+
+// reelArt is one tile name drawn as a whole reel: a still image, or the
+// frames of an animation with their delays in 100ths of a second.
+type reelArt struct {
+	frames []*image.NRGBA
+	delays []int
+}
+
+var (
+	reelArtMutex sync.Mutex
+	reelArtCache = make(map[string]*reelArt) // tile name -> art
+)
+
+// loadReelArt assembles a tile name ("7F", "7", "loading") from its six tiles,
+// preferring the animated gif over the still png when both exist.
+func loadReelArt(tile string) (*reelArt, error) {
+	reelArtMutex.Lock()
+	defer reelArtMutex.Unlock()
+	if art, ok := reelArtCache[tile]; ok {
+		return art, nil
+	}
+
+	dir := "gifs/tiles_" + strings.TrimSuffix(tile, "F")
+	art := &reelArt{}
+	for row := 0; row < tileRows; row++ {
+		for col := 0; col < tileCols; col++ {
+			name := fmt.Sprintf("%s/%s_rounded_tile_%d_%d", dir, tile, row, col)
+			frames, delays, err := decodeTile(name)
+			if err != nil {
+				return nil, err
+			}
+			if art.frames == nil {
+				art.delays = delays
+				for range frames {
+					art.frames = append(art.frames, image.NewNRGBA(image.Rect(0, 0, reelWidth, tileRows*tileSize)))
+				}
+			}
+			at := image.Pt(col*tileSize, row*tileSize)
+			for k, frame := range art.frames {
+				src := frames[k%len(frames)]
+				draw.Draw(frame, src.Bounds().Add(at), src, src.Bounds().Min, draw.Src)
+			}
+		}
+	}
+	reelArtCache[tile] = art
+	return art, nil
+}
+
+// decodeTile reads name.gif as composed frames, or name.png as a single frame.
+func decodeTile(name string) ([]*image.NRGBA, []int, error) {
+	if data, err := fs.ReadFile(tileFS, name+".gif"); err == nil {
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s.gif: %w", name, err)
+		}
+		return composeGIF(g), g.Delay, nil
+	}
+	data, err := fs.ReadFile(tileFS, name+".png")
+	if err != nil {
+		return nil, nil, fmt.Errorf("no tile %s", name)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s.png: %w", name, err)
+	}
+	still := image.NewNRGBA(img.Bounds())
+	draw.Draw(still, still.Bounds(), img, img.Bounds().Min, draw.Src)
+	return []*image.NRGBA{still}, []int{0}, nil
+}
+
+// composeGIF plays g's frames, which may only cover part of the canvas, onto
+// full frames honouring each frame's disposal.
+func composeGIF(g *gif.GIF) []*image.NRGBA {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	canvas := image.NewNRGBA(bounds)
+	frames := make([]*image.NRGBA, len(g.Image))
+	for k, img := range g.Image {
+		var previous *image.NRGBA
+		if k < len(g.Disposal) && g.Disposal[k] == gif.DisposalPrevious {
+			previous = image.NewNRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, img.Bounds(), img, img.Bounds().Min, draw.Over)
+		frames[k] = image.NewNRGBA(bounds)
+		copy(frames[k].Pix, canvas.Pix)
+
+		switch {
+		case previous != nil:
+			canvas = previous
+		case k < len(g.Disposal) && g.Disposal[k] == gif.DisposalBackground:
+			draw.Draw(canvas, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return frames
+}
+
+// renderReels draws one reveal step, one tile name per reel as reelTiles
+// returns them, as a png when every reel has stopped and a looping gif while
+// any still spins.
+func renderReels(tiles []string) (*discordgo.File, error) {
+	arts := make([]*reelArt, len(tiles))
+	var longest *reelArt
+	for k, tile := range tiles {
+		art, err := loadReelArt(tile)
+		if err != nil {
+			return nil, err
+		}
+		arts[k] = art
+		if longest == nil || len(art.frames) > len(longest.frames) {
+			longest = art
+		}
+	}
+
+	bounds := image.Rect(0, 0, reelGap+len(tiles)*(reelWidth+reelGap), tileRows*tileSize+2*reelGap)
+	frame := func(n int) *image.NRGBA {
+		img := image.NewNRGBA(bounds)
+		draw.Draw(img, bounds, image.NewUniform(background), image.Point{}, draw.Src)
+		for k, art := range arts {
+			src := art.frames[n%len(art.frames)]
+			at := image.Pt(reelGap+k*(reelWidth+reelGap), reelGap)
+			draw.Draw(img, src.Bounds().Add(at), src, image.Point{}, draw.Over)
+		}
+		return img
+	}
+
+	var buf bytes.Buffer
+	if len(longest.frames) == 1 {
+		if err := png.Encode(&buf, frame(0)); err != nil {
+			return nil, err
+		}
+		return &discordgo.File{Name: "slot.png", ContentType: "image/png", Reader: bytes.NewReader(buf.Bytes())}, nil
+	}
+
+	anim := &gif.GIF{Config: image.Config{ColorModel: color.Palette(palette.Plan9), Width: bounds.Dx(), Height: bounds.Dy()}}
+	for n := range longest.frames {
+		paletted := image.NewPaletted(bounds, palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, bounds, frame(n), image.Point{})
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, longest.delays[n])
+	}
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, err
+	}
+	return &discordgo.File{Name: "slot.gif", ContentType: "image/gif", Reader: bytes.NewReader(buf.Bytes())}, nil
+}
+
+// Mines board colours.
+var (
+	hiddenTile = color.NRGBA{0x4e, 0x50, 0x58, 0xff}
+	safeTile   = color.NRGBA{0x24, 0x80, 0x46, 0xff}
+	mineTile   = color.NRGBA{0xda, 0x37, 0x3c, 0xff}
+	gemColor   = color.NRGBA{0x7d, 0xe8, 0xff, 0xff}
+	mineColor  = color.NRGBA{0x1e, 0x1f, 0x22, 0xff}
+)
+
+const (
+	minesCell = 72 // px per board cell
+	minesGap  = 8
+)
+
+// renderMinesBoard draws game's board as a png: hidden cells grey, revealed
+// gems green, and mines red on a lost game. Mines revealed after a win are
+// drawn on grey.
+func renderMinesBoard(game *MinesGame) (*discordgo.File, error) {
+	size := minesGap + game.Size*(minesCell+minesGap)
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	for row := 0; row < game.Size; row++ {
+		for col := 0; col < game.Size; col++ {
+			x, y := minesGap+col*(minesCell+minesGap), minesGap+row*(minesCell+minesGap)
+			cell := image.Rect(x, y, x+minesCell, y+minesCell)
+
+			tile := hiddenTile
+			if game.Revealed[row][col] && game.Board[row][col] && !game.Won {
+				tile = mineTile
+			} else if game.Revealed[row][col] && !game.Board[row][col] {
+				tile = safeTile
+			}
+			fillShape(img, cell, tile, roundedSquare(0.2))
+
+			switch {
+			case !game.Revealed[row][col]:
+			case game.Board[row][col]:
+				fillShape(img, cell, mineColor, circle(0.45))
+				fillShape(img, cell, tile, circle(0.12))
+			default:
+				fillShape(img, cell, gemColor, diamond(0.55))
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return &discordgo.File{Name: "mines.png", ContentType: "image/png", Reader: bytes.NewReader(buf.Bytes())}, nil
+}
+
+// A shape reports whether a point of a cell, scaled to [-1, 1] from its
+// centre, is inside it.
+type shape func(x, y float64) bool
+
+func roundedSquare(radius float64) shape {
+	return func(x, y float64) bool {
+		dx, dy := max(abs(x)-1+radius, 0), max(abs(y)-1+radius, 0)
+		return dx*dx+dy*dy <= radius*radius
+	}
+}
+
+func circle(radius float64) shape {
+	return func(x, y float64) bool { return x*x+y*y <= radius*radius }
+}
+
+func diamond(radius float64) shape {
+	return func(x, y float64) bool { return abs(x)+abs(y) <= radius }
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// fillShape blends c over the part of r inside s, 4×4 supersampled so edges
+// come out smooth.
+func fillShape(img *image.NRGBA, r image.Rectangle, c color.NRGBA, s shape) {
+	const samples = 4
+	w, h := float64(r.Dx()), float64(r.Dy())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			covered := 0
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					x := (float64(px-r.Min.X)+(float64(sx)+0.5)/samples)/w*2 - 1
+					y := (float64(py-r.Min.Y)+(float64(sy)+0.5)/samples)/h*2 - 1
+					if s(x, y) {
+						covered++
+					}
+				}
+			}
+			if covered == 0 {
+				continue
+			}
+			a := float64(covered) / samples / samples
+			dst := img.NRGBAAt(px, py)
+			img.SetNRGBA(px, py, color.NRGBA{
+				R: uint8(float64(c.R)*a + float64(dst.R)*(1-a)),
+				G: uint8(float64(c.G)*a + float64(dst.G)*(1-a)),
+				B: uint8(float64(c.B)*a + float64(dst.B)*(1-a)),
+				A: 0xff,
+			})
+		}
+	}
+}
+
+// minesBoardFiles is the board picture to attach to a mines message, none in
+// emoji mode or if it can't be drawn.
+func minesBoardFiles(game *MinesGame) []*discordgo.File {
+	if displayMode != "image" || game == nil {
+		return nil
+	}
+	file, err := renderMinesBoard(game)
+	if err != nil {
+		log.Println("Error rendering mines board:", err)
+		return nil
+	}
+	return []*discordgo.File{file}
+}
+
+// rewindFiles lets a failed upload of files be retried.
+func rewindFiles(files []*discordgo.File) {
+	for _, f := range files {
+		if r, ok := f.Reader.(io.Seeker); ok {
+			r.Seek(0, io.SeekStart)
+		}
+	}
+}
+
+// replaceAttachments makes an edit swap the message's attachments for files:
+// Discord keeps only the attachments listed, and new uploads are listed by index.
+func replaceAttachments(files []*discordgo.File) *[]*discordgo.MessageAttachment {
+	attachments := make([]*discordgo.MessageAttachment, len(files))
+	for k, f := range files {
+		attachments[k] = &discordgo.MessageAttachment{ID: strconv.Itoa(k), Filename: f.Name}
+	}
+	return &attachments
+}
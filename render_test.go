@@ -0,0 +1,73 @@
+package main
+
+import (
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+func TestRenderReels(t *testing.T) {
+	width := reelGap + 3*(reelWidth+reelGap)
+	height := tileRows*tileSize + 2*reelGap
+
+	file, err := renderReels([]string{"0F", "7F", "11F"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(file.Reader)
+	if err != nil {
+		t.Fatalf("stopped reels aren't a png: %v", err)
+	}
+	if b := img.Bounds(); file.Name != "slot.png" || b.Dx() != width || b.Dy() != height {
+		t.Errorf("stopped reels = %s %dx%d; want slot.png %dx%d", file.Name, b.Dx(), b.Dy(), width, height)
+	}
+
+	file, err = renderReels([]string{"0F", "7", "loading"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(file.Reader)
+	if err != nil {
+		t.Fatalf("spinning reels aren't a gif: %v", err)
+	}
+	if file.Name != "slot.gif" || anim.Config.Width != width || anim.Config.Height != height || len(anim.Image) < 2 {
+		t.Errorf("spinning reels = %s %dx%d with %d frames; want an animated slot.gif %dx%d",
+			file.Name, anim.Config.Width, anim.Config.Height, len(anim.Image), width, height)
+	}
+
+	if _, err := renderReels([]string{"nope"}); err == nil {
+		t.Error("renderReels drew a tile that doesn't exist")
+	}
+}
+
+func TestRenderMinesBoard(t *testing.T) {
+	game := &MinesGame{Size: 4, Board: newMinesGrid(4), Revealed: newMinesGrid(4)}
+	game.Board[0][0] = true
+	game.Revealed[0][0] = true
+	game.Revealed[1][1] = true
+	file, err := renderMinesBoard(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(file.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 328 || b.Dy() != 328 {
+		t.Errorf("board is %dx%d; want 328x328", b.Dx(), b.Dy())
+	}
+	if got, want := color.NRGBAModel.Convert(img.At(minesGap+minesCell/2, minesGap+minesCell/8)), mineTile; got != want {
+		t.Errorf("lost mine cell = %v; want %v", got, want)
+	}
+}
+
+func TestDisplayModeFromEnv(t *testing.T) {
+	for env, want := range map[string]string{"": "image", "image": "image", "emoji": "emoji", "ascii": ""} {
+		t.Setenv("display", env)
+		got, err := displayModeFromEnv()
+		if got != want || (err != nil) != (want == "") {
+			t.Errorf("display=%q: %q, %v; want %q", env, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	mrand "math/rand"
+)
+
+// gameRNG is the randomness the bot draws from outside provably-fair games
+// (daily rewards, animation timing). Tests swap in newSeededRNG.
+var gameRNG RNG = cryptoRNG{}
+
+// RNG is a source of uniform random numbers for the game engines.
+type RNG interface {
+	// Intn returns a number in [0, n).
+	Intn(n int) int
+	// Int63n returns a number in [0, n).
+	Int63n(n int64) int64
+}
+
+// cryptoRNG draws from crypto/rand.
+type cryptoRNG struct{}
+
+func (cryptoRNG) Int63n(n int64) int64 {
+	v, err := rand.Int(rand.Reader, big.NewInt(n))
+	if err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return v.Int64()
+}
+
+func (r cryptoRNG) Intn(n int) int {
+	return int(r.Int63n(int64(n)))
+}
+
+// newSeededRNG returns a deterministic RNG, so tests can replay outcomes.
+func newSeededRNG(seed int64) RNG {
+	return mrand.New(mrand.NewSource(seed))
+}
@@ -0,0 +1,662 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// roulettePockets is the single-zero wheel's size: 0 to 36.
+const roulettePockets = 37
+
+// rouletteWheel is the order of the pockets around a single-zero wheel.
+var rouletteWheel = [roulettePockets]int{
+	0, 32, 15, 19, 4, 21, 2, 25, 17, 34, 6, 27, 13, 36, 11, 30, 8, 23, 10,
+	5, 24, 16, 33, 1, 20, 14, 31, 9, 22, 18, 29, 7, 28, 12, 35, 3, 26,
+}
+
+var redPockets = map[int]bool{
+	1: true, 3: true, 5: true, 7: true, 9: true, 12: true, 14: true, 16: true, 18: true,
+	19: true, 21: true, 23: true, 25: true, 27: true, 30: true, 32: true, 34: true, 36: true,
+}
+
+// pocketLabel shows a pocket with its colour, e.g. "🔴 32".
+func pocketLabel(n int) string {
+	switch {
+	case n == 0:
+		return "🟢 0"
+	case redPockets[n]:
+		return fmt.Sprintf("🔴 %d", n)
+	}
+	return fmt.Sprintf("⚫ %d", n)
+}
+
+// rouletteBet is one chip on the table. Its spec, e.g. "straight:17",
+// "split:17-20", "red" or "dozen:2", is how it's picked and logged.
+type rouletteBet struct {
+	Kind   string // straight, split, red, black, odd, even, dozen or column
+	N      int    // the number, the split's lower number, or which dozen or column (1-3)
+	M      int    // the split's higher number
+	Amount Money
+}
+
+func (b rouletteBet) String() string {
+	switch b.Kind {
+	case "straight", "dozen", "column":
+		return fmt.Sprintf("%s:%d", b.Kind, b.N)
+	case "split":
+		return fmt.Sprintf("split:%d-%d", b.N, b.M)
+	}
+	return b.Kind
+}
+
+// parseRouletteBet reads a bet spec, checking the numbers are on the table
+// and a split's two numbers are next to each other.
+func parseRouletteBet(spec string, amount Money) (rouletteBet, error) {
+	kind, arg, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	b := rouletteBet{Kind: kind, Amount: amount}
+	var err error
+	switch kind {
+	case "red", "black", "odd", "even":
+		if arg != "" {
+			return b, fmt.Errorf("%s bet %q takes no number", kind, spec)
+		}
+		return b, nil
+	case "straight":
+		if b.N, err = strconv.Atoi(arg); err != nil || b.N < 0 || b.N > 36 {
+			return b, fmt.Errorf("no number %q on the table", arg)
+		}
+		return b, nil
+	case "dozen", "column":
+		if b.N, err = strconv.Atoi(arg); err != nil || b.N < 1 || b.N > 3 {
+			return b, fmt.Errorf("%s must be 1, 2 or 3", kind)
+		}
+		return b, nil
+	case "split":
+		lo, hi, _ := strings.Cut(arg, "-")
+		b.N, err = strconv.Atoi(lo)
+		if err == nil {
+			b.M, err = strconv.Atoi(hi)
+		}
+		if b.N > b.M {
+			b.N, b.M = b.M, b.N
+		}
+		if err != nil || !adjacentPockets(b.N, b.M) {
+			return b, fmt.Errorf("split %q isn't two numbers next to each other", arg)
+		}
+		return b, nil
+	}
+	return b, fmt.Errorf("unknown bet %q", spec)
+}
+
+// adjacentPockets reports whether lo < hi share an edge on the table layout:
+// 0 at the top, then 1-36 in rows of three.
+func adjacentPockets(lo, hi int) bool {
+	switch {
+	case lo < 0 || hi > 36 || lo >= hi:
+		return false
+	case lo == 0:
+		return hi <= 3
+	case hi == lo+3:
+		return true
+	}
+	return hi == lo+1 && lo%3 != 0
+}
+
+// covers reports whether the bet wins when the ball lands on pocket.
+func (b rouletteBet) covers(pocket int) bool {
+	switch b.Kind {
+	case "straight":
+		return pocket == b.N
+	case "split":
+		return pocket == b.N || pocket == b.M
+	}
+	if pocket == 0 {
+		return false // the zero loses every outside bet
+	}
+	switch b.Kind {
+	case "red":
+		return redPockets[pocket]
+	case "black":
+		return !redPockets[pocket]
+	case "odd":
+		return pocket%2 == 1
+	case "even":
+		return pocket%2 == 0
+	case "dozen":
+		return (pocket-1)/12 == b.N-1
+	case "column":
+		return (pocket-1)%3 == b.N-1
+	}
+	return false
+}
+
+// pays is the multiple of the bet a win pays on top of it: 36 divided by the
+// numbers covered, less the stake, so the zero is the house's edge.
+func (b rouletteBet) pays() int64 {
+	switch b.Kind {
+	case "straight":
+		return 35
+	case "split":
+		return 17
+	case "dozen", "column":
+		return 2
+	}
+	return 1
+}
+
+// roulettePayout is what bets return when the ball lands on pocket, stakes included.
+func roulettePayout(bets []rouletteBet, pocket int) Money {
+	var paid Money
+	for _, b := range bets {
+		if b.covers(pocket) {
+			paid += b.Amount * Money(b.pays()+1)
+		}
+	}
+	return paid
+}
+
+// rouletteStake is the total staked on bets.
+func rouletteStake(bets []rouletteBet) Money {
+	var stake Money
+	for _, b := range bets {
+		stake += b.Amount
+	}
+	return stake
+}
+
+// rouletteRTP is the exact share of their stake bets return on average,
+// taken over every pocket of the wheel.
+func rouletteRTP(bets []rouletteBet) *big.Rat {
+	var paid Money
+	for pocket := 0; pocket < roulettePockets; pocket++ {
+		paid += roulettePayout(bets, pocket)
+	}
+	return ratio(int64(paid), int64(rouletteStake(bets))*roulettePockets)
+}
+
+// formatRouletteBets logs bets in games.params, e.g. "10.00@red;5.00@straight:17".
+func formatRouletteBets(bets []rouletteBet) string {
+	specs := make([]string, len(bets))
+	for k, b := range bets {
+		specs[k] = fmt.Sprintf("%s@%s", b.Amount, b)
+	}
+	return strings.Join(specs, ";")
+}
+
+// parseRouletteBets reads bets back from formatRouletteBets.
+func parseRouletteBets(s string) ([]rouletteBet, error) {
+	var bets []rouletteBet
+	for _, item := range strings.Split(s, ";") {
+		amount, spec, ok := strings.Cut(item, "@")
+		if !ok {
+			return nil, fmt.Errorf("roulette bet %q is not <amount>@<bet>", item)
+		}
+		m, err := parseMoney(amount)
+		if err != nil {
+			return nil, err
+		}
+		b, err := parseRouletteBet(spec, m)
+		if err != nil {
+			return nil, err
+		}
+		bets = append(bets, b)
+	}
+	return bets, nil
+}
+
+// rouletteMenu is a select menu of bets on the slip. Discord caps a menu at
+// 25 options.
+type rouletteMenu struct {
+	ID          string
+	Placeholder string
+	Specs       []string
+}
+
+// rouletteMenus are the select menus a betting slip always offers: outside
+// bets and straight-up numbers, split over two menus.
+var rouletteMenus = []rouletteMenu{
+	{"outside", "Outside bets", []string{"red", "black", "odd", "even", "dozen:1", "dozen:2", "dozen:3", "column:1", "column:2", "column:3"}},
+	{"low", "Straight up 0-18", straightSpecs(0, 18)},
+	{"high", "Straight up 19-36", straightSpecs(19, 36)},
+}
+
+// splitMenus page through every split on the table. A message holds five
+// rows, so the slip shows one page at a time under the three menus above.
+var splitMenus = splitPages(20)
+
+func straightSpecs(from, to int) []string {
+	var specs []string
+	for n := from; n <= to; n++ {
+		specs = append(specs, fmt.Sprintf("straight:%d", n))
+	}
+	return specs
+}
+
+// splitPages cuts every split on the table, by lower number, into menus of
+// at most perPage.
+func splitPages(perPage int) []rouletteMenu {
+	var specs []string
+	for lo := 0; lo <= 36; lo++ {
+		for hi := lo + 1; hi <= 36; hi++ {
+			if adjacentPockets(lo, hi) {
+				specs = append(specs, fmt.Sprintf("split:%d-%d", lo, hi))
+			}
+		}
+	}
+
+	pages := (len(specs) + perPage - 1) / perPage
+	menus := make([]rouletteMenu, pages)
+	for k := range menus {
+		page := specs[k*perPage : min((k+1)*perPage, len(specs))]
+		menus[k] = rouletteMenu{
+			ID: fmt.Sprintf("split%d", k+1),
+			Placeholder: fmt.Sprintf("Splits %s to %s (%d/%d)",
+				strings.TrimPrefix(page[0], "split:"), strings.TrimPrefix(page[len(page)-1], "split:"), k+1, pages),
+			Specs: page,
+		}
+	}
+	return menus
+}
+
+// betLabel is how a bet spec reads on a slip, e.g. "Dozen 2 (13-24)".
+func betLabel(spec string) string {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "straight":
+		n, _ := strconv.Atoi(arg)
+		return pocketLabel(n)
+	case "split":
+		return "Split " + arg
+	case "dozen":
+		n, _ := strconv.Atoi(arg)
+		return fmt.Sprintf("Dozen %d (%d-%d)", n, 12*n-11, 12*n)
+	case "column":
+		return "Column " + arg
+	}
+	return strings.ToUpper(kind[:1]) + kind[1:]
+}
+
+// rouletteSlip is a player's bets before the spin, one chip of Amount per
+// pick. Picks holds each select menu's choice, split pages included.
+type rouletteSlip struct {
+	Amount    Money
+	Picks     map[string][]string
+	SplitPage int       // the page of splitMenus on show
+	Updated   time.Time // last pick, for expireRouletteSlips
+	// Spinning is set from the Spin click until the spin settles or fails.
+	// The slip stays open meanwhile but takes no picks or second spin.
+	Spinning bool
+}
+
+// copy returns a snapshot of slip that later picks don't change; callers
+// hold rouletteMutex for slips in rouletteSlips.
+func (slip *rouletteSlip) copy() *rouletteSlip {
+	cp := &rouletteSlip{Amount: slip.Amount, Picks: make(map[string][]string, len(slip.Picks)), SplitPage: slip.SplitPage, Updated: slip.Updated}
+	for menu, specs := range slip.Picks {
+		cp.Picks[menu] = specs
+	}
+	return cp
+}
+
+func (slip *rouletteSlip) bets() []rouletteBet {
+	keys := make([]string, 0, len(slip.Picks))
+	for k := range slip.Picks {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var bets []rouletteBet
+	for _, k := range keys {
+		for _, spec := range slip.Picks[k] {
+			if b, err := parseRouletteBet(spec, slip.Amount); err == nil {
+				bets = append(bets, b)
+			}
+		}
+	}
+	return bets
+}
+
+// Betting slips by user, and each user's last spun slip for Play Again.
+// rouletteMutex guards both maps and the open slips in rouletteSlips.
+var (
+	rouletteMutex     sync.Mutex
+	rouletteSlips     = make(map[string]*rouletteSlip)
+	rouletteLastSlips = make(map[string]*rouletteSlip)
+)
+
+// expireRouletteSlips forgets slips nobody picked on or spun for idle. They
+// hold no stake, so there's nothing to settle.
+func expireRouletteSlips(idle time.Duration) {
+	rouletteMutex.Lock()
+	defer rouletteMutex.Unlock()
+
+	for userID, slip := range rouletteSlips {
+		if !slip.Spinning && time.Since(slip.Updated) > idle {
+			delete(rouletteSlips, userID)
+		}
+	}
+}
+
+// reopenSlip lets the player pick on and spin slip again after a spin of it
+// failed before settling.
+func reopenSlip(slip *rouletteSlip) {
+	rouletteMutex.Lock()
+	defer rouletteMutex.Unlock()
+
+	slip.Spinning = false
+	slip.Updated = time.Now()
+}
+
+// closeSpunSlip closes open, unless a new /roulette replaced it meanwhile,
+// and keeps spun, the snapshot that settled, for Play Again.
+func closeSpunSlip(userID string, open, spun *rouletteSlip) {
+	rouletteMutex.Lock()
+	defer rouletteMutex.Unlock()
+
+	if rouletteSlips[userID] == open {
+		delete(rouletteSlips, userID)
+	}
+	rouletteLastSlips[userID] = spun
+}
+
+// slipStatus shows a slip and what it stakes.
+func slipStatus(userID string, slip *rouletteSlip, balance Money) string {
+	status := fmt.Sprintf("> **<@%s>'s Roulette**\n", userID)
+	status += fmt.Sprintf("👤 Balance: %s\n", balance)
+	status += fmt.Sprintf("🪙 Chip: %s per bet\n", slip.Amount)
+	bets := slip.bets()
+	if len(bets) == 0 {
+		return status + "Pick your bets below, then spin.\n"
+	}
+	labels := make([]string, len(bets))
+	for k, b := range bets {
+		labels[k] = betLabel(b.String())
+	}
+	status += fmt.Sprintf("🎯 Bets: %s\n", strings.Join(labels, ", "))
+	status += fmt.Sprintf("💰 Total: %s\n", rouletteStake(bets))
+	return status
+}
+
+// slipComponents are the menus and buttons of a betting slip. Ids are
+// roulettepick_<menu>_<user>, roulettespin_<user>, rouletteclear_<user> and
+// roulettesplits_<user>, which turns the split menu's page.
+func slipComponents(userID string, slip *rouletteSlip) []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+	for _, menu := range rouletteMenus {
+		rows = append(rows, menuRow(userID, slip, menu))
+	}
+	rows = append(rows, menuRow(userID, slip, splitMenus[slip.SplitPage]))
+	rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "🎡 Spin", Style: discordgo.SuccessButton, CustomID: "roulettespin_" + userID, Disabled: len(slip.bets()) == 0},
+		discordgo.Button{Label: "Clear", Style: discordgo.SecondaryButton, CustomID: "rouletteclear_" + userID},
+		discordgo.Button{Label: "More splits ▶", Style: discordgo.SecondaryButton, CustomID: "roulettesplits_" + userID},
+	}})
+	return rows
+}
+
+// menuRow is menu as a row of the slip, showing what the slip picked on it.
+func menuRow(userID string, slip *rouletteSlip, menu rouletteMenu) discordgo.ActionsRow {
+	chosen := make(map[string]bool)
+	for _, spec := range slip.Picks[menu.ID] {
+		chosen[spec] = true
+	}
+	options := make([]discordgo.SelectMenuOption, len(menu.Specs))
+	for k, spec := range menu.Specs {
+		options[k] = discordgo.SelectMenuOption{Label: betLabel(spec), Value: spec, Default: chosen[spec]}
+	}
+	minValues := 0
+	return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.SelectMenu{
+			CustomID:    fmt.Sprintf("roulettepick_%s_%s", menu.ID, userID),
+			Placeholder: menu.Placeholder,
+			MinValues:   &minValues,
+			MaxValues:   len(options),
+			Options:     options,
+		},
+	}}
+}
+
+// startRoulette handles /roulette: it posts a betting slip with chips of
+// amount, and the split bet if one was given picked on its page.
+func startRoulette(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, amount Money, split string, balance Money) {
+	if amount <= 0 {
+		respondEphemeral(s, i, "❌ Bet amount must be greater than 0!", nil)
+		return
+	}
+	slip := &rouletteSlip{Amount: amount, Picks: make(map[string][]string)}
+	if split != "" {
+		b, err := parseRouletteBet("split:"+split, amount)
+		if err != nil {
+			respondEphemeral(s, i, "❌ "+err.Error(), nil)
+			return
+		}
+		for page, menu := range splitMenus {
+			for _, spec := range menu.Specs {
+				if spec == b.String() {
+					slip.Picks[menu.ID] = []string{spec}
+					slip.SplitPage = page
+				}
+			}
+		}
+	}
+	postSlip(s, i, userID, slip, balance)
+}
+
+// postSlip makes slip the user's open slip and posts it.
+func postSlip(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, slip *rouletteSlip, balance Money) {
+	slip.Updated = time.Now()
+	rouletteMutex.Lock()
+	rouletteSlips[userID] = slip
+	rouletteMutex.Unlock()
+
+	if err := sendNewMessage(s, i, slipStatus(userID, slip, balance), slipComponents(userID, slip)); err != nil {
+		log.Println("sendNewMessage error (roulette):", err)
+	}
+}
+
+// handleRouletteComponent handles the slip's menus and buttons and Play Again.
+func handleRouletteComponent(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, customID string, balance Money) {
+	action, rest, _ := strings.Cut(customID, "_")
+	ownerID := rest
+	if action == "roulettepick" {
+		_, ownerID, _ = strings.Cut(rest, "_")
+	}
+	if ownerID != userID {
+		respondEphemeral(s, i, "❌ This isn't your game!", nil)
+		return
+	}
+
+	if action == "rouletteagain" {
+		rouletteMutex.Lock()
+		last := rouletteLastSlips[userID]
+		rouletteMutex.Unlock()
+		if last == nil {
+			respondEphemeral(s, i, "❌ Your last bets are gone, start a new game with /roulette!", nil)
+			return
+		}
+		postSlip(s, i, userID, last.copy(), balance)
+		return
+	}
+
+	// A pick only changes the slip while it's still open and not spinning, and
+	// the reply shows a snapshot taken with it
+	rouletteMutex.Lock()
+	slip := rouletteSlips[userID]
+	spinning := slip != nil && slip.Spinning
+	if slip != nil && !spinning {
+		switch action {
+		case "roulettepick":
+			menu, _, _ := strings.Cut(rest, "_")
+			slip.Picks[menu] = i.MessageComponentData().Values
+			slip.Updated = time.Now()
+			slip = slip.copy()
+		case "rouletteclear":
+			slip.Picks = make(map[string][]string)
+			slip.Updated = time.Now()
+			slip = slip.copy()
+		case "roulettesplits":
+			// Picks on the other pages stay on the slip
+			slip.SplitPage = (slip.SplitPage + 1) % len(splitMenus)
+			slip.Updated = time.Now()
+			slip = slip.copy()
+		case "roulettespin":
+			// A second click finds it spinning; the slip itself stays open
+			// until the spin settles
+			slip.Spinning = true
+			slip.Updated = time.Now()
+		}
+	}
+	rouletteMutex.Unlock()
+	if slip == nil {
+		respondEphemeral(s, i, "❌ This betting slip is closed, start a new one with /roulette!", nil)
+		return
+	}
+	if spinning {
+		respondEphemeral(s, i, "❌ This betting slip is already spinning!", nil)
+		return
+	}
+
+	if action == "roulettespin" {
+		spinRoulette(s, i, userID, slip, balance)
+		return
+	}
+	if err := respondUpdateMessage(s, i, slipStatus(userID, slip, balance), slipComponents(userID, slip)); err != nil {
+		log.Println("respondUpdateMessage error (roulette slip):", err)
+	}
+}
+
+// spinRoulette settles the bets of open, the user's slip marked Spinning, on
+// a pocket drawn from the user's seeds, then animates the ball into it with
+// staged edits like the slot reels. The slip is only closed once its spin
+// settled; any failure before that hands it back to the player.
+func spinRoulette(s *discordgo.Session, i *discordgo.InteractionCreate, userID string, open *rouletteSlip, balance Money) {
+	// Settle and show a snapshot of its own, whatever happens to the open slip meanwhile
+	rouletteMutex.Lock()
+	slip := open.copy()
+	rouletteMutex.Unlock()
+
+	settled := false
+	defer func() {
+		if !settled {
+			reopenSlip(open)
+		}
+	}()
+
+	bets := slip.bets()
+	stake := rouletteStake(bets)
+	if len(bets) == 0 {
+		respondEphemeral(s, i, "❌ Pick at least one bet first!", nil)
+		return
+	}
+	if balance < stake {
+		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
+		return
+	}
+
+	seed, err := drawSeed(store, userID)
+	if err != nil {
+		log.Println("Error drawing seed:", err)
+		respondEphemeral(s, i, "❌ Failed to spin!", nil)
+		return
+	}
+	pocket := newFairStream(seed).Intn(roulettePockets)
+	outcome := roulettePayout(bets, pocket) - stake
+
+	// Settle before animating, like slot
+	gameID, err := store.SettleGame(GameResult{
+		UserID:   userID,
+		GameType: "roulette",
+		Bet:      stake,
+		Outcome:  outcome,
+		OneShot:  true,
+		SeedID:   seed.ID,
+		Nonce:    seed.Nonce,
+		Params:   "bets=" + formatRouletteBets(bets),
+	})
+	if err == ErrInsufficientBalance {
+		respondEphemeral(s, i, "❌ Insufficient balance or concurrent transaction!", nil)
+		return
+	} else if err != nil {
+		log.Printf("DB error settling roulette for user %s: %v", userID, err)
+		respondEphemeral(s, i, "❌ Database error!", nil)
+		return
+	}
+	settled = true
+	closeSpunSlip(userID, open, slip)
+
+	header := slipStatus(userID, slip, balance-stake)
+	if err := respondUpdateMessage(s, i, header+"\n🎡 No more bets!", []discordgo.MessageComponent{}); err != nil {
+		log.Println("respondUpdateMessage error (roulette spin):", err)
+		return
+	}
+
+	editWithRetry := func(content string, components []discordgo.MessageComponent) {
+		for attempt := 0; attempt < 3; attempt++ {
+			_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content, Components: &components})
+			if err == nil {
+				return
+			}
+			if attempt < 2 {
+				time.Sleep(100 * time.Millisecond)
+			} else {
+				log.Printf("Failed to edit interaction after 3 attempts: %v", err)
+			}
+		}
+	}
+
+	// The ball slows down around the wheel, landing on the drawn pocket
+	for _, behind := range []int{11 + gameRNG.Intn(8), 5, 2, 1} {
+		time.Sleep(time.Duration(gameRNG.Intn(400)+400) * time.Millisecond)
+		editWithRetry(header+"\n"+wheelWindow(pocket, behind), []discordgo.MessageComponent{})
+	}
+
+	time.Sleep(time.Duration(gameRNG.Intn(500)+500) * time.Millisecond)
+	result := header + "\n" + wheelWindow(pocket, 0) + "\n"
+	result += fmt.Sprintf("🎯 The ball landed on **%s**\n", pocketLabel(pocket))
+	for _, b := range bets {
+		if b.covers(pocket) {
+			result += fmt.Sprintf("✅ %s pays %s\n", betLabel(b.String()), b.Amount*Money(b.pays()+1))
+		}
+	}
+	if outcome >= 0 {
+		result += fmt.Sprintf("💵 Profit: +%s\n", outcome)
+	} else {
+		result += fmt.Sprintf("💸 Loss: %s\n", -outcome)
+	}
+	result += fmt.Sprintf("👤 Balance: %s\n", balance+outcome)
+	result += fmt.Sprintf("🎲 Game #%d (`/verify %d`)\n", gameID, gameID)
+	editWithRetry(result, []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Play Again 🎡", Style: discordgo.PrimaryButton, CustomID: "rouletteagain_" + userID},
+	}}})
+}
+
+// wheelWindow draws the pockets around the ball, which sits behind pockets
+// short of pocket going round the wheel.
+func wheelWindow(pocket, behind int) string {
+	at := 0
+	for k, n := range rouletteWheel {
+		if n == pocket {
+			at = k
+		}
+	}
+	at = (at - behind + roulettePockets) % roulettePockets
+
+	cells := make([]string, 0, 5)
+	for k := -2; k <= 2; k++ {
+		n := rouletteWheel[(at+k+roulettePockets)%roulettePockets]
+		if k == 0 {
+			cells = append(cells, fmt.Sprintf("**[%s]**", pocketLabel(n)))
+		} else {
+			cells = append(cells, pocketLabel(n))
+		}
+	}
+	return "🎡 " + strings.Join(cells, " · ")
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRouletteBet(t *testing.T) {
+	for _, spec := range []string{"red", "straight:0", "straight:36", "split:17-20", "split:20-17", "split:0-3", "split:1-2", "dozen:3", "column:1"} {
+		if _, err := parseRouletteBet(spec, 100); err != nil {
+			t.Errorf("parseRouletteBet(%q): %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"green", "red:1", "straight:37", "straight:x", "split:3-4", "split:0-4", "split:17-17", "split:17", "dozen:4", "column:0"} {
+		if _, err := parseRouletteBet(spec, 100); err == nil {
+			t.Errorf("parseRouletteBet(%q) succeeded", spec)
+		}
+	}
+	if b, _ := parseRouletteBet("split:20-17", 100); b.String() != "split:17-20" {
+		t.Errorf("split 20-17 reads as %s; want split:17-20", b)
+	}
+}
+
+func TestRoulettePayout(t *testing.T) {
+	bet := func(spec string) []rouletteBet {
+		b, err := parseRouletteBet(spec, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []rouletteBet{b}
+	}
+	tests := []struct {
+		spec   string
+		pocket int
+		paid   Money
+	}{
+		{"straight:17", 17, 3600},
+		{"straight:17", 18, 0},
+		{"split:17-20", 20, 1800},
+		{"red", 32, 200},
+		{"red", 0, 0},
+		{"black", 17, 200},
+		{"even", 0, 0},
+		{"odd", 35, 200},
+		{"dozen:3", 25, 300},
+		{"dozen:3", 24, 0},
+		{"column:1", 34, 300},
+		{"column:3", 36, 300},
+	}
+	for _, tt := range tests {
+		if paid := roulettePayout(bet(tt.spec), tt.pocket); paid != tt.paid {
+			t.Errorf("%s on %d paid %s; want %s", tt.spec, tt.pocket, paid, tt.paid)
+		}
+	}
+}
+
+func TestRouletteRTP(t *testing.T) {
+	// Every bet and any mix of them returns 36 of every 37 staked
+	want := ratio(36, 37)
+	var all []rouletteBet
+	for _, menu := range rouletteMenus {
+		for _, spec := range menu.Specs {
+			b, _ := parseRouletteBet(spec, 250)
+			if rtp := rouletteRTP([]rouletteBet{b}); rtp.Cmp(want) != 0 {
+				t.Errorf("%s RTP = %s; want 36/37", spec, rtp.RatString())
+			}
+			all = append(all, b)
+		}
+	}
+	if rtp := rouletteRTP(all); rtp.Cmp(want) != 0 {
+		t.Errorf("RTP of every bet at once = %s; want 36/37", rtp.RatString())
+	}
+
+	parsed, err := parseRouletteBets(formatRouletteBets(all))
+	if err != nil || !reflect.DeepEqual(parsed, all) {
+		t.Errorf("bets don't survive being logged: %v", err)
+	}
+}
+
+func TestSplitMenus(t *testing.T) {
+	// 0 touches 1-3, every row of three has 2 splits across, and 33 pairs
+	// of numbers are 3 apart: 60 splits
+	seen := make(map[string]bool)
+	for _, menu := range splitMenus {
+		if len(menu.Specs) > 25 {
+			t.Errorf("menu %s has %d options; Discord takes 25", menu.ID, len(menu.Specs))
+		}
+		for _, spec := range menu.Specs {
+			if _, err := parseRouletteBet(spec, 100); err != nil || seen[spec] {
+				t.Errorf("menu %s offers %s: %v, seen before %v", menu.ID, spec, err, seen[spec])
+			}
+			seen[spec] = true
+		}
+	}
+	if len(seen) != 60 {
+		t.Errorf("split menus offer %d splits; want 60", len(seen))
+	}
+
+	slip := &rouletteSlip{Amount: 100, Picks: map[string][]string{"split3": {"split:33-36"}}, SplitPage: 2}
+	rows := slipComponents("1", slip)
+	if len(rows) > 5 {
+		t.Errorf("slip has %d rows; a message takes 5", len(rows))
+	}
+	if bets := slip.bets(); len(bets) != 1 || bets[0].String() != "split:33-36" {
+		t.Errorf("bets = %v; want the split picked on page 3", bets)
+	}
+}
+
+func TestExpireRouletteSlips(t *testing.T) {
+	rouletteMutex.Lock()
+	rouletteSlips["1"] = &rouletteSlip{Amount: 100, Picks: map[string][]string{"outside": {"red"}}, Updated: time.Now().Add(-time.Hour)}
+	rouletteSlips["2"] = &rouletteSlip{Amount: 100, Picks: map[string][]string{}, Updated: time.Now()}
+	rouletteMutex.Unlock()
+
+	expireRouletteSlips(time.Minute)
+
+	rouletteMutex.Lock()
+	defer rouletteMutex.Unlock()
+	if _, ok := rouletteSlips["1"]; ok {
+		t.Error("slip left unspun for an hour is still open")
+	}
+	if _, ok := rouletteSlips["2"]; !ok {
+		t.Error("fresh slip was expired")
+	}
+	delete(rouletteSlips, "2")
+}
+
+func TestSpunSlips(t *testing.T) {
+	open := &rouletteSlip{Amount: 100, Picks: map[string][]string{"outside": {"red"}}, Spinning: true}
+	rouletteMutex.Lock()
+	rouletteSlips["1"] = open
+	rouletteMutex.Unlock()
+	defer func() {
+		rouletteMutex.Lock()
+		delete(rouletteSlips, "1")
+		delete(rouletteLastSlips, "1")
+		rouletteMutex.Unlock()
+	}()
+
+	// A spin that failed before settling leaves the bets where they were
+	reopenSlip(open)
+	rouletteMutex.Lock()
+	if rouletteSlips["1"] != open || open.Spinning || len(open.bets()) != 1 {
+		t.Errorf("slip after a failed spin = %+v; want it open with its bet", rouletteSlips["1"])
+	}
+	rouletteMutex.Unlock()
+
+	open.Spinning = true
+	spun := open.copy()
+	closeSpunSlip("1", open, spun)
+	rouletteMutex.Lock()
+	if _, ok := rouletteSlips["1"]; ok || rouletteLastSlips["1"] != spun {
+		t.Error("settled slip is still open or wasn't kept for Play Again")
+	}
+	// A slip posted while another one spun stays open
+	next := &rouletteSlip{Amount: 100, Picks: map[string][]string{}}
+	rouletteSlips["1"] = next
+	rouletteMutex.Unlock()
+	closeSpunSlip("1", open, spun)
+	rouletteMutex.Lock()
+	defer rouletteMutex.Unlock()
+	if rouletteSlips["1"] != next {
+		t.Error("closing a spun slip closed the newer one")
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// simStats accumulates the return of each simulated game as a multiple of
+// the stake: 0 for a loss, 1 for a push, 4 for a 3x slot win (stake + 3x).
+type simStats struct {
+	games int64
+	hits  int64 // games that returned more than the stake
+	sum   float64
+	sumSq float64
+	max   float64
+	wins  map[float64]int64 // return multiple of each win → count
+}
+
+func newSimStats() *simStats {
+	return &simStats{wins: make(map[float64]int64)}
+}
+
+// add records a game where bet was staked and outcome is the net result.
+func (st *simStats) add(bet, outcome Money) {
+	r := float64(bet+outcome) / float64(bet)
+	st.games++
+	st.sum += r
+	st.sumSq += r * r
+	if r > st.max {
+		st.max = r
+	}
+	if outcome > 0 {
+		st.hits++
+		st.wins[r]++
+	}
+}
+
+func (st *simStats) merge(o *simStats) {
+	st.games += o.games
+	st.hits += o.hits
+	st.sum += o.sum
+	st.sumSq += o.sumSq
+	st.max = math.Max(st.max, o.max)
+	for r, n := range o.wins {
+		st.wins[r] += n
+	}
+}
+
+func (st *simStats) rtp() float64     { return st.sum / float64(st.games) }
+func (st *simStats) hitRate() float64 { return float64(st.hits) / float64(st.games) }
+
+// variance is the variance of the per-game return, in stakes².
+func (st *simStats) variance() float64 {
+	mean := st.rtp()
+	return st.sumSq/float64(st.games) - mean*mean
+}
+
+// simulate runs n games split across workers, each with its own seeded RNG,
+// and merges their stats. play runs one game and records it.
+func simulate(n int64, workers int, seed int64, play func(rng RNG, st *simStats)) *simStats {
+	results := make([]*simStats, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		count := n / int64(workers)
+		if int64(w) < n%int64(workers) {
+			count++
+		}
+		wg.Add(1)
+		go func(w int, count int64) {
+			defer wg.Done()
+			rng, st := newSeededRNG(seed+int64(w)), newSimStats()
+			for k := int64(0); k < count; k++ {
+				play(rng, st)
+			}
+			results[w] = st
+		}(w, count)
+	}
+	wg.Wait()
+
+	total := newSimStats()
+	for _, st := range results {
+		total.merge(st)
+	}
+	return total
+}
+
+// playSlot spins the reels of m once; on a payline machine bet is the line
+// bet and free spins count toward the paid spin.
+func playSlot(m *SlotMachine, bet Money) func(RNG, *simStats) {
+	if m.linePays() {
+		return func(rng RNG, st *simStats) {
+			ss := m.PlaySession(rng, bet)
+			st.add(ss.Stake, ss.Outcome())
+		}
+	}
+	// Every cent paid into a jackpot pool is won back eventually, so the fee
+	// counts as returned on the spin that paid it rather than on a jackpot
+	return func(rng RNG, st *simStats) {
+		st.add(bet, m.Outcome(bet, m.Spin(rng))+m.JackpotFee(bet))
+	}
+}
+
+// playMines draws a size x size board and opens random tiles, cashing out
+// after cashout safe reveals like a player pressing the cashout button, at
+// the given RTP.
+func playMines(bet Money, size, numMines, cashout int, rtp *big.Rat) func(RNG, *simStats) {
+	return func(rng RNG, st *simStats) {
+		board := drawMinesBoard(rng, size, numMines)
+		free := make([]int, size*size)
+		for k := range free {
+			free[k] = k
+		}
+		for k := 0; k < cashout; k++ {
+			idx := rng.Intn(len(free))
+			pos := free[idx]
+			free = append(free[:idx], free[idx+1:]...)
+			if board[pos/size][pos%size] {
+				st.add(bet, -bet)
+				return
+			}
+		}
+		st.add(bet, minesProfit(bet, calculateMultiplier(cashout, numMines, size*size, rtp)))
+	}
+}
+
+// playRoulette spins the wheel once for bets.
+func playRoulette(bets []rouletteBet) func(RNG, *simStats) {
+	stake := rouletteStake(bets)
+	return func(rng RNG, st *simStats) {
+		st.add(stake, roulettePayout(bets, rng.Intn(roulettePockets))-stake)
+	}
+}
+
+// runSimulate implements `simulate [flags] slot|mines|roulette`.
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	games := fs.Int64("n", 1000000, "games to play per row")
+	workers := fs.Int("workers", runtime.NumCPU(), "goroutines to play on")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "RNG seed, for reproducible runs")
+	betFlag := fs.String("bet", "1", "stake per game; payouts truncate to the cent like the bot")
+	size := fs.Int("size", defaultMinesSize, "mines board side, 3 to 5")
+	numMines := fs.Int("mines", 0, "mines count to simulate (0 = every count the board takes)")
+	cashout := fs.Int("cashout", 0, "cash out after this many safe reveals (0 = every strategy)")
+	rtpFlag := fs.String("rtp", "", "mines RTP, e.g. 0.96 or 96% (default from the minesRTP env var)")
+	machine := fs.String("machine", defaultSlotMachine, "slot machine from the slotConfig env var's file (or the built-in slots.json)")
+	betList := fs.String("bets", "", "roulette bets played together, e.g. \"red;straight:17\" (default: each kind on its own)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: simulate [flags] slot | mines | roulette")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	bet, err := parseMoney(*betFlag)
+	if err != nil || bet <= 0 {
+		return fmt.Errorf("invalid bet %q", *betFlag)
+	}
+	if *games < 1 || *workers < 1 {
+		return fmt.Errorf("-n and -workers must be positive")
+	}
+	rtp, err := rtpFromFlag(*rtpFlag, "")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("seed %d, %d games per row on %d workers, bet %s\n\n", *seed, *games, *workers, bet)
+
+	switch fs.Arg(0) {
+	case "slot":
+		machines, err := loadSlotMachines(os.Getenv("slotConfig"))
+		if err != nil {
+			return err
+		}
+		m, ok := machines[*machine]
+		if !ok {
+			return fmt.Errorf("unknown slot machine %q", *machine)
+		}
+		st := simulate(*games, *workers, *seed, playSlot(m, bet))
+		fmt.Printf("RTP            %8.4f%%\n", st.rtp()*100)
+		fmt.Printf("Hit frequency  %8.4f%%\n", st.hitRate()*100)
+		fmt.Printf("Variance       %8.4f\n", st.variance())
+		fmt.Printf("Max win        %8.2fx\n\n", st.max)
+		printWinDistribution(st)
+		return nil
+
+	case "mines":
+		if *size < minMinesSize || *size > maxMinesSize {
+			return fmt.Errorf("-size must be between %d and %d", minMinesSize, maxMinesSize)
+		}
+		tiles := *size * *size
+		if *numMines < 0 || *numMines >= tiles {
+			return fmt.Errorf("-mines must be between 0 and %d", tiles-1)
+		}
+		fmt.Printf("%5s %7s %10s %9s %9s %10s\n", "mines", "cashout", "multiplier", "RTP", "hit", "variance")
+		for m := 1; m < tiles; m++ {
+			if *numMines != 0 && m != *numMines {
+				continue
+			}
+			for c := 1; c <= tiles-m; c++ {
+				if *cashout != 0 && c != *cashout {
+					continue
+				}
+				st := simulate(*games, *workers, *seed, playMines(bet, *size, m, c, rtp))
+				fmt.Printf("%5d %7d %9sx %8.4f%% %8.4f%% %10.4f\n",
+					m, c, formatMultiplier(calculateMultiplier(c, m, tiles, rtp)), st.rtp()*100, st.hitRate()*100, st.variance())
+			}
+		}
+		return nil
+
+	case "roulette":
+		slips := [][]string{{"straight:17"}, {"split:17-20"}, {"red"}, {"black"}, {"odd"}, {"even"}, {"dozen:1"}, {"column:1"}}
+		if *betList != "" {
+			slips = [][]string{strings.Split(*betList, ";")}
+		}
+		fmt.Printf("%-24s %9s %9s %9s %10s\n", "bets", "exact", "RTP", "hit", "variance")
+		for _, specs := range slips {
+			var bets []rouletteBet
+			for _, spec := range specs {
+				b, err := parseRouletteBet(spec, bet)
+				if err != nil {
+					return err
+				}
+				bets = append(bets, b)
+			}
+			exact, _ := rouletteRTP(bets).Float64()
+			st := simulate(*games, *workers, *seed, playRoulette(bets))
+			fmt.Printf("%-24s %8.4f%% %8.4f%% %8.4f%% %10.4f\n",
+				strings.Join(specs, ";"), exact*100, st.rtp()*100, st.hitRate()*100, st.variance())
+		}
+		return nil
+
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown game %q", fs.Arg(0))
+	}
+}
+
+// printWinDistribution lists every win size with how often it came up.
+func printWinDistribution(st *simStats) {
+	sizes := make([]float64, 0, len(st.wins))
+	for r := range st.wins {
+		sizes = append(sizes, r)
+	}
+	sort.Float64s(sizes)
+
+	fmt.Printf("%10s %12s %10s\n", "return", "games", "share")
+	for _, r := range sizes {
+		fmt.Printf("%9.2fx %12d %9.4f%%\n", r, st.wins[r], float64(st.wins[r])/float64(st.games)*100)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimulateSlot(t *testing.T) {
+	// the exact RTP over every reel combination
+	m := slotMachines[defaultSlotMachine]
+	var exact float64
+	for a := 0; a < 12; a++ {
+		for b := 0; b < 12; b++ {
+			for c := 0; c < 12; c++ {
+				exact += float64(100+m.Outcome(100, []int{a, b, c})+m.JackpotFee(100)) / 100
+			}
+		}
+	}
+	exact /= 12 * 12 * 12
+
+	st := simulate(400000, 4, 1, playSlot(m, 100))
+	if st.games != 400000 {
+		t.Fatalf("played %d games; want 400000", st.games)
+	}
+	if math.Abs(st.rtp()-exact) > 0.02 {
+		t.Errorf("simulated RTP %.4f; want about %.4f", st.rtp(), exact)
+	}
+	if st.max != 34.34 {
+		t.Errorf("max win %.2fx; want 34.34x (33.3x, the stake and the jackpot fee)", st.max)
+	}
+
+	again := simulate(400000, 4, 1, playSlot(m, 100))
+	if again.sum != st.sum || again.hits != st.hits {
+		t.Error("the same seed gave different results")
+	}
+}
+
+func TestSimulateMines(t *testing.T) {
+	// every cashout strategy returns the configured RTP on average
+	for _, tt := range []struct {
+		mines, cashout int
+		rtp            string
+	}{
+		{1, 1, "1"},
+		{3, 4, "1"},
+		{10, 2, "1"},
+		{3, 4, "0.96"},
+		{1, 15, "0.9"},
+	} {
+		rtp := mustRat(tt.rtp)
+		st := simulate(200000, 2, 1, playMines(10000, 4, tt.mines, tt.cashout, rtp))
+		want, _ := rtp.Float64()
+		if math.Abs(st.rtp()-want) > 0.03 {
+			t.Errorf("%d mines, cashout at %d: RTP %.4f; want about %s", tt.mines, tt.cashout, st.rtp(), tt.rtp)
+		}
+	}
+}
+
+func TestSimulateRoulette(t *testing.T) {
+	bets := []rouletteBet{{Kind: "black", Amount: 100}, {Kind: "straight", N: 17, Amount: 100}}
+	st := simulate(400000, 4, 1, playRoulette(bets))
+	if math.Abs(st.rtp()-36.0/37) > 0.02 {
+		t.Errorf("simulated RTP %.4f; want about %.4f", st.rtp(), 36.0/37)
+	}
+	if st.max != 19 {
+		t.Errorf("max win %.2fx; want 19x (a black 17 wins both bets)", st.max)
+	}
+}
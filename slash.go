@@ -1,10 +1,8 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
@@ -103,6 +101,91 @@ var commands = []*discordgo.ApplicationCommand{
 			},
 		},
 	},
+	{
+		Name:        "grant",
+		Description: "Admin: credit balance to a user from the house",
+		// Type:        discordgo.ChatApplicationCommand,
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextBotDM,
+			discordgo.InteractionContextPrivateChannel,
+		},
+
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "who gets the balance",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "amount",
+				Description: "the amount to credit",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "fairness",
+		Description: "Show or rotate your provably fair seeds",
+		// Type:        discordgo.ChatApplicationCommand,
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextBotDM,
+			discordgo.InteractionContextPrivateChannel,
+		},
+
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "action",
+				Description: "show your seeds, or rotate to reveal the server seed",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "show", Value: "show"},
+					{Name: "rotate", Value: "rotate"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "client_seed",
+				Description: "your own client seed for the new pair",
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:        "verify",
+		Description: "Recompute a past game from its revealed seeds",
+		// Type:        discordgo.ChatApplicationCommand,
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextBotDM,
+			discordgo.InteractionContextPrivateChannel,
+		},
+
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "game_id",
+				Description: "the game number shown when it ended",
+				Required:    true,
+			},
+		},
+	},
 	{
 		Name:        "mines",
 		Description: "Play Mines game",
@@ -127,14 +210,191 @@ var commands = []*discordgo.ApplicationCommand{
 			{
 				Type:        discordgo.ApplicationCommandOptionInteger,
 				Name:        "number_of_mines",
-				Description: "Number of mines on the board (1-15)",
+				Description: "Number of mines on the board (1-15 on 4x4, up to 8 on 3x3 or 24 on 5x5)",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "board_size",
+				Description: "Board size (default 4x4)",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "3x3", Value: 3},
+					{Name: "4x4", Value: 4},
+					{Name: "5x5", Value: 5},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "auto",
+				Description: "Reveal this many random tiles at once and cash out if they're all gems",
+				MinValue:    &minAutoTiles,
+			},
+		},
+	},
+	{
+		Name:        "blackjack",
+		Description: "Play blackjack against the dealer",
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextBotDM,
+			discordgo.InteractionContextPrivateChannel,
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "bet_amount",
+				Description: "Amount to bet",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "roulette",
+		Description: "Play single-zero roulette",
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextBotDM,
+			discordgo.InteractionContextPrivateChannel,
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "bet_amount",
+				Description: "Amount to bet on each pick",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "split",
+				Description: "Also bet on two neighbouring numbers, e.g. 17-20",
+			},
+		},
+	},
+	{
+		Name:        "dice",
+		Description: "Roll over or under a target of your choice",
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextBotDM,
+			discordgo.InteractionContextPrivateChannel,
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "bet_amount",
+				Description: "Amount to bet",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "target",
+				Description: "The roll, from 0.00 to 99.99, has to land over or under this",
+				Required:    true,
+				MinValue:    &minDiceOption,
+				MaxValue:    98.99,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "direction",
+				Description: "Win rolling over or under the target",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "over", Value: "over"},
+					{Name: "under", Value: "under"},
+				},
+			},
+		},
+	},
+	{
+		Name:        "limbo",
+		Description: "Pick a multiplier and win it if the draw reaches it",
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextBotDM,
+			discordgo.InteractionContextPrivateChannel,
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "bet_amount",
+				Description: "Amount to bet",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "target_multiplier",
+				Description: "The multiplier to win, e.g. 2 for 2.00x",
+				Required:    true,
+				MinValue:    &minLimboOption,
+				MaxValue:    10000,
+			},
+		},
+	},
+	{
+		Name:        "coinflip",
+		Description: "Challenge someone to a coinflip for a stake each",
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextPrivateChannel,
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "who to challenge",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "amount",
+				Description: "what each of you stakes",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "crash",
+		Description: "Ride this channel's crash round and cash out before it crashes",
+		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
+			discordgo.ApplicationIntegrationGuildInstall,
+			discordgo.ApplicationIntegrationUserInstall,
+		},
+		Contexts: &[]discordgo.InteractionContextType{
+			discordgo.InteractionContextGuild,
+			discordgo.InteractionContextPrivateChannel,
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "bet_amount",
+				Description: "Amount to bet",
 				Required:    true,
 			},
 		},
 	},
 	{
 		Name:        "slot",
-		Description: "Play classic slot machine, use /preload if having issues with animations",
+		Description: "Play a slot machine",
 		// Type:        discordgo.ChatApplicationCommand,
 		IntegrationTypes: &[]discordgo.ApplicationIntegrationType{
 			discordgo.ApplicationIntegrationGuildInstall,
@@ -153,6 +413,38 @@ var commands = []*discordgo.ApplicationCommand{
 				Description: "Amount to bet",
 				Required:    true,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "machine",
+				Description: "Slot machine to play (default classic)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "autospin",
+				Description: fmt.Sprintf("Play this many spins in a row (up to %d)", maxAutoSpins),
+				Required:    false,
+				MinValue:    &minAutoSpins,
+				MaxValue:    maxAutoSpins,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "stop_on_win",
+				Description: "Stop autospin after any win",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "loss_limit",
+				Description: "Stop autospin once you are down this much",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionNumber,
+				Name:        "stop_below",
+				Description: "Stop autospin once your balance drops below this",
+				Required:    false,
+			},
 		},
 	},
 }
@@ -345,32 +637,16 @@ func addUser(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		log.Printf("Warning: Could not determine user ID for interaction in guild %s", i.GuildID)
 		return
 	}
-	res, err := db.Exec(
-		`INSERT IGNORE INTO users(userid, username, balance, wins, losses, admin) VALUES(?, ?, ?, ?, ?, ?)`,
-		userID, Username, 1000, 0, 0, 0,
-	)
-
+	created, err := store.AddUser(userID, Username)
 	if err != nil {
 		// real DB error
 		log.Println("DB insert error:", err)
 		respondEphemeral(s, i, "⚠️ An error occurred while saving your data.", nil)
-		// s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		// 	Type: discordgo.InteractionResponseChannelMessageWithSource,
-		// 	Data: &discordgo.InteractionResponseData{
-		// 		Content: "⚠️ An error occurred while saving your data.",
-		// 	},
-		// })
 		return
 	}
 
-	rows, err := res.RowsAffected()
-	if err != nil {
-		// should rarely fail, but check anyway
-		log.Println("RowsAffected error:", err)
-	}
-
 	var msg string
-	if rows > 0 {
+	if created {
 		msg = "✅ User added successfully!"
 	} else {
 		msg = "ℹ️ User already exists."
@@ -382,7 +658,7 @@ func addUser(s *discordgo.Session, i *discordgo.InteractionCreate) {
 func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	var userID string
 	var msg string
-	var balance float64
+	var balance Money
 	var admin bool
 	var username string
 
@@ -413,105 +689,197 @@ func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	if banChk(s, i, userID) {
 		return // stop here if banned
 	}
-	err := db.QueryRow(
-		"SELECT balance, admin FROM users WHERE userid = ?",
-		userID,
-	).Scan(&balance, &admin)
-
-	if err != nil {
+	user, err := store.GetUser(userID)
+	if err == nil {
+		balance, admin = user.Balance, user.Admin
+	} else if err != ErrNotFound {
 		log.Println("DB error:", err)
 		msg = "⚠️ Database error, please try again later."
 	}
-	if err == sql.ErrNoRows {
+	if err == ErrNotFound {
 		msg = "❌ User is not registered yet, registring user..."
 		respondEphemeral(s, i, msg, nil)
 		addUser(s, i)
 	}
 	switch i.ApplicationCommandData().Name {
 	case "slot":
-		slot(s, i, i.ApplicationCommandData().Options[0].Value.(float64))
+		// Optional options are only sent when given, so look them up by name
+		opts := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+		for _, opt := range i.ApplicationCommandData().Options {
+			opts[opt.Name] = opt
+		}
+		betAmount, ok := moneyOption(s, i, opts["bet_amount"])
+		if !ok {
+			return
+		}
+		name := defaultSlotMachine
+		if opt, ok := opts["machine"]; ok {
+			name = strings.TrimSpace(opt.StringValue())
+		}
+		m, ok := slotMachines[name]
+		if !ok {
+			respondEphemeral(s, i, fmt.Sprintf("❌ Unknown machine! Try one of: %s", strings.Join(slotMachineNames(), ", ")), nil)
+			return
+		}
+		opt, ok := opts["autospin"]
+		if !ok || opt.IntValue() < 2 {
+			slot(s, i, m, betAmount)
+			return
+		}
+		a := autoSpin{Count: int(min(opt.IntValue(), maxAutoSpins))}
+		if opt, ok := opts["stop_on_win"]; ok {
+			a.StopOnWin = opt.BoolValue()
+		}
+		if opt, ok := opts["loss_limit"]; ok {
+			if a.LossLimit, ok = moneyOption(s, i, opt); !ok {
+				return
+			}
+		}
+		if opt, ok := opts["stop_below"]; ok {
+			if a.StopBelow, ok = moneyOption(s, i, opt); !ok {
+				return
+			}
+		}
+		autoSlot(s, i, m, betAmount, a)
 
 	case "mines":
-		betAmount := i.ApplicationCommandData().Options[0].FloatValue()
-		numMines := i.ApplicationCommandData().Options[1].IntValue()
+		// Optional options are only sent when given, so look them up by name
+		opts := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+		for _, opt := range i.ApplicationCommandData().Options {
+			opts[opt.Name] = opt
+		}
+		betAmount, ok := moneyOption(s, i, opts["bet_amount"])
+		if !ok {
+			return
+		}
+		numMines := opts["number_of_mines"].IntValue()
+		size, auto := defaultMinesSize, 0
+		if opt, ok := opts["board_size"]; ok {
+			size = int(opt.IntValue())
+		}
+		if opt, ok := opts["auto"]; ok {
+			auto = int(opt.IntValue())
+		}
+
+		startMinesGame(s, i, userID, betAmount, numMines, size, auto, balance)
+
+	case "blackjack":
+		betAmount, ok := moneyOption(s, i, i.ApplicationCommandData().Options[0])
+		if !ok {
+			return
+		}
+		startBlackjack(s, i, userID, betAmount, balance)
+
+	case "roulette":
+		// Optional options are only sent when given, so look them up by name
+		opts := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+		for _, opt := range i.ApplicationCommandData().Options {
+			opts[opt.Name] = opt
+		}
+		betAmount, ok := moneyOption(s, i, opts["bet_amount"])
+		if !ok {
+			return
+		}
+		split := ""
+		if opt, ok := opts["split"]; ok {
+			split = strings.TrimSpace(opt.StringValue())
+		}
+		startRoulette(s, i, userID, betAmount, split, balance)
+
+	case "dice":
+		betAmount, ok := moneyOption(s, i, i.ApplicationCommandData().Options[0])
+		if !ok {
+			return
+		}
+		target, ok := hundredthsOption(s, i, i.ApplicationCommandData().Options[1])
+		if !ok {
+			return
+		}
+		over := i.ApplicationCommandData().Options[2].StringValue() == "over"
+		playDice(s, i, userID, betAmount, target, over, balance)
+
+	case "limbo":
+		betAmount, ok := moneyOption(s, i, i.ApplicationCommandData().Options[0])
+		if !ok {
+			return
+		}
+		target, ok := hundredthsOption(s, i, i.ApplicationCommandData().Options[1])
+		if !ok {
+			return
+		}
+		playLimbo(s, i, userID, betAmount, target, balance)
+
+	case "coinflip":
+		amount, ok := moneyOption(s, i, i.ApplicationCommandData().Options[1])
+		if !ok {
+			return
+		}
+		opponentID := strings.Trim(i.ApplicationCommandData().Options[0].Value.(string), "<@!>")
+		startCoinflip(s, i, userID, opponentID, amount, balance)
 
-		startMinesGame(s, i, userID, betAmount, numMines, balance)
+	case "crash":
+		betAmount, ok := moneyOption(s, i, i.ApplicationCommandData().Options[0])
+		if !ok {
+			return
+		}
+		joinCrash(s, i, userID, betAmount, balance)
 
 	case "transfer-balance":
-		var RxBlns float64
-		var Rxusername string
 		var msg string
-		amount := i.ApplicationCommandData().Options[1].FloatValue()
-
-		receiverID, _ := strconv.Atoi(strings.Trim(i.ApplicationCommandData().Options[0].Value.(string), "<@!>"))
-		senderId, _ := strconv.Atoi(userID)
-		err := db.QueryRow(
-			"SELECT username, balance FROM users WHERE userid = ?",
-			receiverID,
-		).Scan(&Rxusername, &RxBlns)
-		if err == sql.ErrNoRows {
+		amount, ok := moneyOption(s, i, i.ApplicationCommandData().Options[1])
+		if !ok {
+			return
+		}
+
+		receiverID := strings.Trim(i.ApplicationCommandData().Options[0].Value.(string), "<@!>")
+		receiver, err := store.GetUser(receiverID)
+		if err == ErrNotFound {
 			msg = "❌ Receiver is not registered."
-		} else if senderId == receiverID && !admin {
+		} else if userID == receiverID && !admin {
 			msg = "❌ You cannot transfer to yourself."
-		} else if (amount > balance || amount <= 0) && !admin {
+		} else if amount <= 0 {
+			msg = "❌ Amount must be greater than 0."
+		} else if amount > balance {
 			msg = "❌ insufficient funds"
 		} else if err != nil {
 			log.Println("DB error:", err)
 			msg = "⚠️ Database error, please try again later."
 		} else {
 			// Update balance in DB
-			_, errUpdate := db.Exec(
-				`UPDATE users
-				SET balance = CASE
-					WHEN userid = ? THEN balance - ?
-					WHEN userid = ? THEN balance + ?
-				END
-				WHERE userid IN (?, ?)`,
-				senderId, amount, receiverID, amount, senderId, receiverID,
-			)
+			RxBlns, errUpdate := store.Transfer(userID, username, receiverID, receiver.Username, amount)
 			if errUpdate != nil {
 				log.Println("DB update error:", errUpdate)
-				logTrans(senderId, username, receiverID, Rxusername, amount, errUpdate.Error())
+				if err := store.LogTransaction(userID, username, receiverID, receiver.Username, amount, errUpdate.Error()); err != nil {
+					log.Println("Error logging transaction:", err)
+				}
 				msg = fmt.Sprintf("⚠️ Failed to update balance: %s", errUpdate.Error())
-			} else {
-				// Get new balance
-				err = db.QueryRow(
-					"SELECT balance FROM users WHERE userid = ?",
-					receiverID,
-				).Scan(&RxBlns)
-				if err != nil {
-					log.Println("DB error after update:", err)
-					msg = "⚠️ Error fetching updated balance."
-				} else {
-					msg = fmt.Sprintf("💰 %s Transferred %.2f, %s's balance is now %.2f", username, amount, Rxusername, RxBlns)
+				if errUpdate == ErrInsufficientBalance {
+					msg = "❌ insufficient funds"
 				}
-				logTrans(senderId, username, receiverID, Rxusername, amount, "Success")
+			} else {
+				msg = fmt.Sprintf("💰 %s Transferred %s, %s's balance is now %s", username, amount, receiver.Username, RxBlns)
 			}
 		}
 		sendNewMessage(s, i, msg, nil)
 
 	case "check-balance":
-		var msg, menName string
-		var menBalance float64
+		var msg string
 		if len(i.ApplicationCommandData().Options) > 0 {
 			// If a user is mentioned, get their ID
 			opt := i.ApplicationCommandData().Options[0]
 			menId := opt.UserValue(nil).ID // safer way to get the user ID
-			err := db.QueryRow(
-				"SELECT username, balance FROM users WHERE userid = ?",
-				menId,
-			).Scan(&menName, &menBalance)
+			mentioned, err := store.GetUser(menId)
 
-			if err == sql.ErrNoRows {
+			if err == ErrNotFound {
 				msg = "❌ Mentioned User is not registered yet."
 			} else if err != nil {
 				log.Println("DB error:", err)
 				msg = "⚠️ Database error, please try again later."
 			} else {
-				msg = fmt.Sprintf("💰 %s's balance is %.2f", menName, menBalance)
+				msg = fmt.Sprintf("💰 %s's balance is %s", mentioned.Username, mentioned.Balance)
 			}
 		} else {
-			msg = fmt.Sprintf("💰 %s's balance is %.2f", username, balance)
+			msg = fmt.Sprintf("💰 %s's balance is %s", username, balance)
 		}
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -521,11 +889,66 @@ func interactionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		})
 
 	case "preload":
-
-		sendAnimatedEmojiGridBatched(s, i, 12, "Emoji Grids", 0x00ff00)
+		if displayMode == "image" {
+			respondEphemeral(s, i, "🖼️ Slots are drawn as images, there's nothing to preload.", nil)
+			return
+		}
+		sendAnimatedEmojiGridBatched(s, i, slotMachines[defaultSlotMachine].Assets(), "Emoji Grids", 0x00ff00)
 	case "daily":
-		HandleDailyCommand(s, i, db, userID)
+		HandleDailyCommand(s, i, store, userID)
+
+	case "fairness":
+		HandleFairnessCommand(s, i, store, userID)
+
+	case "verify":
+		HandleVerifyCommand(s, i, store, i.ApplicationCommandData().Options[0].IntValue())
+
+	case "grant":
+		if !adminChk(s, i, userID) {
+			return
+		}
+		amount, ok := moneyOption(s, i, i.ApplicationCommandData().Options[1])
+		if !ok {
+			return
+		}
+		if amount <= 0 {
+			respondEphemeral(s, i, "❌ Amount must be greater than 0!", nil)
+			return
+		}
+		target := i.ApplicationCommandData().Options[0].UserValue(nil)
+		newBalance, err := store.Grant(userID, target.ID, amount)
+		if err == ErrNotFound {
+			respondEphemeral(s, i, "❌ User is not registered.", nil)
+			return
+		} else if err != nil {
+			log.Println("DB error (grant):", err)
+			respondEphemeral(s, i, "⚠️ Database error, please try again later.", nil)
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("✅ Granted %s to <@%s>, balance is now %s", amount, target.ID, newBalance), nil)
 
 	}
 
 }
+
+// moneyOption reads a number option as Money (rounded to the cent), telling the
+// user and returning false if the value can't be used.
+func moneyOption(s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) (Money, bool) {
+	amount, err := moneyFromFloat(opt.FloatValue())
+	if err != nil {
+		respondEphemeral(s, i, "❌ Invalid amount!", nil)
+		return 0, false
+	}
+	return amount, true
+}
+
+// hundredthsOption reads a number option as a target in hundredths, telling
+// the user if it's not a valid one.
+func hundredthsOption(s *discordgo.Session, i *discordgo.InteractionCreate, opt *discordgo.ApplicationCommandInteractionDataOption) (hundredths, bool) {
+	target, err := hundredthsFromFloat(opt.FloatValue())
+	if err != nil {
+		respondEphemeral(s, i, "❌ Invalid target!", nil)
+		return 0, false
+	}
+	return target, true
+}
@@ -1,110 +1,40 @@
 package main
 
 import (
-	"context"
-	"database/sql"
 	"fmt"
 	"log"
-	"math"
-	"math/rand"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
-// Global emoji cache for multiple guilds
-var guildEmojiCache = make(map[string]map[string]string) // guildID -> emoji_name -> formatted_emoji
-var cacheInitialized = make(map[string]bool)             // guildID -> initialized
-var cacheMutex sync.Mutex
-
-// servers that have the gifs uploaded to them that the bot must join (you can use one if you have enough slots)
+// servers that have the gifs uploaded to them that the bot must join (you can use one if you have enough slots);
+// emojis uploaded to the application itself are found too
 var guildIDs = []string{"", ""}
 
-// Initialize emoji cache for a specific guild
-func initEmojiCache(s *discordgo.Session, guildID string) error {
-	guild, err := s.Guild(guildID)
-	if err != nil {
-		return fmt.Errorf("failed to get guild %s: %w", guildID, err)
-	}
-
-	// Initialize guild cache if it doesn't exist
-	if guildEmojiCache[guildID] == nil {
-		guildEmojiCache[guildID] = make(map[string]string)
-	}
-
-	// Clear existing cache for this guild
-	guildEmojiCache[guildID] = make(map[string]string)
-
-	// Populate cache with all guild emojis
-	for _, emoji := range guild.Emojis {
-		if emoji.Animated {
-			guildEmojiCache[guildID][emoji.Name] = fmt.Sprintf("<a:%s:%s>", emoji.Name, emoji.ID)
-		} else {
-			guildEmojiCache[guildID][emoji.Name] = fmt.Sprintf("<:%s:%s>", emoji.Name, emoji.ID)
-		}
-	}
-
-	fmt.Printf("Loaded %d emojis into cache for guild %s\n", len(guildEmojiCache[guildID]), guildID)
-	cacheInitialized[guildID] = true
-	return nil
-}
-
-// Helper function to get emoji from cache with fallback and auto-initialization
-// Tries multiple guilds in order until emoji is found
-func getEmoji(name string, fallback string, s *discordgo.Session, guildIDs []string) string {
-	cacheMutex.Lock()
-	defer cacheMutex.Unlock()
-
-	for _, guildID := range guildIDs {
-		// Initialize cache if not already done for this guild
-		if !cacheInitialized[guildID] {
-			err := initEmojiCache(s, guildID)
-			if err != nil {
-				fmt.Printf("Failed to initialize emoji cache for guild %s: %v\n", guildID, err)
-				continue // Try next guild
-			}
-		}
-
-		// Check if emoji exists in this guild
-		if guildCache, exists := guildEmojiCache[guildID]; exists {
-			if emoji, emojiExists := guildCache[name]; emojiExists {
-				return emoji
-			}
-		}
-	}
-
-	return fallback // Use fallback if emoji not found in any guild
-}
-
-// Build emoji grid with 3 different tile numbers
-func buildEmojiGrid(input1, input2, input3 string, s *discordgo.Session) string {
+// Build emoji grid with one 3x2 tile block per reel, e.g. "7F" or "loading"
+func buildEmojiGrid(s *discordgo.Session, tiles ...string) string {
 	rows := []string{}
 	for row := 0; row < 3; row++ {
-		rowEmojis := ""
-		// First pair (input1)
-		rowEmojis += getEmoji(fmt.Sprintf("%s_rounded_tile_%d_0", input1, row), ":question:", s, guildIDs)
-		rowEmojis += getEmoji(fmt.Sprintf("%s_rounded_tile_%d_1", input1, row), ":question:", s, guildIDs)
-		rowEmojis += " " // Space between pairs
-		// Second pair (input2)
-		rowEmojis += getEmoji(fmt.Sprintf("%s_rounded_tile_%d_0", input2, row), ":question:", s, guildIDs)
-		rowEmojis += getEmoji(fmt.Sprintf("%s_rounded_tile_%d_1", input2, row), ":question:", s, guildIDs)
-		rowEmojis += " " // Space between pairs
-		// Third pair (input3)
-		rowEmojis += getEmoji(fmt.Sprintf("%s_rounded_tile_%d_0", input3, row), ":question:", s, guildIDs)
-		rowEmojis += getEmoji(fmt.Sprintf("%s_rounded_tile_%d_1", input3, row), ":question:", s, guildIDs)
-		rows = append(rows, rowEmojis)
+		blocks := make([]string, len(tiles))
+		for k, tile := range tiles {
+			blocks[k] = getEmoji(tileEmojiName(tile, row, 0), ":question:", s) +
+				getEmoji(tileEmojiName(tile, row, 1), ":question:", s)
+		}
+		rows = append(rows, strings.Join(blocks, " ")) // Space between reels
 	}
 	return strings.Join(rows, "\n")
 }
 
-func buildEmbed(description string, color int, betAmount float64, payout *float64, balance float64) *discordgo.MessageEmbed {
+// buildEmbed shows a classic spin without its reels, which showReels adds;
+// won is 0 until the final reveal and pool, the progressive jackpot, is nil
+// on machines without one.
+func buildEmbed(color int, betAmount Money, won Money, pool *Money, balance Money) *discordgo.MessageEmbed {
 	fields := []*discordgo.MessageEmbedField{
 		{
 			Name:   "💰 Bet Amount",
-			Value:  fmt.Sprintf("`$%.2f`", betAmount),
+			Value:  fmt.Sprintf("`$%s`", betAmount),
 			Inline: true,
 		},
 	}
@@ -112,9 +42,8 @@ func buildEmbed(description string, color int, betAmount float64, payout *float6
 	// Add Status field only on final reveal
 	var status string
 	status = "`$0.00`"
-	if payout != nil && *payout > 0 {
-		winAmount := *payout * betAmount
-		status = fmt.Sprintf("`$%.2f`\n", winAmount)
+	if won > 0 {
+		status = fmt.Sprintf("`$%s`\n", won)
 	}
 
 	fields = append(fields, &discordgo.MessageEmbedField{
@@ -122,65 +51,66 @@ func buildEmbed(description string, color int, betAmount float64, payout *float6
 		Value:  status,
 		Inline: true,
 	})
+	if pool != nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "🏆 Jackpot",
+			Value:  fmt.Sprintf("`$%s`", *pool),
+			Inline: true,
+		})
+	}
 	fields = append(fields, &discordgo.MessageEmbedField{
-		Value:  fmt.Sprintf("👤 Balance `$%.2f`", balance),
+		Value:  fmt.Sprintf("👤 Balance `$%s`", balance),
 		Inline: false,
 	})
 
 	return &discordgo.MessageEmbed{
-		Title:       "🎰 Slot Machine 🎰",
-		Description: fmt.Sprintf("**%s**", description),
-		Color:       color,
-		Fields:      fields,
+		Title:  "🎰 Slot Machine 🎰",
+		Color:  color,
+		Fields: fields,
 		// Footer: &discordgo.MessageEmbedFooter{
 		// 	Text: fmt.Sprintf("👤 Played by %s", username),
 		// },
 	}
 }
 
+// showReels puts a reveal step into embed as an attached image, or as an
+// emoji grid in emoji mode or if the image can't be drawn. The returned files
+// go with the message edit.
+func showReels(s *discordgo.Session, embed *discordgo.MessageEmbed, tiles []string) []*discordgo.File {
+	if displayMode == "image" {
+		file, err := renderReels(tiles)
+		if err == nil {
+			embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + file.Name}
+			return []*discordgo.File{file}
+		}
+		log.Println("Error rendering reels:", err)
+	}
+	embed.Description = fmt.Sprintf("**%s**", buildEmojiGrid(s, tiles...))
+	return nil
+}
+
 func sendAnimatedEmojiGridBatched(
 	s *discordgo.Session,
 	i *discordgo.InteractionCreate, // use interaction instead of channelID
-	maxSymbol int,
+	assets []string,
 	embedTitle string,
 	embedColor int) {
-	cacheMutex.Lock()
-	defer cacheMutex.Unlock()
-
 	var allGrids []string
-
-	// Collect all grids from all guilds first
-	for _, guildID := range guildIDs {
-		if !cacheInitialized[guildID] {
-			err := initEmojiCache(s, guildID)
-			if err != nil {
-				fmt.Printf("Failed to initialize emoji cache for guild %s: %v\n", guildID, err)
-				continue
-			}
-		}
-
-		if guildCache, exists := guildEmojiCache[guildID]; exists {
-			for symbol := 0; symbol < maxSymbol; symbol++ {
-				rows := []string{}
-				for row := 0; row < 3; row++ {
-					rowEmojis := ""
-					for col := 0; col < 2; col++ {
-						emojiName := fmt.Sprintf("%d_rounded_tile_%d_%d", symbol, row, col)
-						if emoji, ok := guildCache[emojiName]; ok {
-							if strings.HasPrefix(emoji, "<a:") {
-								rowEmojis += emoji
-							}
-						}
-					}
-					if rowEmojis != "" {
-						rows = append(rows, rowEmojis)
-					}
-				}
-				if len(rows) > 0 {
-					grid := strings.Join(rows, "\n")
-					allGrids = append(allGrids, grid)
+	for _, asset := range assets {
+		rows := []string{}
+		for row := 0; row < 3; row++ {
+			rowEmojis := ""
+			for col := 0; col < 2; col++ {
+				if emoji, ok := lookupEmoji(s, tileEmojiName(asset, row, col)); ok && strings.HasPrefix(emoji, "<a:") {
+					rowEmojis += emoji
 				}
 			}
+			if rowEmojis != "" {
+				rows = append(rows, rowEmojis)
+			}
+		}
+		if len(rows) > 0 {
+			allGrids = append(allGrids, strings.Join(rows, "\n"))
 		}
 	}
 
@@ -250,10 +180,61 @@ func sendAnimatedEmojiGridBatched(
 	}
 }
 
-func slot(s *discordgo.Session, i *discordgo.InteractionCreate, betAmount float64) {
-	// Send a deferred response immediately
+// paidSpin is one settled paid spin.
+type paidSpin struct {
+	GameID  int64
+	Bet     Money        // the whole stake
+	Outcome Money        // net, jackpot included
+	Line    []int        // classic machines
+	Session *SlotSession // payline machines
+	Jackpot *JackpotDraw // nil on machines without one
+}
+
+// settleSpin draws a spin of m from the user's provably-fair seed and settles
+// it guarded by balance >= stake, returning ErrInsufficientBalance otherwise.
+func settleSpin(m *SlotMachine, userID string, bet Money) (*paidSpin, error) {
+	seed, err := drawSeed(store, userID)
+	if err != nil {
+		return nil, err
+	}
+	r := GameResult{
+		UserID:   userID,
+		GameType: "slot",
+		OneShot:  true,
+		SeedID:   seed.ID,
+		Nonce:    seed.Nonce,
+		Params:   machineParam(m),
+	}
+	spin := &paidSpin{}
+	if m.linePays() {
+		spin.Session = m.PlaySession(newFairStream(seed), bet)
+		r.Bet, r.Outcome = spin.Session.Stake, spin.Session.Outcome()
+	} else {
+		spin.Line = m.Spin(newFairStream(seed))
+		r.Bet, r.Outcome = bet, m.Outcome(bet, spin.Line)
+		// Feed the progressive pool; a jackpot line claims it when settled
+		if m.jackpot >= 0 {
+			r.Jackpot = &JackpotDraw{Machine: m.Name, Fee: m.JackpotFee(bet), Win: m.JackpotLine(spin.Line)}
+		}
+	}
+
+	spin.GameID, err = store.SettleGame(r)
+	if err != nil {
+		return nil, err
+	}
+	spin.Bet, spin.Jackpot = r.Bet, r.Jackpot
+	spin.Outcome = r.Outcome
+	if r.Jackpot != nil {
+		spin.Outcome += r.Jackpot.Paid
+	}
+	return spin, nil
+}
 
-	var userBalance float64
+// beginSlot runs the checks every spin starts with, claims the user's one
+// active slot game and defers the response. When it reports false the
+// interaction has been answered and nothing is held; otherwise the caller
+// must endSlot.
+func beginSlot(s *discordgo.Session, i *discordgo.InteractionCreate, m *SlotMachine, betAmount Money) (string, Money, bool) {
 	var userID string
 
 	// Safely get user ID
@@ -263,151 +244,97 @@ func slot(s *discordgo.Session, i *discordgo.InteractionCreate, betAmount float6
 		userID = i.User.ID
 	} else {
 		log.Printf("Warning: Could not determine user ID for interaction in guild %s", i.GuildID)
-		return
+		return "", 0, false
 	}
 
 	if banChk(s, i, userID) {
-		return
-	}
-
-	// Use context with timeout for DB operations
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	var gameType sql.NullString
-	err := db.QueryRowContext(ctx, "SELECT type FROM active_games WHERE userid = ? AND type = ? LIMIT 1", userID, "slot").Scan(&gameType)
-	if err == sql.ErrNoRows {
-		// No active game
-		if err := saveActiveslotToDB(userID); err != nil {
-			log.Println("Error saving game:", err)
-		}
-		// return
-	} else if err != nil {
-		log.Println("query error:", err)
-		return
-	}
-
-	if gameType.Valid && gameType.String == "slot" {
-		respondEphemeral(s, i, "❌ You already have an active slot game", nil)
-		return
+		return "", 0, false
 	}
 
-	err = db.QueryRowContext(ctx, "SELECT balance FROM users WHERE userid = ?", userID).Scan(&userBalance)
-	if err == sql.ErrNoRows {
+	user, err := store.GetUser(userID)
+	if err == ErrNotFound {
 		respondEphemeral(s, i, "❌ You're not registered! Registering user...", nil)
 		addUser(s, i)
-		return
+		return "", 0, false
 	} else if err != nil {
 		log.Printf("DB error checking balance for user %s: %v", userID, err)
 		respondEphemeral(s, i, "❌ Database error!", nil)
-		return
+		return "", 0, false
 	}
 
-	// Validation checks
-	if userBalance < betAmount {
+	// Validation checks (payline machines take the bet on every line)
+	if user.Balance < m.Stake(betAmount) {
 		respondEphemeral(s, i, "❌ Insufficient balance!", nil)
-		return
+		return "", 0, false
 	}
 	if betAmount <= 0 {
 		respondEphemeral(s, i, "❌ Invalid amount!", nil)
-		return
+		return "", 0, false
+	}
+
+	// Only one spin at a time per user
+	if err := store.StartActiveSlot(userID); err == ErrGameActive {
+		respondEphemeral(s, i, "❌ You already have an active slot game", nil)
+		return "", 0, false
+	} else if err != nil {
+		log.Println("Error saving game:", err)
+		respondEphemeral(s, i, "❌ Database error!", nil)
+		return "", 0, false
 	}
+
+	// Send a deferred response before the first draw
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 	})
 	if err != nil {
 		log.Printf("failed to defer response: %v", err)
-		return
-	}
-	// Generate results (moved earlier to reduce time between DB operations)
-	result := make([]int, 3)
-	for j := 0; j < 3; j++ {
-		result[j] = rand.Intn(12)
-	}
-
-	// Calculate payout
-	counts := make(map[int]int)
-	for _, v := range result {
-		counts[v]++
-	}
-
-	var payout float64 = 0
-	for sym, c := range counts {
-		if sym == 7 { // skip ❌
-			continue
-		}
-		if c == 3 {
-			if sym == 0 { // 7️⃣ jackpot
-				payout = 77.7
-			} else {
-				payout = 33.3
-			}
-			break
-		} else if c == 2 {
-			if sym == 0 { // two 7️⃣
-				payout = 7.7
-			} else {
-				payout = 3
-			}
-			break
-		}
-	}
-
-	// Calculate win amount before transaction
-	var winAmount float64
-	if payout > 0 {
-		winAmount = payout * betAmount
-	} else {
-		winAmount = -betAmount
-	}
-
-	// Start transaction with context
-	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-	if err != nil {
-		log.Printf("DB error starting transaction for user %s: %v", userID, err)
-		respondEphemeral(s, i, "❌ Database error!", nil)
-		return
+		endSlot(userID)
+		return "", 0, false
 	}
-	defer tx.Rollback()
-	go logGame(userID, "slot", betAmount, winAmount)
+	return userID, user.Balance, true
+}
 
-	// Use a single UPDATE query to handle balance, wins, and losses
-	var query string
-	if payout > 0 {
-		query = `UPDATE users 
-		         SET balance = ROUND(balance + ?, 2),
-		             wins = ROUND(wins + ?, 2)
-		         WHERE userid = ? AND balance >= ?`
-	} else {
-		query = `UPDATE users 
-		         SET balance = ROUND(balance + ?, 2),
-		             losses = ROUND(losses + ?, 2)
-		         WHERE userid = ? AND balance >= ?`
+// endSlot releases the active slot game beginSlot claimed.
+func endSlot(userID string) {
+	if err := store.DeleteActiveGame(userID, "slot"); err != nil {
+		log.Printf("DB error deleting game for user %s: %v", userID, err)
 	}
+}
 
-	result_exec, err := tx.ExecContext(ctx, query, winAmount, math.Abs(winAmount), userID, betAmount)
-	if err != nil {
-		log.Printf("DB error updating user %s: %v", userID, err)
-		respondEphemeral(s, i, "❌ Database error!", nil)
+// slot plays one spin of m. betAmount is the whole bet on classic machines
+// and the bet per line on payline machines.
+func slot(s *discordgo.Session, i *discordgo.InteractionCreate, m *SlotMachine, betAmount Money) {
+	userID, userBalance, ok := beginSlot(s, i, m, betAmount)
+	if !ok {
 		return
 	}
+	defer endSlot(userID)
 
-	// Check if the update affected any rows (handles race condition)
-	rowsAffected, err := result_exec.RowsAffected()
-	if err != nil || rowsAffected == 0 {
+	// Draw and settle before animating (handles race condition)
+	spin, err := settleSpin(m, userID, betAmount)
+	if err == ErrInsufficientBalance {
 		log.Printf("Race condition detected for user %s - insufficient balance", userID)
 		respondEphemeral(s, i, "❌ Insufficient balance or concurrent transaction!", nil)
 		return
-	}
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		log.Printf("DB error committing transaction for user %s: %v", userID, err)
+	} else if err != nil {
+		log.Printf("DB error updating user %s: %v", userID, err)
 		respondEphemeral(s, i, "❌ Database error!", nil)
 		return
 	}
+	if m.linePays() {
+		playLineSlot(s, i, m, spin, userID, userBalance)
+		return
+	}
+	result, winAmount, jackpot, gameID := spin.Line, spin.Outcome, spin.Jackpot, spin.GameID
 
 	// Update final balance
+	var pool, finalPool *Money // the pool while the reels spin and once they stop
+	if jackpot != nil {
+		spinning := jackpot.Pool + jackpot.Paid
+		pool, finalPool = &spinning, &jackpot.Pool
+	}
 	userBalance += winAmount
+	won := max(winAmount, 0)
 
 	color := 0xFFFF00 // yellow
 
@@ -417,7 +344,7 @@ func slot(s *discordgo.Session, i *discordgo.InteractionCreate, betAmount float6
 	playAgainButton := &discordgo.Button{
 		Label:    "Play Again 🎰",
 		Style:    discordgo.PrimaryButton,
-		CustomID: fmt.Sprintf("playagainSlot_%v", betAmount),
+		CustomID: fmt.Sprintf("playagainSlot_%s_%s", betAmount, m.Name),
 		Disabled: true,
 	}
 	row := discordgo.ActionsRow{
@@ -426,7 +353,9 @@ func slot(s *discordgo.Session, i *discordgo.InteractionCreate, betAmount float6
 
 	// Animation sequence (non-blocking)
 	editWithRetry := func(edit *discordgo.WebhookEdit) {
+		edit.Attachments = replaceAttachments(edit.Files)
 		for attempt := 0; attempt < 3; attempt++ {
+			rewindFiles(edit.Files)
 			_, err := s.InteractionResponseEdit(i.Interaction, edit)
 			if err == nil {
 				return
@@ -441,36 +370,32 @@ func slot(s *discordgo.Session, i *discordgo.InteractionCreate, betAmount float6
 
 	// Step 1: Initial loading
 	content := fmt.Sprintf("> **%s's Game**", username)
+	embed := buildEmbed(color, betAmount, 0, pool, userBalance-winAmount)
 	editWithRetry(&discordgo.WebhookEdit{
-		Content: &content,
-		Embeds: &[]*discordgo.MessageEmbed{
-			buildEmbed(buildEmojiGrid("loading", "loading", "loading", s), color, betAmount, nil, userBalance-winAmount),
-		},
-		Components: &[]discordgo.MessageComponent{row},
-	})
-
-	// Step 2: First slot
-	time.Sleep(time.Duration(rand.Intn(1000)+500) * time.Millisecond)
-
-	editWithRetry(&discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{
-			buildEmbed(buildEmojiGrid(strconv.Itoa(result[0]), "loading", "loading", s), color, betAmount, nil, userBalance-winAmount),
-		},
+		Content:    &content,
+		Files:      showReels(s, embed, m.reelTiles(result, 0)),
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
 		Components: &[]discordgo.MessageComponent{row},
 	})
 
-	// Step 3: Second slot
-	time.Sleep(time.Duration(rand.Intn(500)+250) * time.Millisecond)
+	// Steps 2-3: Reveal every reel but the last, one at a time
+	for shown := 1; shown < len(result); shown++ {
+		if shown == 1 {
+			time.Sleep(time.Duration(gameRNG.Intn(1000)+500) * time.Millisecond)
+		} else {
+			time.Sleep(time.Duration(gameRNG.Intn(500)+250) * time.Millisecond)
+		}
 
-	editWithRetry(&discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{
-			buildEmbed(buildEmojiGrid(fmt.Sprintf("%dF", result[0]), strconv.Itoa(result[1]), "loading", s), color, betAmount, nil, userBalance-winAmount),
-		},
-		Components: &[]discordgo.MessageComponent{row},
-	})
+		embed := buildEmbed(color, betAmount, 0, pool, userBalance-winAmount)
+		editWithRetry(&discordgo.WebhookEdit{
+			Files:      showReels(s, embed, m.reelTiles(result, shown)),
+			Embeds:     &[]*discordgo.MessageEmbed{embed},
+			Components: &[]discordgo.MessageComponent{row},
+		})
+	}
 
 	// Step 4: Final result with enabled button
-	if payout > 0 {
+	if winAmount > 0 || jackpot != nil && jackpot.Win {
 		color = 0x00FF00 // green
 	} else {
 		color = 0xFF0000 // red
@@ -479,33 +404,32 @@ func slot(s *discordgo.Session, i *discordgo.InteractionCreate, betAmount float6
 	enabledButton := &discordgo.Button{
 		Label:    "Play Again 🎰",
 		Style:    discordgo.PrimaryButton,
-		CustomID: fmt.Sprintf("playagainSlot_%v", betAmount),
+		CustomID: fmt.Sprintf("playagainSlot_%s_%s", betAmount, m.Name),
 		Disabled: false,
 	}
 	enabledRow := discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{enabledButton},
 	}
 
-	time.Sleep(time.Duration(rand.Intn(1000)+500) * time.Millisecond)
+	time.Sleep(time.Duration(gameRNG.Intn(1000)+500) * time.Millisecond)
+	embed = buildEmbed(color, betAmount, won, finalPool, userBalance)
 	editWithRetry(&discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{
-			buildEmbed(buildEmojiGrid(fmt.Sprintf("%dF", result[0]), fmt.Sprintf("%dF", result[1]), strconv.Itoa(result[2]), s), color, betAmount, &payout, userBalance),
-		},
+		Files:      showReels(s, embed, m.reelTiles(result, len(result))),
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
 		Components: &[]discordgo.MessageComponent{enabledRow},
 	})
 
 	// Step 5: Final state
 	time.Sleep(100 * time.Millisecond)
+	final := buildEmbed(color, betAmount, won, finalPool, userBalance)
+	files := showReels(s, final, m.reelTiles(result, len(result)+1))
+	final.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("🎲 Game #%d · /verify %d", gameID, gameID)}
+	if jackpot != nil && jackpot.Win {
+		final.Title = "🏆 JACKPOT 🏆"
+	}
 	editWithRetry(&discordgo.WebhookEdit{
-		Embeds: &[]*discordgo.MessageEmbed{
-			buildEmbed(buildEmojiGrid(fmt.Sprintf("%dF", result[0]), fmt.Sprintf("%dF", result[1]), fmt.Sprintf("%dF", result[2]), s), color, betAmount, &payout, userBalance),
-		},
+		Files:      files,
+		Embeds:     &[]*discordgo.MessageEmbed{final},
 		Components: &[]discordgo.MessageComponent{enabledRow},
 	})
-	// Delete active game
-	if err := deleteActiveGameFromDB(userID, "slot"); err != nil {
-		log.Printf("DB error deleting game for user %s: %v", userID, err)
-		return
-	}
-
 }
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// store is the storage backend every handler goes through.
+var store Store
+
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrGameActive          = errors.New("game already active")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrAlreadyClaimed      = errors.New("already claimed today")
+	ErrGameChanged         = errors.New("game state changed")
+	ErrInvalidAmount       = errors.New("amount must be greater than 0")
+)
+
+// startingBalance is what a newly registered user gets.
+const startingBalance Money = 1000 * centsPerUnit
+
+// User is a row of the users table.
+type User struct {
+	UserID   string
+	Username string
+	Balance  Money
+	Wins     Money
+	Losses   Money
+	Admin    bool
+	Banned   bool
+}
+
+// GameResult describes a finished round to be settled against the user's balance.
+type GameResult struct {
+	UserID   string
+	GameType string
+	Bet      Money
+	Outcome  Money // net balance change, negative for a loss
+
+	// OneShot only settles if the balance still covers Bet, for games that
+	// take the bet and pay out in the same statement (slot).
+	OneShot bool
+	// CloseActive deletes the user's active_games row of GameType in the same transaction.
+	CloseActive bool
+	// Escrowed settles a game whose stake StartActiveGame already took: the
+	// balance gets Bet+Outcome back and the active row is closed. If the row is
+	// already gone the game was settled elsewhere and ErrNotFound is returned.
+	Escrowed bool
+	// Version is the active row version the game was loaded at. An escrowed
+	// game saved past it since is left alone and ErrGameChanged returned.
+	Version int64
+
+	// SeedID and Nonce identify the provably-fair draw, Params what /verify
+	// needs to recompute it (e.g. "mines=3").
+	SeedID int64
+	Nonce  int64
+	Params string
+	// RoundID links the bets of one multiplayer round, a crash round or a
+	// coinflip duel; 0 for none.
+	RoundID int64
+
+	// Jackpot, if set, feeds a progressive jackpot pool in the same transaction.
+	Jackpot *JackpotDraw
+}
+
+// JackpotDraw is a spin's share in a progressive jackpot pool.
+type JackpotDraw struct {
+	Machine string // the pool belongs to this slot machine
+	Fee     Money  // part of the bet paid into the pool, out of the house's side
+	Win     bool   // claim the whole pool, Fee included, on top of Outcome
+
+	// Set by SettleGame: what the pool paid and what is left in it.
+	Paid Money
+	Pool Money
+}
+
+// GameRecord is a row of the games table.
+type GameRecord struct {
+	ID       int64
+	UserID   string
+	GameType string
+	Amount   Money
+	Outcome  Money
+	SeedID   int64 // 0 for games played before provably-fair seeds
+	Nonce    int64
+	Params   string
+	RoundID  int64
+}
+
+// CrashRound is a row of the crash_rounds table. Its server seed stays secret
+// until the round is settled; players joining only see the hash.
+type CrashRound struct {
+	ID             int64
+	ChannelID      string
+	ServerSeed     string
+	ServerSeedHash string
+	CrashPoint     int64 // in hundredths of a multiplier, 0 until settled
+	Settled        bool
+}
+
+// Duel is a row of the coinflip_duels table: a challenge and, once accepted,
+// its flip. The server seed stays secret until the coin is flipped.
+type Duel struct {
+	ID             int64
+	ChallengerID   string
+	ChallengerName string
+	OpponentID     string
+	OpponentName   string
+	Amount         Money // each side's stake
+	ServerSeed     string
+	ServerSeedHash string
+	Status         string // "open", "accepted", "declined" or "expired"
+}
+
+// DailyClaim is a row of the daily_rewards table.
+type DailyClaim struct {
+	UserID       string
+	ClaimDate    string // YYYY-MM-DD
+	Streak       int
+	RewardAmount Money
+}
+
+// Store covers users, active games, game history, transactions and daily rewards.
+// Every method that changes a balance also appends the matching ledger postings
+// in the same transaction.
+type Store interface {
+	// AddUser registers a user with the starting balance, reporting false if they already exist.
+	AddUser(userID, username string) (bool, error)
+	// GetUser returns ErrNotFound for unregistered users.
+	GetUser(userID string) (*User, error)
+
+	// Transfer moves amount between two users, logs it and returns the receiver's new balance.
+	// It returns ErrInvalidAmount unless amount is positive, or ErrInsufficientBalance if the sender's
+	// balance doesn't cover amount, without changing anything.
+	Transfer(senderID, senderName, receiverID, receiverName string, amount Money) (Money, error)
+	LogTransaction(senderID, senderName, receiverID, receiverName string, amount Money, status string) error
+
+	// SaveActiveGame updates the user's active mines game and bumps its
+	// Version, or returns ErrGameChanged if the stored game isn't at
+	// game.Version any more (or is gone).
+	SaveActiveGame(game *MinesGame) error
+	// StartActiveGame takes the stake into escrow and saves the new game atomically.
+	// It returns ErrInsufficientBalance or ErrGameActive without changing anything.
+	StartActiveGame(game *MinesGame) error
+	// GetActiveGame returns ErrNotFound if the user has no active game of that type.
+	GetActiveGame(userID, gameType string) (*MinesGame, error)
+	// StaleActiveGames returns the games of gameType nobody has touched for idle.
+	StaleActiveGames(gameType string, idle time.Duration) ([]*MinesGame, error)
+	// StartBlackjack takes the opening bet into escrow and saves the new round
+	// atomically, returning ErrInsufficientBalance or ErrGameActive without
+	// changing anything.
+	StartBlackjack(game *BlackjackGame) error
+	// SaveBlackjack takes raise more into escrow (a double, split or insurance)
+	// and saves the round, bumping its Version. It returns ErrInsufficientBalance
+	// or ErrGameChanged, like SaveActiveGame, without changing anything.
+	SaveBlackjack(game *BlackjackGame, raise Money) error
+	// GetBlackjack returns ErrNotFound if the user has no round running.
+	GetBlackjack(userID string) (*BlackjackGame, error)
+	// StaleBlackjackGames returns the rounds nobody has touched for idle.
+	StaleBlackjackGames(idle time.Duration) ([]*BlackjackGame, error)
+	// StartCrashBet takes a crash bet into escrow, held in an active_games row
+	// until its round settles. It returns ErrInsufficientBalance, or
+	// ErrGameActive if the user already rides a round, without changing anything.
+	StartCrashBet(userID, username string, bet Money) error
+	// StartCrashRound saves a round about to take bets along with its first
+	// bet, taken into escrow in the same transaction, and sets its ID. It fails
+	// like StartCrashBet, saving nothing.
+	StartCrashRound(round *CrashRound, userID, username string, bet Money) error
+	// SettleCrashRound records the crash point and settles every bet of the
+	// round in one transaction, returning the games row ids in order. A bet the
+	// reaper got to first is skipped with id 0. ErrNotFound if the round is
+	// unknown or was settled already, ErrGameChanged without settling any of
+	// it if a bet was saved past its Version.
+	SettleCrashRound(round *CrashRound, results []GameResult) ([]int64, error)
+	// GetCrashRound returns ErrNotFound for unknown ids.
+	GetCrashRound(id int64) (*CrashRound, error)
+	// StartDuel takes the challenger's stake into escrow and saves the open
+	// challenge, setting its ID. It returns ErrInsufficientBalance, or
+	// ErrGameActive if the challenger already has a coinflip going, without
+	// changing anything.
+	StartDuel(d *Duel) error
+	// AcceptDuel takes the opponent's stake into escrow and settles results,
+	// both sides of the flip, in one transaction, returning their games row
+	// ids. ErrNotFound if the challenge isn't open any more, and
+	// ErrInsufficientBalance or ErrGameActive for the opponent.
+	AcceptDuel(d *Duel, results []GameResult) ([]int64, error)
+	// CloseDuel ends an open challenge as "declined" or "expired" and refunds
+	// the challenger, returning ErrNotFound if it isn't open any more.
+	CloseDuel(id int64, status string) (*Duel, error)
+	// GetDuel returns ErrNotFound for unknown ids.
+	GetDuel(id int64) (*Duel, error)
+	// StaleDuels returns the challenges left open for longer than idle.
+	StaleDuels(idle time.Duration) ([]*Duel, error)
+	// StartActiveSlot marks a slot spin as running, returning ErrGameActive if one already is.
+	StartActiveSlot(userID string) error
+	// TouchActiveSlot keeps a long run of spins from looking like a guard left
+	// behind by a crash.
+	TouchActiveSlot(userID string) error
+	DeleteActiveGame(userID, gameType string) error
+
+	// SettleGame applies a GameResult to balance, wins/losses and logs it to games
+	// atomically, returning the games row id, or ErrNotFound for an unknown user.
+	// OneShot results return ErrInsufficientBalance if the balance no longer covers the bet.
+	// A jackpot win pays the pool and empties it in the same transaction, so
+	// only one of two simultaneous winners gets it.
+	SettleGame(r GameResult) (int64, error)
+	LogGame(userID, gameType string, amount, outcome Money) error
+	// GetGame returns ErrNotFound for unknown ids.
+	GetGame(id int64) (*GameRecord, error)
+	// JackpotPool returns a slot machine's progressive pool, 0 if nothing was paid in yet.
+	JackpotPool(machine string) (Money, error)
+
+	// UseSeed returns the user's active seed pair with Nonce set to the nonce
+	// this game uses, and advances the stored nonce. ErrNotFound if they have none.
+	UseSeed(userID string) (*FairSeed, error)
+	// CurrentSeed returns the active seed pair without using a nonce.
+	CurrentSeed(userID string) (*FairSeed, error)
+	// RotateSeed retires the active pair (returned, nil if there was none) and activates next.
+	// It returns ErrGameActive without changing anything while one of seededGameTypes is
+	// still open, since the revealed seed would give its outcome away.
+	RotateSeed(userID string, next *FairSeed) (*FairSeed, error)
+	// GetSeed returns any seed pair by id, active or retired.
+	GetSeed(id int64) (*FairSeed, error)
+
+	// LastDailyClaim returns ErrNotFound if the user never claimed.
+	LastDailyClaim(userID string) (*DailyClaim, error)
+	// AddDailyClaim records the claim and credits the reward, returning
+	// ErrAlreadyClaimed if the user already has a claim for that date.
+	AddDailyClaim(c DailyClaim) error
+
+	// Grant credits amount to a user out of the house on an admin's behalf and returns the new balance.
+	Grant(adminID, userID string, amount Money) (Money, error)
+	// Reconcile recomputes every balance from the ledger and reports disagreements.
+	Reconcile() (*ReconcileReport, error)
+
+	Close() error
+}
+
+// openStore opens the backend selected by driver ("mysql" or "memory").
+func openStore(driver, dsn string) (Store, error) {
+	switch driver {
+	case "", "mysql":
+		return openMySQLStore(dsn)
+	case "memory":
+		return newMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown store driver %q", driver)
+	}
+}
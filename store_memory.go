@@ -0,0 +1,799 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryStore is an in-process Store for running the bot locally without a
+// MySQL server. Nothing survives a restart.
+type memoryStore struct {
+	mu           sync.Mutex
+	users        map[string]*User
+	active       map[string]map[string]memActive // userID -> type -> game
+	games        []memGame
+	transactions []memTransaction
+	daily        []DailyClaim
+	ledger       []memLedgerTxn
+	seeds        []*FairSeed      // id = index + 1
+	jackpots     map[string]Money // machine -> pool
+	crashRounds  []*CrashRound    // id = index + 1
+	duels        []*memDuel       // id = index + 1
+}
+
+// memDuel is a coinflip_duels row with when it was created.
+type memDuel struct {
+	Duel
+	Created time.Time
+}
+
+// memActive is an active_games row: the JSON-encoded MinesGame and when it last changed.
+type memActive struct {
+	Data    []byte
+	Updated time.Time
+	Version int64
+}
+
+type memLedgerTxn struct {
+	Reason   string
+	Ref      string
+	Postings []posting
+}
+
+type memGame struct {
+	GameRecord
+	PlayedAt time.Time
+}
+
+type memTransaction struct {
+	SenderID     string
+	SenderName   string
+	ReceiverID   string
+	ReceiverName string
+	Amount       Money
+	Status       string
+	PlayedAt     time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		users:    make(map[string]*User),
+		active:   make(map[string]map[string]memActive),
+		jackpots: make(map[string]Money),
+	}
+}
+
+func (s *memoryStore) Close() error { return nil }
+
+func (s *memoryStore) AddUser(userID, username string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; ok {
+		return false, nil
+	}
+	s.users[userID] = &User{UserID: userID, Username: username, Balance: startingBalance}
+	s.postLedger(reasonSignup, userID,
+		posting{userAccount(userID), startingBalance},
+		posting{houseAccount(reasonSignup), -startingBalance},
+	)
+	return true, nil
+}
+
+func (s *memoryStore) GetUser(userID string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (s *memoryStore) Transfer(senderID, senderName, receiverID, receiverName string, amount Money) (Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	sender, ok := s.users[senderID]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	receiver, ok := s.users[receiverID]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	if sender.Balance < amount {
+		return 0, ErrInsufficientBalance
+	}
+	sender.Balance -= amount
+	receiver.Balance += amount
+	s.logTransaction(senderID, senderName, receiverID, receiverName, amount, "Success")
+	s.postLedger(reasonTransfer, strconv.Itoa(len(s.transactions)),
+		posting{userAccount(senderID), -amount},
+		posting{userAccount(receiverID), amount},
+	)
+	return receiver.Balance, nil
+}
+
+func (s *memoryStore) LogTransaction(senderID, senderName, receiverID, receiverName string, amount Money, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logTransaction(senderID, senderName, receiverID, receiverName, amount, status)
+	return nil
+}
+
+func (s *memoryStore) logTransaction(senderID, senderName, receiverID, receiverName string, amount Money, status string) {
+	s.transactions = append(s.transactions, memTransaction{
+		SenderID:     senderID,
+		SenderName:   senderName,
+		ReceiverID:   receiverID,
+		ReceiverName: receiverName,
+		Amount:       amount,
+		Status:       status,
+		PlayedAt:     time.Now(),
+	})
+}
+
+func (s *memoryStore) SaveActiveGame(game *MinesGame) error {
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.active[game.UserID][game.Type]
+	if !ok || row.Version != game.Version {
+		return ErrGameChanged
+	}
+	s.active[game.UserID][game.Type] = memActive{Data: data, Updated: time.Now(), Version: row.Version + 1}
+	game.Version++
+	return nil
+}
+
+// setActive stores an active game row; callers hold s.mu.
+func (s *memoryStore) setActive(userID, gameType string, data []byte) {
+	if s.active[userID] == nil {
+		s.active[userID] = make(map[string]memActive)
+	}
+	s.active[userID][gameType] = memActive{Data: data, Updated: time.Now()}
+}
+
+func (s *memoryStore) StartActiveGame(game *MinesGame) error {
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[game.UserID]
+	if !ok || u.Balance < game.BetAmount {
+		return ErrInsufficientBalance
+	}
+	if _, ok := s.active[game.UserID][game.Type]; ok {
+		return ErrGameActive
+	}
+	u.Balance -= game.BetAmount
+	s.setActive(game.UserID, game.Type, data)
+	s.postLedger(reasonEscrow, game.UserID,
+		posting{userAccount(game.UserID), -game.BetAmount},
+		posting{escrowAccount, game.BetAmount},
+	)
+	return nil
+}
+
+func (s *memoryStore) GetActiveGame(userID, gameType string) (*MinesGame, error) {
+	s.mu.Lock()
+	row, ok := s.active[userID][gameType]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var game MinesGame
+	if err := json.Unmarshal(row.Data, &game); err != nil {
+		return nil, err
+	}
+	game.Version = row.Version
+	// slot guards carry no board
+	if game.Type == "mines" {
+		if err := game.checkSize(); err != nil {
+			return nil, fmt.Errorf("%s's %s game: %w", userID, gameType, err)
+		}
+	}
+	return &game, nil
+}
+
+func (s *memoryStore) StaleActiveGames(gameType string, idle time.Duration) ([]*MinesGame, error) {
+	s.mu.Lock()
+	var stale []memActive
+	for _, byType := range s.active {
+		if row, ok := byType[gameType]; ok && time.Since(row.Updated) > idle {
+			stale = append(stale, row)
+		}
+	}
+	s.mu.Unlock()
+
+	games := make([]*MinesGame, 0, len(stale))
+	for _, row := range stale {
+		var game MinesGame
+		if err := json.Unmarshal(row.Data, &game); err != nil {
+			return nil, err
+		}
+		game.Version = row.Version
+		games = append(games, &game)
+	}
+	return games, nil
+}
+
+func (s *memoryStore) StartBlackjack(game *BlackjackGame) error {
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[game.UserID]
+	if !ok || u.Balance < game.Bet {
+		return ErrInsufficientBalance
+	}
+	if _, ok := s.active[game.UserID]["blackjack"]; ok {
+		return ErrGameActive
+	}
+	u.Balance -= game.Bet
+	s.setActive(game.UserID, "blackjack", data)
+	s.postLedger(reasonEscrow, game.UserID,
+		posting{userAccount(game.UserID), -game.Bet},
+		posting{escrowAccount, game.Bet},
+	)
+	return nil
+}
+
+func (s *memoryStore) SaveBlackjack(game *BlackjackGame, raise Money) error {
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.active[game.UserID]["blackjack"]
+	if !ok || row.Version != game.Version {
+		return ErrGameChanged
+	}
+	if raise > 0 {
+		u := s.users[game.UserID]
+		if u.Balance < raise {
+			return ErrInsufficientBalance
+		}
+		u.Balance -= raise
+		s.postLedger(reasonEscrow, game.UserID,
+			posting{userAccount(game.UserID), -raise},
+			posting{escrowAccount, raise},
+		)
+	}
+	s.active[game.UserID]["blackjack"] = memActive{Data: data, Updated: time.Now(), Version: row.Version + 1}
+	game.Version++
+	return nil
+}
+
+func (s *memoryStore) GetBlackjack(userID string) (*BlackjackGame, error) {
+	s.mu.Lock()
+	row, ok := s.active[userID]["blackjack"]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var game BlackjackGame
+	if err := json.Unmarshal(row.Data, &game); err != nil {
+		return nil, err
+	}
+	game.Version = row.Version
+	return &game, nil
+}
+
+func (s *memoryStore) StaleBlackjackGames(idle time.Duration) ([]*BlackjackGame, error) {
+	s.mu.Lock()
+	var stale []string
+	for userID, byType := range s.active {
+		if row, ok := byType["blackjack"]; ok && time.Since(row.Updated) > idle {
+			stale = append(stale, userID)
+		}
+	}
+	s.mu.Unlock()
+
+	games := make([]*BlackjackGame, 0, len(stale))
+	for _, userID := range stale {
+		game, err := s.GetBlackjack(userID)
+		if err == ErrNotFound {
+			continue // settled since the scan
+		}
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+func (s *memoryStore) StartCrashBet(userID, username string, bet Money) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.escrowBet(userID, username, "crash", bet)
+}
+
+// escrowBet takes bet into escrow for a game that keeps no state of its own
+// in active_games; callers hold s.mu.
+func (s *memoryStore) escrowBet(userID, username, gameType string, bet Money) error {
+	u, ok := s.users[userID]
+	if !ok || u.Balance < bet {
+		return ErrInsufficientBalance
+	}
+	if _, ok := s.active[userID][gameType]; ok {
+		return ErrGameActive
+	}
+	data, err := json.Marshal(&MinesGame{UserID: userID, Type: gameType, UserName: username, BetAmount: bet})
+	if err != nil {
+		return err
+	}
+	u.Balance -= bet
+	s.setActive(userID, gameType, data)
+	s.postLedger(reasonEscrow, userID,
+		posting{userAccount(userID), -bet},
+		posting{escrowAccount, bet},
+	)
+	return nil
+}
+
+func (s *memoryStore) StartDuel(d *Duel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.escrowBet(d.ChallengerID, d.ChallengerName, "coinflip", d.Amount); err != nil {
+		return err
+	}
+	d.ID, d.Status = int64(len(s.duels)+1), "open"
+	s.duels = append(s.duels, &memDuel{Duel: *d, Created: time.Now()})
+	return nil
+}
+
+// openDuel returns the challenge id if it's still open; callers hold s.mu.
+func (s *memoryStore) openDuel(id int64) (*memDuel, error) {
+	if id < 1 || id > int64(len(s.duels)) || s.duels[id-1].Status != "open" {
+		return nil, ErrNotFound
+	}
+	return s.duels[id-1], nil
+}
+
+func (s *memoryStore) AcceptDuel(d *Duel, results []GameResult) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.openDuel(d.ID)
+	if err != nil {
+		return nil, err
+	}
+	// The challenger's stake must still be there, so nothing fails halfway
+	if _, ok := s.active[stored.ChallengerID]["coinflip"]; !ok {
+		return nil, ErrNotFound
+	}
+	if err := s.escrowBet(d.OpponentID, d.OpponentName, "coinflip", d.Amount); err != nil {
+		return nil, err
+	}
+
+	gameIDs := make([]int64, len(results))
+	for k, r := range results {
+		if gameIDs[k], err = s.settle(r); err != nil {
+			return nil, err
+		}
+	}
+	stored.Status, d.Status = "accepted", "accepted"
+	return gameIDs, nil
+}
+
+func (s *memoryStore) CloseDuel(id int64, status string) (*Duel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.openDuel(id)
+	if err != nil {
+		return nil, err
+	}
+	stored.Status = status
+	d := stored.Duel
+	if _, err := s.settle(d.refund()); err != nil && err != ErrNotFound { // ErrNotFound: the reaper refunded it already
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *memoryStore) GetDuel(id int64) (*Duel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > int64(len(s.duels)) {
+		return nil, ErrNotFound
+	}
+	d := s.duels[id-1].Duel
+	return &d, nil
+}
+
+func (s *memoryStore) StaleDuels(idle time.Duration) ([]*Duel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stale []*Duel
+	for _, stored := range s.duels {
+		if stored.Status == "open" && time.Since(stored.Created) > idle {
+			d := stored.Duel
+			stale = append(stale, &d)
+		}
+	}
+	return stale, nil
+}
+
+func (s *memoryStore) StartCrashRound(round *CrashRound, userID, username string, bet Money) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.escrowBet(userID, username, "crash", bet); err != nil {
+		return err
+	}
+	stored := *round
+	stored.ID = int64(len(s.crashRounds) + 1)
+	s.crashRounds = append(s.crashRounds, &stored)
+	round.ID = stored.ID
+	return nil
+}
+
+func (s *memoryStore) GetCrashRound(id int64) (*CrashRound, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > int64(len(s.crashRounds)) {
+		return nil, ErrNotFound
+	}
+	round := *s.crashRounds[id-1]
+	return &round, nil
+}
+
+func (s *memoryStore) StartActiveSlot(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := s.active[userID]["slot"]; ok {
+		return ErrGameActive
+	}
+	data, err := json.Marshal(&MinesGame{UserID: userID, Type: "slot"})
+	if err != nil {
+		return err
+	}
+	s.setActive(userID, "slot", data)
+	return nil
+}
+
+func (s *memoryStore) TouchActiveSlot(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if row, ok := s.active[userID]["slot"]; ok {
+		row.Updated = time.Now()
+		s.active[userID]["slot"] = row
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteActiveGame(userID, gameType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.active[userID], gameType)
+	return nil
+}
+
+func (s *memoryStore) SettleGame(r GameResult) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.settle(r)
+}
+
+// settle is SettleGame for callers holding s.mu.
+func (s *memoryStore) settle(r GameResult) (int64, error) {
+	u, ok := s.users[r.UserID]
+	if !ok {
+		if r.OneShot {
+			return 0, ErrInsufficientBalance
+		}
+		return 0, ErrNotFound
+	}
+	if r.OneShot && u.Balance < r.Bet {
+		return 0, ErrInsufficientBalance
+	}
+	if r.Escrowed {
+		// Only one settlement may release the stake
+		row, ok := s.active[r.UserID][r.GameType]
+		if !ok {
+			return 0, ErrNotFound
+		}
+		if row.Version != r.Version {
+			return 0, ErrGameChanged
+		}
+		delete(s.active[r.UserID], r.GameType)
+	}
+	if j := r.Jackpot; j != nil {
+		pool := s.jackpots[j.Machine] + j.Fee
+		j.Paid = 0
+		if j.Win {
+			j.Paid, pool = pool, 0
+		}
+		j.Pool = pool
+		s.jackpots[j.Machine] = pool
+		r.Outcome += j.Paid
+	}
+
+	u.Balance += r.credit()
+	if r.Outcome > 0 {
+		u.Wins += r.Outcome
+	} else {
+		u.Losses += r.Outcome.Abs()
+	}
+	gameID := s.logGame(GameRecord{
+		UserID:   r.UserID,
+		GameType: r.GameType,
+		Amount:   r.Bet,
+		Outcome:  r.Outcome,
+		SeedID:   r.SeedID,
+		Nonce:    r.Nonce,
+		Params:   r.Params,
+		RoundID:  r.RoundID,
+	})
+	s.postLedger(reasonGame, strconv.FormatInt(gameID, 10), settlementPostings(r)...)
+	if r.CloseActive {
+		delete(s.active[r.UserID], r.GameType)
+	}
+	return gameID, nil
+}
+
+func (s *memoryStore) SettleCrashRound(round *CrashRound, results []GameResult) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if round.ID < 1 || round.ID > int64(len(s.crashRounds)) || s.crashRounds[round.ID-1].Settled {
+		return nil, ErrNotFound
+	}
+	// Check every bet first, so a failing one leaves the whole round untouched
+	for _, r := range results {
+		if row, ok := s.active[r.UserID][r.GameType]; ok && r.Escrowed && row.Version != r.Version {
+			return nil, ErrGameChanged
+		}
+	}
+
+	stored := s.crashRounds[round.ID-1]
+	stored.CrashPoint, stored.Settled = round.CrashPoint, true
+	gameIDs := make([]int64, len(results))
+	for k, r := range results {
+		gameID, err := s.settle(r)
+		if err == ErrNotFound {
+			continue // refunded by the reaper
+		} else if err != nil {
+			return nil, err
+		}
+		gameIDs[k] = gameID
+	}
+	round.Settled = true
+	return gameIDs, nil
+}
+
+func (s *memoryStore) LogGame(userID, gameType string, amount, outcome Money) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logGame(GameRecord{UserID: userID, GameType: gameType, Amount: amount, Outcome: outcome})
+	return nil
+}
+
+func (s *memoryStore) JackpotPool(machine string) (Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.jackpots[machine], nil
+}
+
+// logGame appends a games row and returns its id; callers hold s.mu.
+func (s *memoryStore) logGame(g GameRecord) int64 {
+	g.ID = int64(len(s.games) + 1)
+	s.games = append(s.games, memGame{GameRecord: g, PlayedAt: time.Now()})
+	return g.ID
+}
+
+func (s *memoryStore) GetGame(id int64) (*GameRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > int64(len(s.games)) {
+		return nil, ErrNotFound
+	}
+	g := s.games[id-1].GameRecord
+	return &g, nil
+}
+
+// activeSeed returns the user's active seed pair; callers hold s.mu.
+func (s *memoryStore) activeSeed(userID string) *FairSeed {
+	for _, seed := range s.seeds {
+		if seed.UserID == userID && seed.Active {
+			return seed
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) UseSeed(userID string) (*FairSeed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seed := s.activeSeed(userID)
+	if seed == nil {
+		return nil, ErrNotFound
+	}
+	used := *seed
+	seed.Nonce++
+	return &used, nil
+}
+
+func (s *memoryStore) CurrentSeed(userID string) (*FairSeed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seed := s.activeSeed(userID)
+	if seed == nil {
+		return nil, ErrNotFound
+	}
+	cp := *seed
+	return &cp, nil
+}
+
+func (s *memoryStore) RotateSeed(userID string, next *FairSeed) (*FairSeed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous *FairSeed
+	if seed := s.activeSeed(userID); seed != nil {
+		for _, gameType := range seededGameTypes {
+			if _, ok := s.active[userID][gameType]; ok {
+				return nil, ErrGameActive
+			}
+		}
+		seed.Active = false
+		cp := *seed
+		previous = &cp
+	}
+
+	added := *next
+	added.ID = int64(len(s.seeds) + 1)
+	added.UserID = userID
+	added.Nonce = 0
+	added.Active = true
+	s.seeds = append(s.seeds, &added)
+	next.ID = added.ID
+	return previous, nil
+}
+
+func (s *memoryStore) GetSeed(id int64) (*FairSeed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id < 1 || id > int64(len(s.seeds)) {
+		return nil, ErrNotFound
+	}
+	cp := *s.seeds[id-1]
+	return &cp, nil
+}
+
+func (s *memoryStore) LastDailyClaim(userID string) (*DailyClaim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.daily) - 1; i >= 0; i-- {
+		if s.daily[i].UserID == userID {
+			c := s.daily[i]
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *memoryStore) AddDailyClaim(c DailyClaim) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[c.UserID]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, d := range s.daily {
+		if d.UserID == c.UserID && d.ClaimDate == c.ClaimDate {
+			return ErrAlreadyClaimed
+		}
+	}
+	s.daily = append(s.daily, c)
+	u.Balance += c.RewardAmount
+	s.postLedger(reasonDaily, strconv.Itoa(len(s.daily)),
+		posting{userAccount(c.UserID), c.RewardAmount},
+		posting{houseAccount(reasonDaily), -c.RewardAmount},
+	)
+	return nil
+}
+
+func (s *memoryStore) Grant(adminID, userID string, amount Money) (Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	u.Balance += amount
+	s.postLedger(reasonAdminGrant, adminID,
+		posting{userAccount(userID), amount},
+		posting{houseAccount(reasonAdminGrant), -amount},
+	)
+	return u.Balance, nil
+}
+
+// postLedger appends a ledger transaction; callers hold s.mu.
+func (s *memoryStore) postLedger(reason, ref string, postings ...posting) {
+	s.ledger = append(s.ledger, memLedgerTxn{Reason: reason, Ref: ref, Postings: postings})
+}
+
+func (s *memoryStore) Reconcile() (*ReconcileReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sums := make(map[string]Money)
+	report := &ReconcileReport{Users: len(s.users)}
+	for idx, txn := range s.ledger {
+		var total Money
+		for _, p := range txn.Postings {
+			sums[p.Account] += p.Amount
+			total += p.Amount
+		}
+		if total != 0 {
+			report.UnbalancedTxns = append(report.UnbalancedTxns, int64(idx+1))
+		}
+	}
+
+	for id, u := range s.users {
+		if ledger := sums[userAccount(id)]; ledger != u.Balance {
+			report.Mismatches = append(report.Mismatches, BalanceMismatch{
+				UserID:  id,
+				Stored:  u.Balance,
+				Ledger:  ledger,
+				Missing: u.Balance - ledger,
+			})
+		}
+	}
+	sort.Slice(report.Mismatches, func(a, b int) bool { return report.Mismatches[a].UserID < report.Mismatches[b].UserID })
+	return report, nil
+}
@@ -0,0 +1,1137 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlStore is the production Store backed by MySQL.
+type mysqlStore struct {
+	db *sql.DB
+}
+
+func openMySQLStore(dsn string) (*mysqlStore, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// verify connection
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database not reachable: %w", err)
+	}
+
+	// Add these for better connection management
+	db.SetMaxOpenConns(500) // max connections MySQL can handle
+	db.SetMaxIdleConns(50)  // keep some idle connections ready
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	// Older versions cleaned up active_games with a MySQL event; runReaper does
+	// that now, and the event would race it and throw stakes away.
+	if _, err = db.Exec(`DROP EVENT IF EXISTS delete_inactive_games`); err != nil {
+		log.Printf("Failed to drop delete_inactive_games event: %v", err)
+	}
+
+	return &mysqlStore{db: db}, nil
+}
+
+func (s *mysqlStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *mysqlStore) AddUser(userID, username string) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT IGNORE INTO users(userid, username, balance, wins, losses, admin) VALUES(?, ?, ?, ?, ?, ?)`,
+		userID, username, startingBalance, 0, 0, 0,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		// should rarely fail, but check anyway
+		log.Println("RowsAffected error:", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if err := postLedgerTx(tx, reasonSignup, userID,
+		posting{userAccount(userID), startingBalance},
+		posting{houseAccount(reasonSignup), -startingBalance},
+	); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (s *mysqlStore) GetUser(userID string) (*User, error) {
+	u := User{UserID: userID}
+	err := s.db.QueryRow(
+		"SELECT username, balance, wins, losses, admin, banned FROM users WHERE userid = ?",
+		userID,
+	).Scan(&u.Username, &u.Balance, &u.Wins, &u.Losses, &u.Admin, &u.Banned)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *mysqlStore) Transfer(senderID, senderName, receiverID, receiverName string, amount Money) (Money, error) {
+	if amount <= 0 {
+		return 0, ErrInvalidAmount
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// The balance check and the debit are one statement, so a racing transfer
+	// or game can't spend the same money twice
+	res, err := tx.Exec(`
+		UPDATE users SET balance = balance - CAST(? AS DECIMAL(19,2))
+		WHERE userid = ? AND balance >= CAST(? AS DECIMAL(19,2))`,
+		amount, senderID, amount)
+	if err != nil {
+		return 0, err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return 0, ErrInsufficientBalance
+	}
+	res, err = tx.Exec("UPDATE users SET balance = balance + CAST(? AS DECIMAL(19,2)) WHERE userid = ?", amount, receiverID)
+	if err != nil {
+		return 0, err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return 0, ErrNotFound
+	}
+
+	var balance Money
+	if err := tx.QueryRow("SELECT balance FROM users WHERE userid = ?", receiverID).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	res, err = tx.Exec(
+		"INSERT INTO transactions (sender, sendername, receiver, receivername, amount, status) VALUES (?, ?, ?, ?, ?, ?)",
+		senderID, senderName, receiverID, receiverName, amount, "Success",
+	)
+	if err != nil {
+		return 0, err
+	}
+	transferID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := postLedgerTx(tx, reasonTransfer, strconv.FormatInt(transferID, 10),
+		posting{userAccount(senderID), -amount},
+		posting{userAccount(receiverID), amount},
+	); err != nil {
+		return 0, err
+	}
+
+	return balance, tx.Commit()
+}
+
+func (s *mysqlStore) LogTransaction(senderID, senderName, receiverID, receiverName string, amount Money, status string) error {
+	_, err := s.db.Exec(
+		"INSERT INTO transactions (sender, sendername, receiver, receivername, amount, status) VALUES (?, ?, ?, ?, ?, ?)",
+		senderID, senderName, receiverID, receiverName, amount, status,
+	)
+	return err
+}
+
+func (s *mysqlStore) SaveActiveGame(game *MinesGame) error {
+	boardJSON, _ := json.Marshal(game.Board)
+	revealedJSON, _ := json.Marshal(game.Revealed)
+
+	res, err := s.db.Exec(`
+        UPDATE active_games
+        SET bet_amount = ?, board = ?, revealed = ?, revealed_safe = ?,
+            game_over = ?, won = ?, current_profit = ?, version = version + 1
+        WHERE userid = ? AND type = ? AND version = ?`,
+		game.BetAmount, boardJSON, revealedJSON, game.RevealedSafe,
+		game.GameOver, game.Won, game.CurrentProfit, game.UserID, game.Type, game.Version)
+	if err != nil {
+		return err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return ErrGameChanged
+	}
+	game.Version++
+	return nil
+}
+
+func (s *mysqlStore) StartActiveGame(game *MinesGame) error {
+	boardJSON, _ := json.Marshal(game.Board)
+	revealedJSON, _ := json.Marshal(game.Revealed)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE users SET balance = balance - CAST(? AS DECIMAL(19,2))
+		WHERE userid = ? AND balance >= CAST(? AS DECIMAL(19,2))`,
+		game.BetAmount, game.UserID, game.BetAmount)
+	if err != nil {
+		return err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return ErrInsufficientBalance
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO active_games (userid,type,username, bet_amount, num_mines, board_size, board, revealed,
+                                safe_spots, revealed_safe, game_over, won, current_profit, seed_id, nonce, rtp)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		game.UserID, game.Type, game.UserName, game.BetAmount, game.NumMines, game.Size, boardJSON, revealedJSON,
+		game.SafeSpots, game.RevealedSafe, game.GameOver, game.Won, game.CurrentProfit, nullID(game.SeedID), game.Nonce, game.rtp().RatString())
+	if isDuplicateKey(err) {
+		return ErrGameActive
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := postLedgerTx(tx, reasonEscrow, game.UserID,
+		posting{userAccount(game.UserID), -game.BetAmount},
+		posting{escrowAccount, game.BetAmount},
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *mysqlStore) GetActiveGame(userID, gameType string) (*MinesGame, error) {
+	var game MinesGame
+	var boardJSON, revealedJSON, rtp string
+
+	err := s.db.QueryRow(`
+        SELECT userid, type, username, bet_amount, num_mines, board_size, board, revealed, safe_spots,
+               revealed_safe, game_over, won, current_profit, COALESCE(seed_id, 0), COALESCE(nonce, 0), rtp, version
+        FROM active_games WHERE userid = ? AND type = ?`, userID, gameType).Scan(
+		&game.UserID, &game.Type, &game.UserName, &game.BetAmount, &game.NumMines, &game.Size, &boardJSON, &revealedJSON,
+		&game.SafeSpots, &game.RevealedSafe, &game.GameOver, &game.Won,
+		&game.CurrentProfit, &game.SeedID, &game.Nonce, &rtp, &game.Version)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(boardJSON), &game.Board); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(revealedJSON), &game.Revealed); err != nil {
+		return nil, err
+	}
+	// slot guards carry no board
+	if game.Type == "mines" {
+		if err := game.checkSize(); err != nil {
+			return nil, fmt.Errorf("%s's %s game: %w", userID, gameType, err)
+		}
+	}
+	var ok bool
+	if game.RTP, ok = new(big.Rat).SetString(rtp); !ok {
+		return nil, fmt.Errorf("invalid rtp %q for %s's %s game", rtp, userID, gameType)
+	}
+	return &game, nil
+}
+
+func (s *mysqlStore) StaleActiveGames(gameType string, idle time.Duration) ([]*MinesGame, error) {
+	userIDs, err := s.staleUserIDs(gameType, idle)
+	if err != nil {
+		return nil, err
+	}
+
+	var games []*MinesGame
+	for _, userID := range userIDs {
+		game, err := s.GetActiveGame(userID, gameType)
+		if err == ErrNotFound {
+			continue // settled since the scan
+		}
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// staleUserIDs lists the users whose gameType game nobody has touched for idle.
+func (s *mysqlStore) staleUserIDs(gameType string, idle time.Duration) ([]string, error) {
+	rows, err := s.db.Query(
+		"SELECT userid FROM active_games WHERE type = ? AND last_updated < NOW() - INTERVAL ? SECOND",
+		gameType, int64(idle/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (s *mysqlStore) StartBlackjack(game *BlackjackGame) error {
+	state, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE users SET balance = balance - CAST(? AS DECIMAL(19,2))
+		WHERE userid = ? AND balance >= CAST(? AS DECIMAL(19,2))`,
+		game.Bet, game.UserID, game.Bet)
+	if err != nil {
+		return err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return ErrInsufficientBalance
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO active_games (userid, type, username, bet_amount, num_mines, board, revealed, state,
+                                safe_spots, revealed_safe, game_over, won, current_profit, seed_id, nonce)
+        VALUES (?, 'blackjack', ?, ?, 0, '[]', '[]', ?, 0, 0, FALSE, FALSE, 0.00, ?, ?)`,
+		game.UserID, game.UserName, game.stake(), state, nullID(game.SeedID), game.Nonce)
+	if isDuplicateKey(err) {
+		return ErrGameActive
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := postLedgerTx(tx, reasonEscrow, game.UserID,
+		posting{userAccount(game.UserID), -game.Bet},
+		posting{escrowAccount, game.Bet},
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *mysqlStore) SaveBlackjack(game *BlackjackGame, raise Money) error {
+	state, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+        UPDATE active_games SET bet_amount = ?, state = ?, game_over = ?, version = version + 1
+        WHERE userid = ? AND type = 'blackjack' AND version = ?`,
+		game.stake(), state, game.Phase == phaseOver, game.UserID, game.Version)
+	if err != nil {
+		return err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return ErrGameChanged
+	}
+
+	if raise > 0 {
+		res, err := tx.Exec(`
+			UPDATE users SET balance = balance - CAST(? AS DECIMAL(19,2))
+			WHERE userid = ? AND balance >= CAST(? AS DECIMAL(19,2))`,
+			raise, game.UserID, raise)
+		if err != nil {
+			return err
+		}
+		if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+			return ErrInsufficientBalance
+		}
+		if err := postLedgerTx(tx, reasonEscrow, game.UserID,
+			posting{userAccount(game.UserID), -raise},
+			posting{escrowAccount, raise},
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	game.Version++
+	return nil
+}
+
+func (s *mysqlStore) GetBlackjack(userID string) (*BlackjackGame, error) {
+	var state []byte
+	var version int64
+	err := s.db.QueryRow("SELECT state, version FROM active_games WHERE userid = ? AND type = 'blackjack'", userID).Scan(&state, &version)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var game BlackjackGame
+	if err := json.Unmarshal(state, &game); err != nil {
+		return nil, fmt.Errorf("%s's blackjack game: %w", userID, err)
+	}
+	game.Version = version
+	return &game, nil
+}
+
+func (s *mysqlStore) StaleBlackjackGames(idle time.Duration) ([]*BlackjackGame, error) {
+	userIDs, err := s.staleUserIDs("blackjack", idle)
+	if err != nil {
+		return nil, err
+	}
+
+	var games []*BlackjackGame
+	for _, userID := range userIDs {
+		game, err := s.GetBlackjack(userID)
+		if err == ErrNotFound {
+			continue // settled since the scan
+		}
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+func (s *mysqlStore) StartCrashBet(userID, username string, bet Money) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := escrowBetTx(tx, userID, username, "crash", bet); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// escrowBetTx takes bet into escrow for a game that keeps no state of its own
+// in active_games, only the row that holds the stake.
+func escrowBetTx(tx *sql.Tx, userID, username, gameType string, bet Money) error {
+	res, err := tx.Exec(`
+		UPDATE users SET balance = balance - CAST(? AS DECIMAL(19,2))
+		WHERE userid = ? AND balance >= CAST(? AS DECIMAL(19,2))`,
+		bet, userID, bet)
+	if err != nil {
+		return err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return ErrInsufficientBalance
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO active_games (userid, type, username, bet_amount, num_mines, board, revealed,
+                                safe_spots, revealed_safe, game_over, won, current_profit)
+        VALUES (?, ?, ?, ?, 0, '[]', '[]', 0, 0, FALSE, FALSE, 0.00)`,
+		userID, gameType, username, bet)
+	if isDuplicateKey(err) {
+		return ErrGameActive
+	}
+	if err != nil {
+		return err
+	}
+
+	return postLedgerTx(tx, reasonEscrow, userID,
+		posting{userAccount(userID), -bet},
+		posting{escrowAccount, bet},
+	)
+}
+
+const duelColumns = "id, challenger, challenger_name, opponent, opponent_name, amount, server_seed, server_seed_hash, status"
+
+func scanDuel(row rowScanner) (*Duel, error) {
+	var d Duel
+	err := row.Scan(&d.ID, &d.ChallengerID, &d.ChallengerName, &d.OpponentID, &d.OpponentName,
+		&d.Amount, &d.ServerSeed, &d.ServerSeedHash, &d.Status)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *mysqlStore) StartDuel(d *Duel) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := escrowBetTx(tx, d.ChallengerID, d.ChallengerName, "coinflip", d.Amount); err != nil {
+		return err
+	}
+	res, err := tx.Exec(`
+		INSERT INTO coinflip_duels (challenger, challenger_name, opponent, opponent_name, amount, server_seed, server_seed_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		d.ChallengerID, d.ChallengerName, d.OpponentID, d.OpponentName, d.Amount, d.ServerSeed, d.ServerSeedHash)
+	if err != nil {
+		return err
+	}
+	if d.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	d.Status = "open"
+	return nil
+}
+
+func (s *mysqlStore) AcceptDuel(d *Duel, results []GameResult) ([]int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Only one of two clicks on Accept gets past this
+	res, err := tx.Exec(
+		"UPDATE coinflip_duels SET status = 'accepted', closed_at = CURRENT_TIMESTAMP WHERE id = ? AND status = 'open'", d.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return nil, ErrNotFound
+	}
+	if err := escrowBetTx(tx, d.OpponentID, d.OpponentName, "coinflip", d.Amount); err != nil {
+		return nil, err
+	}
+
+	gameIDs := make([]int64, len(results))
+	for k, r := range results {
+		if gameIDs[k], err = settleTx(tx, r); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	d.Status = "accepted"
+	return gameIDs, nil
+}
+
+func (s *mysqlStore) CloseDuel(id int64, status string) (*Duel, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	d, err := scanDuel(tx.QueryRow("SELECT "+duelColumns+" FROM coinflip_duels WHERE id = ? FOR UPDATE", id))
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != "open" {
+		return nil, ErrNotFound
+	}
+	if _, err := tx.Exec("UPDATE coinflip_duels SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?", status, id); err != nil {
+		return nil, err
+	}
+	_, err = settleTx(tx, d.refund())
+	if err != nil && err != ErrNotFound { // ErrNotFound: the reaper refunded it already
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	d.Status = status
+	return d, nil
+}
+
+func (s *mysqlStore) GetDuel(id int64) (*Duel, error) {
+	return scanDuel(s.db.QueryRow("SELECT "+duelColumns+" FROM coinflip_duels WHERE id = ?", id))
+}
+
+func (s *mysqlStore) StaleDuels(idle time.Duration) ([]*Duel, error) {
+	rows, err := s.db.Query(
+		"SELECT "+duelColumns+" FROM coinflip_duels WHERE status = 'open' AND created_at <= NOW() - INTERVAL ? SECOND",
+		int64(idle/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var duels []*Duel
+	for rows.Next() {
+		d, err := scanDuel(rows)
+		if err != nil {
+			return nil, err
+		}
+		duels = append(duels, d)
+	}
+	return duels, rows.Err()
+}
+
+func (s *mysqlStore) StartCrashRound(round *CrashRound, userID, username string, bet Money) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := escrowBetTx(tx, userID, username, "crash", bet); err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		"INSERT INTO crash_rounds (channel_id, server_seed, server_seed_hash) VALUES (?, ?, ?)",
+		round.ChannelID, round.ServerSeed, round.ServerSeedHash)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	round.ID = id
+	return nil
+}
+
+func (s *mysqlStore) GetCrashRound(id int64) (*CrashRound, error) {
+	round := CrashRound{ID: id}
+	err := s.db.QueryRow(`
+		SELECT channel_id, server_seed, server_seed_hash, COALESCE(crash_point, 0), settled_at IS NOT NULL
+		FROM crash_rounds WHERE id = ?`, id,
+	).Scan(&round.ChannelID, &round.ServerSeed, &round.ServerSeedHash, &round.CrashPoint, &round.Settled)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &round, nil
+}
+
+func (s *mysqlStore) StartActiveSlot(userID string) error {
+	_, err := s.db.Exec(`
+        INSERT INTO active_games (userid,type,username, bet_amount, num_mines, board, revealed,
+    safe_spots, revealed_safe, game_over, won, current_profit)
+        VALUES (?, ?, ?,0.00, 0, '[]', '[]', 0, 0, FALSE, FALSE, 0.00)`,
+		userID, "slot", "")
+	if isDuplicateKey(err) {
+		return ErrGameActive
+	}
+	return err
+}
+
+func (s *mysqlStore) TouchActiveSlot(userID string) error {
+	_, err := s.db.Exec("UPDATE active_games SET last_updated = NOW() WHERE userid = ? AND type = ?", userID, "slot")
+	return err
+}
+
+func (s *mysqlStore) DeleteActiveGame(userID, gameType string) error {
+	for i := 0; i < 3; i++ {
+		_, err := s.db.Exec("DELETE FROM active_games WHERE userid = ? AND type = ?", userID, gameType)
+		if err == nil {
+			return nil
+		}
+		log.Printf("retry delete attempt %d failed: %v", i+1, err)
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("failed to delete active game for %s after retries", userID)
+}
+
+func (s *mysqlStore) SettleGame(r GameResult) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // rollback on failure
+
+	gameID, err := settleTx(tx, r)
+	if err != nil {
+		return 0, err
+	}
+	return gameID, tx.Commit()
+}
+
+// settleTx is SettleGame inside tx, which the caller commits.
+func settleTx(tx *sql.Tx, r GameResult) (int64, error) {
+	// Closing an escrowed game first makes sure only one settlement pays out its stake
+	if r.Escrowed {
+		res, err := tx.Exec("DELETE FROM active_games WHERE userid = ? AND type = ? AND version = ?", r.UserID, r.GameType, r.Version)
+		if err != nil {
+			return 0, err
+		}
+		if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+			// Still there means it was saved past the version this settles
+			var exists bool
+			if tx.QueryRow("SELECT 1 FROM active_games WHERE userid = ? AND type = ?", r.UserID, r.GameType).Scan(&exists) == nil {
+				return 0, ErrGameChanged
+			}
+			return 0, ErrNotFound
+		}
+	}
+
+	if r.Jackpot != nil {
+		if err := drawJackpot(tx, r.Jackpot); err != nil {
+			return 0, err
+		}
+		r.Outcome += r.Jackpot.Paid
+	}
+
+	stat := "losses"
+	if r.Outcome > 0 {
+		stat = "wins"
+	}
+	query := fmt.Sprintf(`UPDATE users
+		SET balance = balance + CAST(? AS DECIMAL(19,2)),
+		    %[1]s = %[1]s + CAST(? AS DECIMAL(19,2))
+		WHERE userid = ?`, stat)
+	args := []interface{}{r.credit(), r.Outcome.Abs(), r.UserID}
+	if r.OneShot {
+		query += " AND balance >= CAST(? AS DECIMAL(19,2))"
+		args = append(args, r.Bet)
+	}
+
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	// Check if the update affected any rows (handles race condition)
+	if rows, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if rows == 0 {
+		// A settlement that changes nothing (a push, or a one-shot win of
+		// exactly the bet) matches 0 rows too, so look at why
+		var covered bool
+		switch err := tx.QueryRow("SELECT balance >= CAST(? AS DECIMAL(19,2)) FROM users WHERE userid = ?", r.Bet, r.UserID).Scan(&covered); {
+		case err == sql.ErrNoRows && r.OneShot:
+			return 0, ErrInsufficientBalance
+		case err == sql.ErrNoRows:
+			return 0, ErrNotFound
+		case err != nil:
+			return 0, err
+		case r.OneShot && !covered:
+			return 0, ErrInsufficientBalance
+		}
+	}
+
+	res, err = tx.Exec(
+		"INSERT INTO games (userid, game_type, amount, outcome, seed_id, nonce, params, round_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		r.UserID, r.GameType, r.Bet, r.Outcome, nullID(r.SeedID), r.Nonce, r.Params, nullID(r.RoundID),
+	)
+	if err != nil {
+		return 0, err
+	}
+	gameID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := postLedgerTx(tx, reasonGame, strconv.FormatInt(gameID, 10), settlementPostings(r)...); err != nil {
+		return 0, err
+	}
+
+	if r.CloseActive && !r.Escrowed {
+		if _, err := tx.Exec("DELETE FROM active_games WHERE userid = ? AND type = ?", r.UserID, r.GameType); err != nil {
+			return 0, err
+		}
+	}
+	return gameID, nil
+}
+
+func (s *mysqlStore) SettleCrashRound(round *CrashRound, results []GameResult) ([]int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE crash_rounds SET crash_point = ?, settled_at = CURRENT_TIMESTAMP WHERE id = ? AND settled_at IS NULL",
+		round.CrashPoint, round.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return nil, ErrNotFound
+	}
+	// Check every bet's version first, like the memory store, so a stale
+	// round fails with ErrGameChanged before any of it is settled
+	for _, r := range results {
+		var version int64
+		err := tx.QueryRow("SELECT version FROM active_games WHERE userid = ? AND type = ? FOR UPDATE", r.UserID, r.GameType).Scan(&version)
+		if err == nil && r.Escrowed && version != r.Version {
+			return nil, ErrGameChanged
+		} else if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	gameIDs := make([]int64, len(results))
+	for k, r := range results {
+		gameID, err := settleTx(tx, r)
+		if err == ErrNotFound {
+			continue // refunded by the reaper, nothing of it was touched
+		} else if err != nil {
+			return nil, err
+		}
+		gameIDs[k] = gameID
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	round.Settled = true
+	return gameIDs, nil
+}
+
+// drawJackpot pays j.Fee into its pool and, for a win, empties it into j.Paid.
+// The row stays locked until tx ends, so spins on one machine settle one at a time.
+func drawJackpot(tx *sql.Tx, j *JackpotDraw) error {
+	if _, err := tx.Exec("INSERT IGNORE INTO jackpots (machine) VALUES (?)", j.Machine); err != nil {
+		return err
+	}
+	var pool Money
+	if err := tx.QueryRow("SELECT pool FROM jackpots WHERE machine = ? FOR UPDATE", j.Machine).Scan(&pool); err != nil {
+		return err
+	}
+	pool += j.Fee
+	j.Paid = 0
+	if j.Win {
+		j.Paid, pool = pool, 0
+	}
+	j.Pool = pool
+	_, err := tx.Exec(
+		"UPDATE jackpots SET pool = ?, last_won_at = IF(?, CURRENT_TIMESTAMP, last_won_at) WHERE machine = ?",
+		pool, j.Win, j.Machine,
+	)
+	return err
+}
+
+func (s *mysqlStore) JackpotPool(machine string) (Money, error) {
+	var pool Money
+	err := s.db.QueryRow("SELECT pool FROM jackpots WHERE machine = ?", machine).Scan(&pool)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return pool, err
+}
+
+func (s *mysqlStore) LogGame(userID, gameType string, amount, outcome Money) error {
+	_, err := s.db.Exec(
+		"INSERT INTO games (userid, game_type, amount, outcome) VALUES (?, ?, ?, ?)",
+		userID, gameType, amount, outcome,
+	)
+	return err
+}
+
+func (s *mysqlStore) GetGame(id int64) (*GameRecord, error) {
+	g := GameRecord{ID: id}
+	err := s.db.QueryRow(`
+		SELECT userid, game_type, amount, outcome, COALESCE(seed_id, 0), COALESCE(nonce, 0), params, COALESCE(round_id, 0)
+		FROM games WHERE id = ?`, id,
+	).Scan(&g.UserID, &g.GameType, &g.Amount, &g.Outcome, &g.SeedID, &g.Nonce, &g.Params, &g.RoundID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+const seedColumns = "id, userid, server_seed, server_seed_hash, client_seed, nonce, active IS NOT NULL"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSeed(row rowScanner) (*FairSeed, error) {
+	var seed FairSeed
+	err := row.Scan(&seed.ID, &seed.UserID, &seed.ServerSeed, &seed.ServerSeedHash, &seed.ClientSeed, &seed.Nonce, &seed.Active)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &seed, nil
+}
+
+func (s *mysqlStore) UseSeed(userID string) (*FairSeed, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	seed, err := scanSeed(tx.QueryRow("SELECT "+seedColumns+" FROM fairness_seeds WHERE userid = ? AND active = TRUE FOR UPDATE", userID))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("UPDATE fairness_seeds SET nonce = nonce + 1 WHERE id = ?", seed.ID); err != nil {
+		return nil, err
+	}
+	return seed, tx.Commit()
+}
+
+func (s *mysqlStore) CurrentSeed(userID string) (*FairSeed, error) {
+	return scanSeed(s.db.QueryRow("SELECT "+seedColumns+" FROM fairness_seeds WHERE userid = ? AND active = TRUE", userID))
+}
+
+func (s *mysqlStore) RotateSeed(userID string, next *FairSeed) (*FairSeed, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	previous, err := scanSeed(tx.QueryRow("SELECT "+seedColumns+" FROM fairness_seeds WHERE userid = ? AND active = TRUE FOR UPDATE", userID))
+	if err == ErrNotFound {
+		previous = nil
+	} else if err != nil {
+		return nil, err
+	} else {
+		for _, gameType := range seededGameTypes {
+			var exists bool
+			err := tx.QueryRow("SELECT 1 FROM active_games WHERE userid = ? AND type = ? FOR UPDATE", userID, gameType).Scan(&exists)
+			if err == nil {
+				return nil, ErrGameActive
+			} else if err != sql.ErrNoRows {
+				return nil, err
+			}
+		}
+		// active is TRUE or NULL so UNIQUE(userid, active) allows one active pair per user
+		if _, err := tx.Exec("UPDATE fairness_seeds SET active = NULL, revealed_at = NOW() WHERE id = ?", previous.ID); err != nil {
+			return nil, err
+		}
+		previous.Active = false
+	}
+
+	res, err := tx.Exec(`
+		INSERT INTO fairness_seeds (userid, server_seed, server_seed_hash, client_seed, nonce, active)
+		VALUES (?, ?, ?, ?, 0, TRUE)`,
+		userID, next.ServerSeed, next.ServerSeedHash, next.ClientSeed)
+	if err != nil {
+		return nil, err
+	}
+	if next.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return previous, tx.Commit()
+}
+
+func (s *mysqlStore) GetSeed(id int64) (*FairSeed, error) {
+	return scanSeed(s.db.QueryRow("SELECT "+seedColumns+" FROM fairness_seeds WHERE id = ?", id))
+}
+
+func (s *mysqlStore) LastDailyClaim(userID string) (*DailyClaim, error) {
+	c := DailyClaim{UserID: userID}
+	err := s.db.QueryRow(`
+		SELECT streak, claim_date, reward_amount
+		FROM daily_rewards
+		WHERE userid = ?
+		ORDER BY claimed_at DESC
+		LIMIT 1
+	`, userID).Scan(&c.Streak, &c.ClaimDate, &c.RewardAmount)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (s *mysqlStore) AddDailyClaim(c DailyClaim) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// UNIQUE(userid, claim_date) rejects a second claim for the same day
+	res, err := tx.Exec(`
+		INSERT INTO daily_rewards (userid, claim_date, streak, reward_amount)
+		VALUES (?, ?, ?, ?)
+	`, c.UserID, c.ClaimDate, c.Streak, c.RewardAmount)
+	if isDuplicateKey(err) {
+		return ErrAlreadyClaimed
+	}
+	if err != nil {
+		return err
+	}
+	claimID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	res, err = tx.Exec(`
+		UPDATE users
+		SET balance = balance + CAST(? AS DECIMAL(19,2))
+		WHERE userid = ?
+	`, c.RewardAmount, c.UserID)
+	if err != nil {
+		return err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return ErrNotFound
+	}
+
+	if err := postLedgerTx(tx, reasonDaily, strconv.FormatInt(claimID, 10),
+		posting{userAccount(c.UserID), c.RewardAmount},
+		posting{houseAccount(reasonDaily), -c.RewardAmount},
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *mysqlStore) Grant(adminID, userID string, amount Money) (Money, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE users SET balance = balance + CAST(? AS DECIMAL(19,2)) WHERE userid = ?", amount, userID)
+	if err != nil {
+		return 0, err
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return 0, ErrNotFound
+	}
+
+	var balance Money
+	if err := tx.QueryRow("SELECT balance FROM users WHERE userid = ?", userID).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	if err := postLedgerTx(tx, reasonAdminGrant, adminID,
+		posting{userAccount(userID), amount},
+		posting{houseAccount(reasonAdminGrant), -amount},
+	); err != nil {
+		return 0, err
+	}
+
+	return balance, tx.Commit()
+}
+
+// postLedgerTx appends one ledger transaction with its postings inside tx.
+// The ledger is append-only: nothing ever updates or deletes these rows.
+func postLedgerTx(tx *sql.Tx, reason, ref string, postings ...posting) error {
+	res, err := tx.Exec("INSERT INTO ledger_txns (reason, ref) VALUES (?, ?)", reason, ref)
+	if err != nil {
+		return err
+	}
+	txnID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for _, p := range postings {
+		if _, err := tx.Exec(
+			"INSERT INTO ledger_entries (txn_id, account, amount) VALUES (?, ?, ?)",
+			txnID, p.Account, p.Amount,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *mysqlStore) Reconcile() (*ReconcileReport, error) {
+	report := &ReconcileReport{}
+
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&report.Users); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT u.userid, u.balance, COALESCE(SUM(l.amount), 0) AS ledger
+		FROM users u
+		LEFT JOIN ledger_entries l ON l.account = CONCAT('user:', u.userid)
+		GROUP BY u.userid, u.balance
+		HAVING u.balance <> ledger
+		ORDER BY u.userid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m BalanceMismatch
+		if err := rows.Scan(&m.UserID, &m.Stored, &m.Ledger); err != nil {
+			return nil, err
+		}
+		m.Missing = m.Stored - m.Ledger
+		report.Mismatches = append(report.Mismatches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	txns, err := s.db.Query("SELECT txn_id FROM ledger_entries GROUP BY txn_id HAVING SUM(amount) <> 0 ORDER BY txn_id")
+	if err != nil {
+		return nil, err
+	}
+	defer txns.Close()
+	for txns.Next() {
+		var id int64
+		if err := txns.Scan(&id); err != nil {
+			return nil, err
+		}
+		report.UnbalancedTxns = append(report.UnbalancedTxns, id)
+	}
+	return report, txns.Err()
+}
+
+// nullID stores a zero id as NULL.
+func nullID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// isDuplicateKey reports whether err is MySQL's ER_DUP_ENTRY.
+func isDuplicateKey(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == 1062
+}
@@ -0,0 +1,589 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testStoreContract runs the behaviour every Store backend must share against
+// st. Users get fresh ids per run, so a database can be reused.
+func testStoreContract(t *testing.T, st Store) {
+	t.Helper()
+	base := time.Now().UnixNano() / 1000 % 1e12 * 10
+	id := func(n int) string { return strconv.FormatInt(base+int64(n), 10) }
+
+	t.Run("users", func(t *testing.T) {
+		added, err := st.AddUser(id(1), "alice")
+		if err != nil || !added {
+			t.Fatalf("AddUser = %v, %v; want true, nil", added, err)
+		}
+		if added, err := st.AddUser(id(1), "alice"); err != nil || added {
+			t.Fatalf("second AddUser = %v, %v; want false, nil", added, err)
+		}
+		u, err := st.GetUser(id(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Username != "alice" || u.Balance != startingBalance {
+			t.Errorf("GetUser = %+v; want alice with %s", u, startingBalance)
+		}
+		if _, err := st.GetUser(id(9)); err != ErrNotFound {
+			t.Errorf("GetUser(unknown) error = %v; want ErrNotFound", err)
+		}
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		st.AddUser(id(2), "bob")
+		balance, err := st.Transfer(id(1), "alice", id(2), "bob", 250*centsPerUnit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := startingBalance + 250*centsPerUnit; balance != want {
+			t.Errorf("receiver balance = %s; want %s", balance, want)
+		}
+		if u, _ := st.GetUser(id(1)); u.Balance != startingBalance-250*centsPerUnit {
+			t.Errorf("sender balance = %s; want %s", u.Balance, startingBalance-250*centsPerUnit)
+		}
+		if _, err := st.Transfer(id(1), "alice", id(2), "bob", startingBalance); err != ErrInsufficientBalance {
+			t.Errorf("overdrawing transfer error = %v; want ErrInsufficientBalance", err)
+		}
+		for _, amount := range []Money{0, -100} {
+			if _, err := st.Transfer(id(1), "alice", id(2), "bob", amount); err != ErrInvalidAmount {
+				t.Errorf("transfer of %s error = %v; want ErrInvalidAmount", amount, err)
+			}
+		}
+		if u, _ := st.GetUser(id(2)); u.Balance != startingBalance+250*centsPerUnit {
+			t.Errorf("receiver balance after a refused transfer = %s; want it unchanged", u.Balance)
+		}
+	})
+
+	t.Run("one-shot game", func(t *testing.T) {
+		st.AddUser(id(3), "carol")
+		if _, err := st.SettleGame(GameResult{UserID: id(3), GameType: "slot", Bet: 100, Outcome: 400, OneShot: true}); err != nil {
+			t.Fatal(err)
+		}
+		u, _ := st.GetUser(id(3))
+		if u.Balance != startingBalance+400 || u.Wins != 400 {
+			t.Errorf("after a win balance = %s, wins = %s", u.Balance, u.Wins)
+		}
+		if _, err := st.SettleGame(GameResult{UserID: id(3), GameType: "slot", Bet: u.Balance + 1, Outcome: -u.Balance - 1, OneShot: true}); err != ErrInsufficientBalance {
+			t.Errorf("bet over the balance error = %v; want ErrInsufficientBalance", err)
+		}
+		// A win of exactly the bet leaves the users row as it was
+		gameID, err := st.SettleGame(GameResult{UserID: id(3), GameType: "roulette", Bet: 200, Outcome: 0, OneShot: true})
+		if err != nil {
+			t.Fatalf("zero-net settlement error = %v", err)
+		}
+		if g, err := st.GetGame(gameID); err != nil || g.Amount != 200 || g.Outcome != 0 {
+			t.Errorf("GetGame = %+v, %v; want a 2.00 bet with outcome 0", g, err)
+		}
+		if _, err := st.SettleGame(GameResult{UserID: id(3), GameType: "roulette", Bet: u.Balance + 1, Outcome: 0, OneShot: true}); err != ErrInsufficientBalance {
+			t.Errorf("zero-net bet over the balance error = %v; want ErrInsufficientBalance", err)
+		}
+		if _, err := st.SettleGame(GameResult{UserID: id(9), GameType: "slot", Bet: 100, Outcome: 0, OneShot: true}); err != ErrInsufficientBalance {
+			t.Errorf("one-shot settlement for an unknown user error = %v; want ErrInsufficientBalance", err)
+		}
+	})
+
+	t.Run("active game", func(t *testing.T) {
+		st.AddUser(id(4), "dave")
+		game := createMinesGame(id(4), "dave", 500, 3, 5, testSeed, defaultMinesRTP)
+		if err := st.SaveActiveGame(game); err != ErrGameChanged {
+			t.Errorf("saving a game that was never started error = %v; want ErrGameChanged", err)
+		}
+		if err := st.StartActiveGame(game); err != nil {
+			t.Fatal(err)
+		}
+		// A second click loads the game before the first one saves it
+		stale, err := st.GetActiveGame(id(4), "mines")
+		if err != nil {
+			t.Fatal(err)
+		}
+		game.Revealed[0][0] = true
+		if err := st.SaveActiveGame(game); err != nil {
+			t.Fatal(err)
+		}
+		saved, err := st.GetActiveGame(id(4), "mines")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.BetAmount != 500 || saved.NumMines != 3 || saved.Size != 5 || !reflect.DeepEqual(saved.Board, game.Board) ||
+			!saved.Revealed[0][0] || saved.RTP.Cmp(defaultMinesRTP) != 0 || saved.Version != 1 {
+			t.Errorf("GetActiveGame = %+v; want the game as saved", saved)
+		}
+
+		if err := st.SaveActiveGame(stale); err != ErrGameChanged {
+			t.Errorf("saving a stale game error = %v; want ErrGameChanged", err)
+		}
+		if _, err := st.SettleGame(stale.result(-500)); err != ErrGameChanged {
+			t.Errorf("settling a stale game error = %v; want ErrGameChanged", err)
+		}
+		if _, err := st.SettleGame(saved.result(-500)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.GetActiveGame(id(4), "mines"); err != ErrNotFound {
+			t.Errorf("GetActiveGame after settling error = %v; want ErrNotFound", err)
+		}
+		if _, err := st.SettleGame(GameResult{UserID: id(9), GameType: "mines", Bet: 5, Outcome: -5}); err != ErrNotFound {
+			t.Errorf("settling for an unknown user error = %v; want ErrNotFound", err)
+		}
+
+		if err := st.StartActiveSlot(id(4)); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.StartActiveSlot(id(4)); err != ErrGameActive {
+			t.Errorf("second StartActiveSlot error = %v; want ErrGameActive", err)
+		}
+		if err := st.DeleteActiveGame(id(4), "slot"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("escrowed game", func(t *testing.T) {
+		st.AddUser(id(7), "grace")
+		if err := st.StartActiveGame(createMinesGame(id(7), "grace", startingBalance+1, 3, 4, testSeed, defaultMinesRTP)); err != ErrInsufficientBalance {
+			t.Errorf("staking more than the balance error = %v; want ErrInsufficientBalance", err)
+		}
+		game := createMinesGame(id(7), "grace", 300, 3, 4, testSeed, defaultMinesRTP)
+		if err := st.StartActiveGame(game); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.StartActiveGame(game); err != ErrGameActive {
+			t.Errorf("second StartActiveGame error = %v; want ErrGameActive", err)
+		}
+		if u, _ := st.GetUser(id(7)); u.Balance != startingBalance-300 {
+			t.Errorf("balance with the stake in escrow = %s; want %s", u.Balance, startingBalance-300)
+		}
+
+		win := GameResult{UserID: id(7), GameType: "mines", Bet: 300, Outcome: 120, Escrowed: true}
+		if _, err := st.SettleGame(win); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.SettleGame(win); err != ErrNotFound {
+			t.Errorf("settling the same game twice error = %v; want ErrNotFound", err)
+		}
+		if u, _ := st.GetUser(id(7)); u.Balance != startingBalance+120 {
+			t.Errorf("balance after cashing out = %s; want %s", u.Balance, startingBalance+120)
+		}
+	})
+
+	t.Run("daily claims", func(t *testing.T) {
+		st.AddUser(id(5), "erin")
+		if _, err := st.LastDailyClaim(id(5)); err != ErrNotFound {
+			t.Errorf("LastDailyClaim before claiming error = %v; want ErrNotFound", err)
+		}
+		claim := DailyClaim{UserID: id(5), ClaimDate: "2024-01-02", Streak: 2, RewardAmount: 5000}
+		if err := st.AddDailyClaim(claim); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.AddDailyClaim(claim); err != ErrAlreadyClaimed {
+			t.Errorf("claiming the same day twice error = %v; want ErrAlreadyClaimed", err)
+		}
+		last, err := st.LastDailyClaim(id(5))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last.ClaimDate != claim.ClaimDate || last.Streak != 2 || last.RewardAmount != 5000 {
+			t.Errorf("LastDailyClaim = %+v; want %+v", last, claim)
+		}
+		if u, _ := st.GetUser(id(5)); u.Balance != startingBalance+5000 {
+			t.Errorf("balance after claiming = %s; want %s", u.Balance, startingBalance+5000)
+		}
+		if err := st.AddDailyClaim(DailyClaim{UserID: id(9), ClaimDate: "2024-01-02", RewardAmount: 5000}); err != ErrNotFound {
+			t.Errorf("claiming for an unknown user error = %v; want ErrNotFound", err)
+		}
+	})
+
+	t.Run("fairness seeds", func(t *testing.T) {
+		st.AddUser(id(8), "heidi")
+		if _, err := st.UseSeed(id(8)); err != ErrNotFound {
+			t.Errorf("UseSeed without a seed pair error = %v; want ErrNotFound", err)
+		}
+		first, _ := newFairSeed(id(8), "lucky")
+		if previous, err := st.RotateSeed(id(8), first); err != nil || previous != nil {
+			t.Fatalf("first RotateSeed = %v, %v; want nil, nil", previous, err)
+		}
+		for want := int64(0); want < 2; want++ {
+			seed, err := st.UseSeed(id(8))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seed.ID != first.ID || seed.Nonce != want || seed.ServerSeed != first.ServerSeed {
+				t.Errorf("UseSeed = %+v; want seed %d at nonce %d", seed, first.ID, want)
+			}
+		}
+		if current, err := st.CurrentSeed(id(8)); err != nil || current.Nonce != 2 {
+			t.Errorf("CurrentSeed = %+v, %v; want next nonce 2", current, err)
+		}
+
+		gameID, err := st.SettleGame(GameResult{UserID: id(8), GameType: "slot", Bet: 100, Outcome: -100, OneShot: true,
+			SeedID: first.ID, Nonce: 1, Params: "mines=3"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		game, err := st.GetGame(gameID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if game.UserID != id(8) || game.SeedID != first.ID || game.Nonce != 1 || game.Params != "mines=3" || game.Outcome != -100 {
+			t.Errorf("GetGame = %+v; want the settled game", game)
+		}
+
+		// An open mines game still draws from the first pair
+		mines := createMinesGame(id(8), "heidi", 100, 3, 5, first, defaultMinesRTP)
+		if err := st.StartActiveGame(mines); err != nil {
+			t.Fatal(err)
+		}
+		blocked, _ := newFairSeed(id(8), "lucky")
+		if _, err := st.RotateSeed(id(8), blocked); err != ErrGameActive {
+			t.Errorf("RotateSeed during a mines game error = %v; want ErrGameActive", err)
+		}
+		if current, err := st.CurrentSeed(id(8)); err != nil || current.ID != first.ID {
+			t.Errorf("CurrentSeed after a refused rotation = %+v, %v; want the first pair", current, err)
+		}
+		if _, err := st.SettleGame(mines.result(-100)); err != nil {
+			t.Fatal(err)
+		}
+
+		second, _ := newFairSeed(id(8), "lucky")
+		previous, err := st.RotateSeed(id(8), second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if previous == nil || previous.ID != first.ID || previous.ServerSeed != first.ServerSeed || previous.Nonce != 2 || previous.Active {
+			t.Errorf("RotateSeed revealed %+v; want the retired first pair", previous)
+		}
+		if seed, err := st.UseSeed(id(8)); err != nil || seed.ID != second.ID || seed.Nonce != 0 {
+			t.Errorf("UseSeed after rotating = %+v, %v; want the new pair at nonce 0", seed, err)
+		}
+		if seed, err := st.GetSeed(first.ID); err != nil || seed.Active {
+			t.Errorf("GetSeed(retired) = %+v, %v; want an inactive pair", seed, err)
+		}
+	})
+
+	t.Run("jackpot", func(t *testing.T) {
+		st.AddUser(id(10), "heidi")
+		machine := "t" + id(10)
+		if pool, err := st.JackpotPool(machine); err != nil || pool != 0 {
+			t.Fatalf("JackpotPool before any spin = %s, %v; want 0.00", pool, err)
+		}
+		spin := func(fee Money, win bool) *JackpotDraw {
+			t.Helper()
+			j := &JackpotDraw{Machine: machine, Fee: fee, Win: win}
+			if _, err := st.SettleGame(GameResult{UserID: id(10), GameType: "slot", Bet: 100, Outcome: -100, OneShot: true, Jackpot: j}); err != nil {
+				t.Fatal(err)
+			}
+			return j
+		}
+		spin(5, false)
+		if j := spin(5, false); j.Pool != 10 || j.Paid != 0 {
+			t.Errorf("after two fees pool = %s, paid %s; want 0.10 and nothing", j.Pool, j.Paid)
+		}
+		if pool, _ := st.JackpotPool(machine); pool != 10 {
+			t.Errorf("JackpotPool = %s; want 0.10", pool)
+		}
+
+		before, _ := st.GetUser(id(10))
+		if j := spin(5, true); j.Paid != 15 || j.Pool != 0 {
+			t.Errorf("jackpot paid %s leaving %s; want 0.15 leaving 0.00", j.Paid, j.Pool)
+		}
+		if u, _ := st.GetUser(id(10)); u.Balance != before.Balance-100+15 {
+			t.Errorf("balance after the jackpot = %s; want %s", u.Balance, before.Balance-100+15)
+		}
+		if j := spin(0, true); j.Paid != 0 {
+			t.Errorf("second jackpot paid %s; want the emptied pool", j.Paid)
+		}
+	})
+
+	t.Run("blackjack game", func(t *testing.T) {
+		st.AddUser(id(11), "ivan")
+		// 8 8 against a 5 up: a pair to split
+		game := newBlackjackGame(id(11), "ivan", 400, testShoe(8, 5, 8, 10, 3, 10, 10, 9))
+		if err := st.StartBlackjack(game); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.StartBlackjack(game); err != ErrGameActive {
+			t.Errorf("second StartBlackjack error = %v; want ErrGameActive", err)
+		}
+		// The shoe came from the seed, so it stays hidden until the round is over
+		seed, _ := newFairSeed(id(11), "lucky")
+		st.RotateSeed(id(11), seed)
+		next, _ := newFairSeed(id(11), "lucky")
+		if _, err := st.RotateSeed(id(11), next); err != ErrGameActive {
+			t.Errorf("RotateSeed during a blackjack round error = %v; want ErrGameActive", err)
+		}
+		stale, err := st.GetBlackjack(id(11))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		raise, _ := game.split()
+		if err := st.SaveBlackjack(game, raise); err != nil {
+			t.Fatal(err)
+		}
+		saved, err := st.GetBlackjack(id(11))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(saved.Hands) != 2 || saved.stake() != 800 || !reflect.DeepEqual(saved.Shoe, game.Shoe) || saved.Version != 1 {
+			t.Errorf("GetBlackjack = %+v; want the split round as saved", saved)
+		}
+		if err := st.SaveBlackjack(stale, 0); err != ErrGameChanged {
+			t.Errorf("saving a stale round error = %v; want ErrGameChanged", err)
+		}
+		if u, _ := st.GetUser(id(11)); u.Balance != startingBalance-800 {
+			t.Errorf("balance with both hands in escrow = %s; want %s", u.Balance, startingBalance-800)
+		}
+
+		if err := st.SaveBlackjack(saved, startingBalance); err != ErrInsufficientBalance {
+			t.Errorf("raising past the balance error = %v; want ErrInsufficientBalance", err)
+		}
+		raise, _ = game.double()
+		game.stand()
+		if err := st.SaveBlackjack(game, raise); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.SettleGame(game.result()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.GetBlackjack(id(11)); err != ErrNotFound {
+			t.Errorf("GetBlackjack after settling error = %v; want ErrNotFound", err)
+		}
+		if u, _ := st.GetUser(id(11)); u.Balance != startingBalance+1200 {
+			t.Errorf("balance after winning both hands = %s; want %s", u.Balance, startingBalance+1200)
+		}
+	})
+
+	t.Run("crash round", func(t *testing.T) {
+		st.AddUser(id(12), "judy")
+		st.AddUser(id(13), "ken")
+		round := &CrashRound{ChannelID: "42", ServerSeed: testSeed.ServerSeed, ServerSeedHash: testSeed.ServerSeedHash}
+		if err := st.StartCrashRound(round, id(13), "ken", startingBalance+1); err != ErrInsufficientBalance || round.ID != 0 {
+			t.Errorf("starting a round past the balance = %v, id %d; want ErrInsufficientBalance and no round", err, round.ID)
+		}
+		if err := st.StartCrashBet(id(13), "ken", startingBalance+1); err != ErrInsufficientBalance {
+			t.Errorf("betting past the balance error = %v; want ErrInsufficientBalance", err)
+		}
+		if err := st.StartCrashRound(round, id(12), "judy", 200); err != nil || round.ID == 0 {
+			t.Fatalf("StartCrashRound = %v, id %d", err, round.ID)
+		}
+		if err := st.StartCrashBet(id(13), "ken", 200); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.StartCrashBet(id(12), "judy", 200); err != ErrGameActive {
+			t.Errorf("second StartCrashBet error = %v; want ErrGameActive", err)
+		}
+		other := &CrashRound{ChannelID: "43", ServerSeed: testSeed.ServerSeed, ServerSeedHash: testSeed.ServerSeedHash}
+		if err := st.StartCrashRound(other, id(12), "judy", 200); err != ErrGameActive || other.ID != 0 {
+			t.Errorf("starting a second round = %v, id %d; want ErrGameActive and no round", err, other.ID)
+		}
+
+		// ken's bet was refunded as abandoned before the round settled
+		stale, err := st.GetActiveGame(id(13), "crash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.SettleGame(stale.result(0)); err != nil {
+			t.Fatal(err)
+		}
+
+		live := &crashRound{CrashRound: *round, Players: []*crashPlayer{
+			{UserID: id(12), Bet: 200, CashedAt: 250},
+			{UserID: id(13), Bet: 200},
+		}}
+		round.CrashPoint = 300
+		gameIDs, err := st.SettleCrashRound(round, live.results())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(gameIDs) != 2 || gameIDs[0] == 0 || gameIDs[1] != 0 {
+			t.Errorf("game ids = %v; want judy's bet settled and ken's skipped", gameIDs)
+		}
+		if g, err := st.GetGame(gameIDs[0]); err != nil || g.RoundID != round.ID || g.Outcome != 300 {
+			t.Errorf("GetGame = %+v, %v; want +3.00 in round %d", g, err, round.ID)
+		}
+		if u, _ := st.GetUser(id(12)); u.Balance != startingBalance+300 {
+			t.Errorf("balance after cashing out at 2.50x = %s; want %s", u.Balance, startingBalance+300)
+		}
+		if u, _ := st.GetUser(id(13)); u.Balance != startingBalance {
+			t.Errorf("balance after the refund = %s; want %s", u.Balance, startingBalance)
+		}
+		if _, err := st.SettleCrashRound(round, nil); err != ErrNotFound {
+			t.Errorf("settling a round twice error = %v; want ErrNotFound", err)
+		}
+		if saved, err := st.GetCrashRound(round.ID); err != nil || !saved.Settled || saved.CrashPoint != 300 || saved.ServerSeed != testSeed.ServerSeed {
+			t.Errorf("GetCrashRound = %+v, %v; want it settled at 3.00x", saved, err)
+		}
+
+		// A bet saved since the round loaded it fails the whole round
+		next := &CrashRound{ChannelID: "42", ServerSeed: testSeed.ServerSeed, ServerSeedHash: testSeed.ServerSeedHash}
+		if err := st.StartCrashRound(next, id(12), "judy", 200); err != nil {
+			t.Fatal(err)
+		}
+		bet, err := st.GetActiveGame(id(12), "crash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.SaveActiveGame(bet); err != nil {
+			t.Fatal(err)
+		}
+		results := (&crashRound{CrashRound: *next, Players: []*crashPlayer{{UserID: id(12), Bet: 200}}}).results()
+		next.CrashPoint = 150
+		if _, err := st.SettleCrashRound(next, results); err != ErrGameChanged {
+			t.Errorf("settling a stale bet error = %v; want ErrGameChanged", err)
+		}
+		if saved, err := st.GetCrashRound(next.ID); err != nil || saved.Settled {
+			t.Errorf("GetCrashRound = %+v, %v; want the stale round unsettled", saved, err)
+		}
+		results[0].Version = bet.Version
+		if _, err := st.SettleCrashRound(next, results); err != nil {
+			t.Errorf("settling at the saved version error = %v", err)
+		}
+	})
+
+	t.Run("coinflip duel", func(t *testing.T) {
+		st.AddUser(id(14), "leo")
+		st.AddUser(id(15), "mia")
+		d := &Duel{ChallengerID: id(14), ChallengerName: "leo", OpponentID: id(15), OpponentName: "mia", Amount: 300,
+			ServerSeed: testSeed.ServerSeed, ServerSeedHash: testSeed.ServerSeedHash}
+		if err := st.StartDuel(d); err != nil || d.ID == 0 {
+			t.Fatalf("StartDuel = %v, id %d", err, d.ID)
+		}
+		if err := st.StartDuel(&Duel{ChallengerID: id(14), OpponentID: id(15), Amount: 100}); err != ErrGameActive {
+			t.Errorf("second challenge error = %v; want ErrGameActive", err)
+		}
+		if u, _ := st.GetUser(id(14)); u.Balance != startingBalance-300 {
+			t.Errorf("challenger balance = %s; want the stake in escrow", u.Balance)
+		}
+
+		gameIDs, err := st.AcceptDuel(d, d.results(true, mustRat("0.1")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.AcceptDuel(d, d.results(true, mustRat("0.1"))); err != ErrNotFound {
+			t.Errorf("accepting twice error = %v; want ErrNotFound", err)
+		}
+		if _, err := st.CloseDuel(d.ID, "declined"); err != ErrNotFound {
+			t.Errorf("declining an accepted duel error = %v; want ErrNotFound", err)
+		}
+		for k, n := range []int{14, 15} {
+			g, err := st.GetGame(gameIDs[k])
+			if err != nil || g.RoundID != d.ID || g.UserID != id(n) {
+				t.Errorf("GetGame(%d) = %+v, %v; want %s's side of duel %d", gameIDs[k], g, err, id(n), d.ID)
+			}
+		}
+		if u, _ := st.GetUser(id(14)); u.Balance != startingBalance+240 {
+			t.Errorf("winner balance = %s; want the pot less a 60 rake", u.Balance)
+		}
+		if u, _ := st.GetUser(id(15)); u.Balance != startingBalance-300 {
+			t.Errorf("loser balance = %s; want the stake gone", u.Balance)
+		}
+
+		// A challenge nobody takes is refunded
+		d = &Duel{ChallengerID: id(15), ChallengerName: "mia", OpponentID: id(14), OpponentName: "leo", Amount: 200,
+			ServerSeed: testSeed.ServerSeed, ServerSeedHash: testSeed.ServerSeedHash}
+		if err := st.StartDuel(d); err != nil {
+			t.Fatal(err)
+		}
+		if stale, err := st.StaleDuels(0); err != nil || len(stale) != 1 || stale[0].ID != d.ID {
+			t.Errorf("StaleDuels = %v, %v; want the open challenge", stale, err)
+		}
+		closed, err := st.CloseDuel(d.ID, "expired")
+		if err != nil || closed.Status != "expired" {
+			t.Fatalf("CloseDuel = %+v, %v", closed, err)
+		}
+		if u, _ := st.GetUser(id(15)); u.Balance != startingBalance-300 {
+			t.Errorf("balance after the refund = %s; want %s", u.Balance, startingBalance-300)
+		}
+		if stale, _ := st.StaleDuels(0); len(stale) != 0 {
+			t.Errorf("StaleDuels after expiring = %v; want none", stale)
+		}
+	})
+
+	t.Run("grant and reconcile", func(t *testing.T) {
+		st.AddUser(id(6), "frank")
+		balance, err := st.Grant(id(1), id(6), 75*centsPerUnit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := startingBalance + 75*centsPerUnit; balance != want {
+			t.Errorf("balance after grant = %s; want %s", balance, want)
+		}
+		if _, err := st.Grant(id(1), id(9), 100); err != ErrNotFound {
+			t.Errorf("granting to an unknown user error = %v; want ErrNotFound", err)
+		}
+
+		report, err := st.Reconcile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range report.Mismatches {
+			for n := 1; n <= 15; n++ {
+				if m.UserID == id(n) {
+					t.Errorf("user %s doesn't reconcile: %+v", m.UserID, m)
+				}
+			}
+		}
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStoreContract(t, newMemoryStore())
+}
+
+func TestReconcileFindsBrokenLedger(t *testing.T) {
+	st := newMemoryStore()
+	st.AddUser("1", "alice")
+	st.AddUser("2", "bob")
+	st.Transfer("1", "alice", "2", "bob", 100)
+
+	report, err := st.Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Users != 2 || len(report.Mismatches) != 0 || len(report.UnbalancedTxns) != 0 {
+		t.Fatalf("clean ledger report = %+v; want 2 users and nothing out of balance", report)
+	}
+
+	// a balance written around the ledger, and a posting that lost its other side
+	st.users["2"].Balance += 500
+	st.postLedger(reasonAdminGrant, "1", posting{userAccount("1"), 30})
+
+	report, err = st.Reconcile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BalanceMismatch{
+		{UserID: "1", Stored: startingBalance - 100, Ledger: startingBalance - 70, Missing: -30},
+		{UserID: "2", Stored: startingBalance + 600, Ledger: startingBalance + 100, Missing: 500},
+	}
+	if len(report.Mismatches) != len(want) {
+		t.Fatalf("mismatches = %+v; want %+v", report.Mismatches, want)
+	}
+	for k := range want {
+		if report.Mismatches[k] != want[k] {
+			t.Errorf("mismatch %d = %+v; want %+v", k, report.Mismatches[k], want[k])
+		}
+	}
+	if len(report.UnbalancedTxns) != 1 || report.UnbalancedTxns[0] != int64(len(st.ledger)) {
+		t.Errorf("unbalanced txns = %v; want just the last one (%d)", report.UnbalancedTxns, len(st.ledger))
+	}
+}
+
+// TestMySQLStore runs the contract against the scratch database in
+// TEST_MYSQL_DSN, migrated up first.
+func TestMySQLStore(t *testing.T) {
+	openTestMySQL(t)
+	st, err := openMySQLStore(os.Getenv("TEST_MYSQL_DSN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	if _, err := migrateUp(st.db, false); err != nil {
+		t.Fatal(err)
+	}
+	testStoreContract(t, st)
+}